@@ -1,7 +1,11 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"os"
+	"os/signal"
 	"time"
 )
 
@@ -12,17 +16,38 @@ func doWork(id int) {
 }
 
 func main() {
+	workers := flag.Int("workers", 3, "number of worker goroutines")
+	jobs := flag.Int("jobs", 5, "number of jobs to run through the pool")
+	flag.Parse()
+
+	// A pool with no workers would block forever on the first job, and a
+	// negative job count can't size the results channel
+	if *workers < 1 || *jobs < 0 {
+		fmt.Fprintln(os.Stderr, "-workers must be at least 1 and -jobs must not be negative")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Ctrl-C cancels ctx, so the wait below can end early.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
 	t := time.NewTimer(500 * time.Millisecond)
-	for {
-		select {
-		case _, ok := <-t.C:
-			if !ok {
-				return
-			}
-			fmt.Println("time out!")
-			return
-		default:
-			fmt.Println("waiting...")
-		}
+	defer t.Stop()
+
+	// The previous version had a `default` case that printed "waiting..." and
+	// looped straight back into the select. A select with a default never
+	// blocks, so the loop spun thousands of times and pegged a CPU core.
+	// Without a default, select parks the goroutine until one of the channels
+	// is ready and the runtime can schedule other work meanwhile.
+	fmt.Println("waiting...")
+	select {
+	case <-t.C:
+		fmt.Println("time out!")
+	case <-ctx.Done():
+		fmt.Println("cancelled:", ctx.Err())
+		return
 	}
+
+	runWorkerPool(*workers, *jobs)
 }
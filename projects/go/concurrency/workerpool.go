@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type result struct {
+	job      int
+	worker   int
+	duration time.Duration
+}
+
+func worker(id int, jobs <-chan int, results chan<- result, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	// range ends once jobs is closed and drained, which is what lets the
+	// worker exit instead of blocking forever on an empty channel.
+	for job := range jobs {
+		start := time.Now()
+		doWork(job)
+		results <- result{job: job, worker: id, duration: time.Since(start)}
+	}
+}
+
+func runWorkerPool(numWorkers, numJobs int) {
+	jobs := make(chan int)
+	results := make(chan result, numJobs)
+
+	var wg sync.WaitGroup
+	for i := 1; i <= numWorkers; i++ {
+		wg.Add(1)
+		go worker(i, jobs, results, &wg)
+	}
+
+	// Only the sender closes jobs, and only after the last send.
+	go func() {
+		for j := 1; j <= numJobs; j++ {
+			jobs <- j
+		}
+		close(jobs)
+	}()
+
+	// results is closed once every worker has returned, so the range below
+	// terminates. Closing it any earlier would make a worker panic on send.
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	for r := range results {
+		fmt.Printf("Job %d done by worker %d in %s\n", r.job, r.worker, r.duration.Round(time.Millisecond))
+	}
+}
@@ -2,56 +2,84 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
 	"github.com/yourusername/go-starter/internal/api"
+	"github.com/yourusername/go-starter/internal/api/middleware"
+	"github.com/yourusername/go-starter/internal/cache"
 	"github.com/yourusername/go-starter/internal/config"
 	"github.com/yourusername/go-starter/internal/db"
+	"github.com/yourusername/go-starter/internal/mailer"
+	"github.com/yourusername/go-starter/internal/outbox"
+	"github.com/yourusername/go-starter/internal/run"
 )
 
 func main() {
+	configFile := flag.String("config", "", "optional YAML or TOML config file; environment variables override it")
+	flag.Parse()
+
 	// Load .env file
 	_ = godotenv.Load()
 
 	// Load configuration
-	cfg, err := config.Load()
+	loadConfig := func() (*config.Config, error) {
+		if *configFile != "" {
+			return config.LoadFromFile(*configFile)
+		}
+		return config.Load()
+	}
+	cfg, err := loadConfig()
 	if err != nil {
 		log.Fatal("Failed to load configuration:", err)
 	}
 
-	// Initialize logger
-	logLevel := slog.LevelInfo
-	switch cfg.LogLevel {
-	case "debug":
-		logLevel = slog.LevelDebug
-	case "warn":
-		logLevel = slog.LevelWarn
-	case "error":
-		logLevel = slog.LevelError
-	}
+	// Initialize logger. The level lives in a LevelVar so SIGHUP can change
+	// it without rebuilding the handler.
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(cfg.SlogLevel())
 
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+	// ContextHandler adds request_id to every log call made with a request
+	// context
+	logger := slog.New(middleware.NewContextHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
 		Level: logLevel,
-	}))
+	})))
 
 	slog.SetDefault(logger)
 
+	for _, warning := range cfg.Warnings {
+		logger.Warn(warning)
+	}
+
 	// Connect to database
 	ctx := context.Background()
-	dbpool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	poolConfig, err := pgxpool.ParseConfig(cfg.DatabaseURL)
+	if err != nil {
+		logger.Error("Invalid database URL", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	if cfg.SlowQueryThreshold > 0 {
+		poolConfig.ConnConfig.Tracer = db.NewSlowQueryTracer(cfg.SlowQueryThreshold, logger)
+	}
+	dbpool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		logger.Error("Failed to connect to database", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
-	defer dbpool.Close()
+	// Closed at the end of shutdown, once nothing can query it any more
 
 	// Verify database connection
 	if err := dbpool.Ping(ctx); err != nil {
@@ -61,45 +89,222 @@ func main() {
 
 	logger.Info("Connected to database")
 
-	// Initialize dependencies
-	queries := db.New(dbpool)
+	// Listeners and background work (outbox delivery, Redis probing) run in
+	// one group: if any of them fails, the whole server shuts down
+	group := run.NewGroup(ctx, logger)
+
+	// Connect to Redis if configured. It is optional: if it is down the
+	// server starts anyway on in-memory fallbacks and keeps probing, and
+	// /health reports it as degraded.
+	var redisClient *cache.Client
+	if cfg.RedisURL != "" {
+		opts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			logger.Error("Invalid REDIS_URL", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		rdb := redis.NewClient(opts)
+		defer rdb.Close()
+
+		redisClient = cache.NewClient(rdb, logger)
+		if !redisClient.Check(ctx) {
+			logger.Warn("Redis unreachable at startup, continuing in degraded mode")
+		}
+		group.Go("redis probe", func(ctx context.Context) error {
+			redisClient.Run(ctx)
+			return nil
+		})
+	}
+
+	// Set once shutdown starts, failing /health so load balancers stop
+	// sending new requests while in-flight ones drain
+	var draining atomic.Bool
 
 	// Setup router
-	router := api.NewRouter(queries, logger)
+	router := api.NewRouter(cfg, dbpool, redisClient, &draining, logger)
 
 	// Create HTTP server
 	server := &http.Server{
-		Addr:         cfg.ServerAddress,
-		Handler:      router,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
-	}
-
-	// Start server in goroutine
-	go func() {
-		logger.Info("Starting server", slog.String("address", cfg.ServerAddress))
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Error("Server failed to start", slog.String("error", err.Error()))
-			os.Exit(1)
+		Addr:              cfg.ServerAddress,
+		Handler:           router,
+		ReadTimeout:       cfg.ServerReadTimeout,
+		ReadHeaderTimeout: cfg.ServerReadHeaderTimeout,
+		WriteTimeout:      cfg.ServerWriteTimeout,
+		IdleTimeout:       cfg.ServerIdleTimeout,
+		MaxHeaderBytes:    cfg.ServerMaxHeaderBytes,
+	}
+
+	// Start the server. The listener settings are copied first
+	// since cfg is replaced on SIGHUP.
+	tlsEnabled, certFile, keyFile := cfg.TLSEnabled(), cfg.TLSCertFile, cfg.TLSKeyFile
+	shutdownTimeout, drainDelay := cfg.ShutdownTimeout, cfg.ShutdownDrainDelay
+	dbCloseTimeout := cfg.DatabaseCloseTimeout
+	group.Go("http server", func(context.Context) error {
+		var err error
+		if tlsEnabled {
+			server.TLSConfig = tlsConfig()
+			logger.Info("Starting server", slog.String("address", server.Addr), slog.Bool("tls", true))
+			err = server.ListenAndServeTLS(certFile, keyFile)
+		} else {
+			logger.Info("Starting server", slog.String("address", server.Addr), slog.Bool("tls", false))
+			err = server.ListenAndServe()
+		}
+		return ignoreServerClosed(err)
+	})
+
+	// Optional plain HTTP listener that sends everyone to HTTPS. Config
+	// validation guarantees TLS is enabled when this is set.
+	var redirectServer *http.Server
+	if cfg.HTTPRedirectAddress != "" {
+		redirectServer = &http.Server{
+			Addr:              cfg.HTTPRedirectAddress,
+			Handler:           api.NewHTTPSRedirect(cfg.ServerAddress),
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       5 * time.Second,
+			WriteTimeout:      5 * time.Second,
+			IdleTimeout:       60 * time.Second,
 		}
-	}()
+		group.Go("https redirect", func(context.Context) error {
+			logger.Info("Starting HTTPS redirect listener", slog.String("address", redirectServer.Addr))
+			return ignoreServerClosed(redirectServer.ListenAndServe())
+		})
+	}
+
+	// Emails are only logged until an SMTP server is configured
+	var mail mailer.Mailer = mailer.NewNoop(logger)
+	if cfg.SMTPHost != "" {
+		mail = mailer.NewSMTP(mailer.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+		})
+	}
 
-	// Wait for interrupt signal for graceful shutdown
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	// Deliver outbox events in the background, off the request path: new
+	// users get their welcome email here. The stdout publisher is a
+	// placeholder until a broker is wired in.
+	publisher := outbox.NewWelcomeEmailPublisher(outbox.NewWriterPublisher(os.Stdout), mail, logger)
+	poller := outbox.NewPoller(db.New(dbpool), publisher, logger)
+	group.Go("outbox poller", func(ctx context.Context) error {
+		poller.Run(ctx)
+		return nil
+	})
+
+	// Wait for an interrupt signal or a failed component; SIGHUP reloads the
+	// settings that can change live
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+wait:
+	for {
+		select {
+		case sig := <-signals:
+			if sig != syscall.SIGHUP {
+				break wait
+			}
+			cfg = reloadConfig(logger, logLevel, cfg, loadConfig)
+		case <-group.Done():
+			break wait
+		}
+	}
 
 	logger.Info("Shutting down server...")
 
-	// Graceful shutdown with timeout
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// Flip /health before the listeners close; new connections are still
+	// served during the delay
+	draining.Store(true)
+	if drainDelay > 0 {
+		logger.Info("Draining before shutdown", slog.Duration("delay", drainDelay))
+		time.Sleep(drainDelay)
+	}
+
+	// One deadline covers draining requests and stopping background work
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 	defer cancel()
 
+	failed := false
+	if redirectServer != nil {
+		if err := redirectServer.Shutdown(shutdownCtx); err != nil {
+			logger.Error("Redirect listener forced to shutdown", slog.String("error", err.Error()))
+		}
+	}
+
 	if err := server.Shutdown(shutdownCtx); err != nil {
 		logger.Error("Server forced to shutdown", slog.String("error", err.Error()))
+		failed = true
+	}
+
+	// Stop background work once requests have drained; outbox events still
+	// undelivered are picked up on the next start
+	if err := group.Stop(shutdownCtx); err != nil {
+		logger.Error("Background work forced to stop", slog.String("error", err.Error()))
+		failed = true
+	}
+
+	// Last, so handlers and the outbox poller still draining above never
+	// see a closed pool
+	if !closePool(logger, dbpool.Close, dbCloseTimeout) {
+		failed = true
+	}
+
+	if failed || group.Err() != nil {
 		os.Exit(1)
 	}
 
 	logger.Info("Server exited")
 }
+
+// ignoreServerClosed drops the error a listener returns after Shutdown
+func ignoreServerClosed(err error) error {
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// reloadConfig re-reads the configuration and applies the live settings
+// (config.LiveSettings). Other changed settings are logged as ignored and
+// keep their running value; the returned Config is what is now in effect.
+// A configuration that fails to load or validate is rejected as a whole.
+func reloadConfig(logger *slog.Logger, logLevel *slog.LevelVar, current *config.Config, load func() (*config.Config, error)) *config.Config {
+	next, err := load()
+	if err != nil {
+		logger.Error("config reload failed, keeping current configuration", slog.String("error", err.Error()))
+		return current
+	}
+
+	applied := *current
+	for _, name := range current.Diff(next) {
+		if !slices.Contains(config.LiveSettings, name) {
+			logger.Warn("reload ignored, restart required", slog.String("setting", name))
+			continue
+		}
+
+		switch name {
+		case "LOG_LEVEL":
+			applied.LogLevel = next.LogLevel
+			logLevel.Set(next.SlogLevel())
+		}
+		logger.Info("config reloaded", slog.String("setting", name))
+	}
+
+	return &applied
+}
+
+// tlsConfig requires TLS 1.2 or later. TLS 1.3 suites are not configurable in
+// Go and are all considered safe; for 1.2 only forward-secret AEAD suites are
+// offered.
+func tlsConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		},
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+)
+
+// closeWithin runs stop and waits at most timeout for it to return. It
+// reports whether stop finished in time; if not, stop keeps running in the
+// background and the process is expected to exit anyway.
+func closeWithin(stop func(), timeout time.Duration) (elapsed time.Duration, ok bool) {
+	start := time.Now()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		stop()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return time.Since(start), true
+	case <-timer.C:
+		return time.Since(start), false
+	}
+}
+
+// closePool closes the database pool, which waits for every acquired
+// connection to be released, i.e. for queries still running to finish. A
+// query stuck past timeout is abandoned so shutdown can't hang on it.
+func closePool(logger *slog.Logger, poolClose func(), timeout time.Duration) bool {
+	elapsed, ok := closeWithin(poolClose, timeout)
+	if !ok {
+		logger.Error("Database pool did not close in time, abandoning in-flight queries",
+			slog.Duration("timeout", timeout),
+		)
+		return false
+	}
+	logger.Info("Database pool closed", slog.Duration("duration", elapsed))
+	return true
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloseWithin(t *testing.T) {
+	_, ok := closeWithin(func() {}, time.Second)
+	assert.True(t, ok)
+
+	// A query that never finishes must not hold up shutdown
+	stuck := make(chan struct{})
+	defer close(stuck)
+	start := time.Now()
+	_, ok = closeWithin(func() { <-stuck }, 20*time.Millisecond)
+	assert.False(t, ok)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestClosePool_WaitsForInFlightQuery(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// Like pgxpool.Close, returns only once the connection in use is back
+	var queryDone bool
+	release := make(chan struct{})
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		queryDone = true
+		close(release)
+	}()
+
+	ok := closePool(logger, func() { <-release }, time.Second)
+	assert.True(t, ok)
+	assert.True(t, queryDone, "pool closed before the query finished")
+}
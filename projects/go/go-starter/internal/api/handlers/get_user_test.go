@@ -0,0 +1,36 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yourusername/go-starter/internal/api/testutil"
+	"github.com/yourusername/go-starter/internal/repository"
+)
+
+func TestGetUser(t *testing.T) {
+	id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	h := testutil.New(t, repository.User{ID: id, Email: "jane@example.com", Name: "Jane"})
+
+	t.Run("200", func(t *testing.T) {
+		rec := h.Do(http.MethodGet, "/api/v1/users/"+id.String(), "")
+		user := testutil.AssertResource(t, rec, http.StatusOK, "users")
+		assert.Equal(t, id.String(), user.ID)
+		assert.Equal(t, "jane@example.com", user.Attributes["email"])
+		assert.Equal(t, "Jane", user.Attributes["name"])
+		assert.NotContains(t, user.Attributes, "password_hash")
+	})
+
+	t.Run("400 bad UUID", func(t *testing.T) {
+		rec := h.Do(http.MethodGet, "/api/v1/users/not-a-uuid", "")
+		testutil.AssertError(t, rec, http.StatusBadRequest, "INVALID_ID")
+	})
+
+	t.Run("404", func(t *testing.T) {
+		rec := h.Do(http.MethodGet, "/api/v1/users/"+uuid.NewString(), "")
+		testutil.AssertError(t, rec, http.StatusNotFound, "NOT_FOUND")
+	})
+}
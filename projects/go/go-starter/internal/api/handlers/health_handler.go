@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Health statuses, from best to worst
+const (
+	HealthStatusHealthy   = "healthy"
+	HealthStatusDegraded  = "degraded"
+	HealthStatusUnhealthy = "unhealthy"
+	// HealthStatusDraining means the server is shutting down and should get
+	// no new traffic; dependencies are not checked
+	HealthStatusDraining = "draining"
+)
+
+// Dependency check statuses
+const (
+	CheckStatusUp   = "up"
+	CheckStatusDown = "down"
+)
+
+// defaultCheckTimeout bounds each dependency check so /health never hangs
+const defaultCheckTimeout = 2 * time.Second
+
+// HealthCheck describes one dependency probed by /health. A failing
+// required check makes the service unhealthy; a failing optional check only
+// degrades it.
+type HealthCheck struct {
+	Name     string
+	Required bool
+	Check    func(ctx context.Context) error
+}
+
+// CheckResult is the outcome of a single dependency check
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+}
+
+// HealthResponse is the body returned by /health
+type HealthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// HealthHandler reports the health of the service and its dependencies
+type HealthHandler struct {
+	checks   []HealthCheck
+	timeout  time.Duration
+	logger   *slog.Logger
+	draining *atomic.Bool
+}
+
+// NewHealthHandler creates a new HealthHandler
+func NewHealthHandler(logger *slog.Logger, checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{
+		checks:  checks,
+		timeout: defaultCheckTimeout,
+		logger:  logger,
+	}
+}
+
+// SetDraining makes Health answer 503 "draining" whenever draining is set.
+// main sets it as soon as shutdown starts, so load balancers stop routing
+// to the instance while in-flight requests finish.
+func (h *HealthHandler) SetDraining(draining *atomic.Bool) {
+	h.draining = draining
+}
+
+// Health handles GET /health requests
+func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if h.draining != nil && h.draining.Load() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(HealthResponse{Status: HealthStatusDraining, Checks: map[string]CheckResult{}})
+		return
+	}
+
+	results := make(map[string]CheckResult, len(h.checks))
+	status := HealthStatusHealthy
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func(check HealthCheck) {
+			defer wg.Done()
+
+			result := h.run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[check.Name] = result
+			if result.Status == CheckStatusDown {
+				if check.Required {
+					status = HealthStatusUnhealthy
+				} else if status == HealthStatusHealthy {
+					status = HealthStatusDegraded
+				}
+			}
+		}(check)
+	}
+	wg.Wait()
+
+	code := http.StatusOK
+	if status == HealthStatusUnhealthy {
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(HealthResponse{Status: status, Checks: results})
+}
+
+// run executes a single check under its own timeout
+func (h *HealthHandler) run(ctx context.Context, check HealthCheck) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Check(ctx)
+	latency := time.Since(start)
+
+	result := CheckResult{
+		Status:    CheckStatusUp,
+		LatencyMS: float64(latency.Microseconds()) / 1000,
+	}
+
+	if err != nil {
+		// The error stays in the logs; /health is often reachable publicly
+		h.logger.WarnContext(ctx, "health check failed",
+			slog.String("check", check.Name),
+			slog.Bool("required", check.Required),
+			slog.String("error", err.Error()),
+		)
+		result.Status = CheckStatusDown
+	}
+
+	return result
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealth_Status(t *testing.T) {
+	up := func(context.Context) error { return nil }
+	down := func(context.Context) error { return errors.New("connection refused") }
+	hang := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	tests := []struct {
+		name       string
+		checks     []HealthCheck
+		wantCode   int
+		wantStatus string
+		wantChecks map[string]string
+	}{
+		{
+			name:       "no checks",
+			wantCode:   http.StatusOK,
+			wantStatus: HealthStatusHealthy,
+			wantChecks: map[string]string{},
+		},
+		{
+			name: "all up",
+			checks: []HealthCheck{
+				{Name: "database", Required: true, Check: up},
+				{Name: "redis", Check: up},
+			},
+			wantCode:   http.StatusOK,
+			wantStatus: HealthStatusHealthy,
+			wantChecks: map[string]string{"database": CheckStatusUp, "redis": CheckStatusUp},
+		},
+		{
+			name: "optional down degrades",
+			checks: []HealthCheck{
+				{Name: "database", Required: true, Check: up},
+				{Name: "redis", Check: down},
+			},
+			wantCode:   http.StatusOK,
+			wantStatus: HealthStatusDegraded,
+			wantChecks: map[string]string{"database": CheckStatusUp, "redis": CheckStatusDown},
+		},
+		{
+			name: "required down is unhealthy",
+			checks: []HealthCheck{
+				{Name: "database", Required: true, Check: down},
+				{Name: "redis", Check: up},
+			},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: HealthStatusUnhealthy,
+			wantChecks: map[string]string{"database": CheckStatusDown, "redis": CheckStatusUp},
+		},
+		{
+			name: "unhealthy wins over degraded",
+			checks: []HealthCheck{
+				{Name: "database", Required: true, Check: down},
+				{Name: "redis", Check: down},
+			},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: HealthStatusUnhealthy,
+			wantChecks: map[string]string{"database": CheckStatusDown, "redis": CheckStatusDown},
+		},
+		{
+			name: "timed out check is down",
+			checks: []HealthCheck{
+				{Name: "database", Required: true, Check: hang},
+			},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: HealthStatusUnhealthy,
+			wantChecks: map[string]string{"database": CheckStatusDown},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHealthHandler(slog.New(slog.NewTextHandler(io.Discard, nil)), tt.checks...)
+			h.timeout = 10 * time.Millisecond
+
+			rec := httptest.NewRecorder()
+			h.Health(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			var body HealthResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, tt.wantStatus, body.Status)
+
+			got := make(map[string]string, len(body.Checks))
+			for name, result := range body.Checks {
+				got[name] = result.Status
+			}
+			assert.Equal(t, tt.wantChecks, got)
+		})
+	}
+}
+
+func TestHealth_Draining(t *testing.T) {
+	var checked atomic.Int32
+	h := NewHealthHandler(slog.New(slog.NewTextHandler(io.Discard, nil)), HealthCheck{
+		Name:     "database",
+		Required: true,
+		Check: func(context.Context) error {
+			checked.Add(1)
+			return nil
+		},
+	})
+	var draining atomic.Bool
+	h.SetDraining(&draining)
+
+	get := func() (int, HealthResponse) {
+		rec := httptest.NewRecorder()
+		h.Health(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+		var body HealthResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return rec.Code, body
+	}
+
+	code, body := get()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, HealthStatusHealthy, body.Status)
+	assert.EqualValues(t, 1, checked.Load())
+
+	draining.Store(true)
+	code, body = get()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, HealthStatusDraining, body.Status)
+	assert.EqualValues(t, 1, checked.Load(), "no dependency checks while draining")
+}
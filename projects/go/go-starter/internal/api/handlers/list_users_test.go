@@ -0,0 +1,87 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/go-starter/internal/api/testutil"
+	"github.com/yourusername/go-starter/internal/models"
+	"github.com/yourusername/go-starter/internal/repository"
+)
+
+func seedUsers() []repository.User {
+	at := func(minute int) pgtype.Timestamptz {
+		return pgtype.Timestamptz{Time: time.Date(2026, 1, 1, 0, minute, 0, 0, time.UTC), Valid: true}
+	}
+	return []repository.User{
+		{ID: uuid.New(), Email: "jane@example.com", Name: "Jane Doe", CreatedAt: at(0)},
+		{ID: uuid.New(), Email: "john@example.com", Name: "John Doe", CreatedAt: at(1)},
+		{ID: uuid.New(), Email: "ada@example.com", Name: "Ada Lovelace", CreatedAt: at(2)},
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	h := testutil.New(t, seedUsers()...)
+
+	rec := h.Do(http.MethodGet, "/api/v1/users?limit=2&filter[name]=doe", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var doc struct {
+		Data []testutil.Resource `json:"data"`
+		Meta map[string]any      `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	require.Len(t, doc.Data, 2)
+	// Newest first
+	assert.Equal(t, "john@example.com", doc.Data[0].Attributes["email"])
+	assert.Equal(t, "jane@example.com", doc.Data[1].Attributes["email"])
+	assert.EqualValues(t, 2, doc.Meta["total"])
+}
+
+func TestListUsers_Offset(t *testing.T) {
+	h := testutil.New(t)
+
+	t.Run("largest offset", func(t *testing.T) {
+		rec := h.Do(http.MethodGet, "/api/v1/users?offset=2147483647", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("400 offset over int32", func(t *testing.T) {
+		rec := h.Do(http.MethodGet, "/api/v1/users?offset=2147483648", "")
+		testutil.AssertError(t, rec, http.StatusBadRequest, "INVALID_PAGINATION")
+	})
+}
+
+func TestCountUsers(t *testing.T) {
+	h := testutil.New(t, seedUsers()...)
+
+	t.Run("200 admin", func(t *testing.T) {
+		rec := h.Do(http.MethodGet, "/api/v1/users/count?filter[name]=doe", "", h.AuthHeader(models.RoleAdmin))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var doc struct {
+			Data struct {
+				Count int `json:"count"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+		assert.Equal(t, 2, doc.Data.Count)
+	})
+
+	t.Run("401 without a token", func(t *testing.T) {
+		rec := h.Do(http.MethodGet, "/api/v1/users/count", "")
+		testutil.AssertError(t, rec, http.StatusUnauthorized, "UNAUTHORIZED")
+	})
+
+	t.Run("403 for users", func(t *testing.T) {
+		rec := h.Do(http.MethodGet, "/api/v1/users/count", "", h.AuthHeader(models.RoleUser))
+		testutil.AssertError(t, rec, http.StatusForbidden, "FORBIDDEN")
+	})
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"mime"
+	"net/http"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/go-starter/internal/api/jsonapi"
+	"github.com/yourusername/go-starter/internal/models"
+	"github.com/yourusername/go-starter/internal/service"
+)
+
+// isMergePatch reports whether r carries a JSON Merge Patch body
+// (RFC 7386) instead of a JSON:API document
+func isMergePatch(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == jsonapi.MergePatchMediaType
+}
+
+// mergePatchUser applies a JSON Merge Patch such as {"name":"Ada"} to the
+// user's mutable fields. There is no data.meta here, so the version must
+// come in If-Match. Members naming anything but a mutable field, and null
+// for a field that can't be cleared, are 422s pointing at the member. errs
+// holds what UpdateUser already found wrong, e.g. the id, and is reported
+// along with problems of the patch.
+func (h *UserHandler) mergePatchUser(w http.ResponseWriter, r *http.Request, id uuid.UUID, errs *errorList) {
+	ctx := r.Context()
+
+	var input service.UpdateUserInput
+	var patch map[string]json.RawMessage
+	if err := decodeJSON(w, r, &patch); err != nil {
+		h.logFailure(ctx, slog.LevelWarn, "invalid merge patch body",
+			slog.String("error", err.Error()),
+		)
+		errs.add(ctx, http.StatusBadRequest, "INVALID_BODY", err.Error())
+	} else if patch == nil {
+		// A non-object patch would replace the whole user
+		errs.add(ctx, http.StatusBadRequest, "INVALID_BODY", "a merge patch must be a JSON object")
+	} else {
+		var invalid models.ValidationErrors
+		input, invalid = userMergePatch(patch)
+		if len(invalid) > 0 {
+			h.logFailure(ctx, slog.LevelInfo, "invalid input",
+				slog.String("error", invalid.Error()),
+			)
+			errs.append(fieldErrors(ctx, "", invalid)...)
+		}
+	}
+
+	version, err := requestVersion(r, nil)
+	if errors.Is(err, errVersionRequired) {
+		errs.add(ctx, http.StatusPreconditionRequired, "VERSION_REQUIRED", "Send the current version in If-Match")
+	} else if err != nil {
+		errs.add(ctx, http.StatusBadRequest, "INVALID_VERSION", err.Error())
+	}
+
+	if !errs.empty() {
+		errs.respond(ctx, w)
+		return
+	}
+
+	user, err := h.userService.UpdateUser(ctx, id, version, input)
+	if err != nil {
+		h.writeAppErrorAt(ctx, w, "", err, slog.String("id", id.String()), slog.Int("version", int(version)))
+		return
+	}
+
+	h.logger.InfoContext(ctx, "user updated successfully",
+		slog.String("id", id.String()),
+		slog.Int("version", int(user.Version)),
+	)
+
+	respondUser(ctx, w, http.StatusOK, user)
+}
+
+// userMergePatch turns the members of a merge patch into an update. Name
+// and email are required, so null, which would clear them, is rejected
+// like members for fields that can't be updated.
+func userMergePatch(patch map[string]json.RawMessage) (service.UpdateUserInput, models.ValidationErrors) {
+	var input service.UpdateUserInput
+	var errs models.ValidationErrors
+
+	fields := make([]string, 0, len(patch))
+	for field := range patch {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		var dst **string
+		switch field {
+		case "name":
+			dst = &input.Name
+		case "email":
+			dst = &input.Email
+		default:
+			errs = append(errs, models.ValidationError{Field: field, Message: "is not updatable"})
+			continue
+		}
+
+		raw := patch[field]
+		if bytes.Equal(raw, []byte("null")) {
+			errs = append(errs, models.ValidationError{Field: field, Message: "cannot be cleared"})
+			continue
+		}
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			errs = append(errs, models.ValidationError{Field: field, Message: "must be a string"})
+			continue
+		}
+		*dst = &value
+	}
+
+	return input, errs
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/go-starter/internal/api/jsonapi"
+	"github.com/yourusername/go-starter/internal/api/middleware"
+)
+
+// maxBodyBytes caps request bodies so a client can't exhaust memory
+const maxBodyBytes = 1 << 20
+
+// decodeOptions are the settings decodeOption functions change
+type decodeOptions struct {
+	useNumber bool
+}
+
+// decodeOption configures decodeJSON
+type decodeOption func(*decodeOptions)
+
+// withUseNumber decodes numbers that land in an interface{} (free-form
+// maps, untyped attributes) as json.Number instead of float64, which can't
+// hold integers above 2^53 or decimals such as 0.1 exactly. The handler
+// then converts each value itself, with Int64 or by parsing String, and
+// reports out-of-range input as a validation error.
+//
+// Enable it on handlers whose body has untyped numeric values that must
+// round-trip exactly: money, large IDs, counters. Typed fields don't need
+// it; an int64 or json.Number field is decoded exactly either way.
+func withUseNumber() decodeOption {
+	return func(o *decodeOptions) {
+		o.useNumber = true
+	}
+}
+
+// decodeJSON reads a single JSON document from the request body into dst.
+// Unknown fields are rejected so typos in attribute names don't pass
+// silently.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}, opts ...decodeOption) error {
+	var options decodeOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if options.useNumber {
+		dec.UseNumber()
+	}
+
+	if err := dec.Decode(dst); err != nil {
+		var maxErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxErr):
+			return fmt.Errorf("request body must not exceed %d bytes", maxErr.Limit)
+		case errors.Is(err, io.EOF):
+			return errors.New("request body must not be empty")
+		default:
+			return fmt.Errorf("request body is not valid JSON: %w", err)
+		}
+	}
+
+	if dec.More() {
+		return errors.New("request body must contain a single JSON document")
+	}
+
+	return nil
+}
+
+// checkResourceType compares a resource object's type member with the type
+// the endpoint serves. JSON:API requires 409 Conflict for a mismatch; a
+// missing type is a malformed body. pointer locates the member, e.g.
+// /data/type. ok is true when the type matches.
+func checkResourceType(ctx context.Context, pointer, got, want string) (status int, e JSONAPIError, ok bool) {
+	switch got {
+	case want:
+		return 0, JSONAPIError{}, true
+	case "":
+		status = http.StatusBadRequest
+		e = jsonapi.NewError(middleware.GetRequestID(ctx), status, "INVALID_BODY", "type is required")
+	default:
+		status = http.StatusConflict
+		e = jsonapi.NewError(middleware.GetRequestID(ctx), status, "TYPE_MISMATCH",
+			fmt.Sprintf("type must be %q, got %q", want, got))
+	}
+	e.Source = &JSONAPIErrorSource{Pointer: pointer}
+	return status, e, false
+}
+
+// errVersionRequired means the client sent neither If-Match nor a version
+// in the body
+var errVersionRequired = errors.New("version required")
+
+// requestVersion returns the resource version the client based its change
+// on. If-Match takes precedence over data.meta.version; weak and quoted
+// entity tags are accepted since the version is what we hand out as ETag.
+func requestVersion(r *http.Request, meta *VersionMeta) (int32, error) {
+	if raw := r.Header.Get("If-Match"); raw != "" {
+		tag := strings.Trim(strings.TrimPrefix(strings.TrimSpace(raw), "W/"), `"`)
+		version, err := strconv.ParseInt(tag, 10, 32)
+		if err != nil || version < 1 {
+			return 0, fmt.Errorf("If-Match must be a single version number, got %q", raw)
+		}
+		return int32(version), nil
+	}
+
+	if meta != nil && meta.Version != nil {
+		if *meta.Version < 1 {
+			return 0, errors.New("data.meta.version must be a positive integer")
+		}
+		return *meta.Version, nil
+	}
+
+	return 0, errVersionRequired
+}
+
+// parseFilters reads JSON:API filter[field]=value query parameters. Fields
+// outside allowed are an error; empty values are dropped.
+func parseFilters(r *http.Request, allowed []string) (map[string]string, error) {
+	filters := make(map[string]string)
+
+	for key, values := range r.URL.Query() {
+		if key != "filter" && !strings.HasPrefix(key, "filter[") {
+			continue
+		}
+
+		field, ok := strings.CutPrefix(key, "filter[")
+		field, closed := strings.CutSuffix(field, "]")
+		if !ok || !closed || field == "" {
+			return nil, fmt.Errorf("malformed filter parameter %q, expected filter[field]=value", key)
+		}
+		if !slices.Contains(allowed, field) {
+			return nil, fmt.Errorf("unknown filter %q, allowed filters are: %s", field, strings.Join(allowed, ", "))
+		}
+		if len(values) > 1 {
+			return nil, fmt.Errorf("filter %q must be given only once", field)
+		}
+
+		if value := strings.TrimSpace(values[0]); value != "" {
+			filters[field] = value
+		}
+	}
+
+	return filters, nil
+}
+
+// parseInclude reads a JSON:API include parameter such as "profile" and
+// returns the requested relationships without repeats. Relationships
+// outside allowed, including nested paths like "profile.user", are errors.
+// A missing include parameter yields nil.
+func parseInclude(r *http.Request, allowed []string) ([]string, error) {
+	raw := r.URL.Query().Get("include")
+	if raw == "" {
+		return nil, nil
+	}
+
+	var include []string
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if !slices.Contains(allowed, item) {
+			return nil, fmt.Errorf("cannot include %q, includable relationships are: %s", item, strings.Join(allowed, ", "))
+		}
+		if !slices.Contains(include, item) {
+			include = append(include, item)
+		}
+	}
+
+	return include, nil
+}
+
+// sortKey is one field of a JSON:API sort parameter
+type sortKey struct {
+	Field string
+	Desc  bool
+}
+
+// parseSort reads a JSON:API sort parameter such as "name,-created_at".
+// Fields outside allowed, empty fields and repeated fields are errors. A
+// missing sort parameter yields nil.
+func parseSort(r *http.Request, allowed []string) ([]sortKey, error) {
+	raw := r.URL.Query().Get("sort")
+	if raw == "" {
+		return nil, nil
+	}
+
+	var keys []sortKey
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		field, desc := strings.CutPrefix(item, "-")
+		if field == "" {
+			return nil, errors.New("sort must be a comma-separated list of fields, each optionally prefixed with -")
+		}
+		if !slices.Contains(allowed, field) {
+			return nil, fmt.Errorf("cannot sort by %q, sortable fields are: %s", field, strings.Join(allowed, ", "))
+		}
+		if seen[field] {
+			return nil, fmt.Errorf("sort field %q is repeated", field)
+		}
+		seen[field] = true
+		keys = append(keys, sortKey{Field: field, Desc: desc})
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeJSON_UseNumber(t *testing.T) {
+	// 2^53 + 1 has no exact float64
+	const body = `{"amount":9007199254740993,"price":0.1}`
+
+	decode := func(opts ...decodeOption) map[string]interface{} {
+		var dst map[string]interface{}
+		r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		require.NoError(t, decodeJSON(httptest.NewRecorder(), r, &dst, opts...))
+		return dst
+	}
+
+	plain := decode()
+	assert.Equal(t, float64(9007199254740992), plain["amount"])
+
+	exact := decode(withUseNumber())
+	require.IsType(t, json.Number(""), exact["amount"])
+	amount, err := exact["amount"].(json.Number).Int64()
+	require.NoError(t, err)
+	assert.Equal(t, int64(9007199254740993), amount)
+	assert.Equal(t, json.Number("0.1"), exact["price"])
+}
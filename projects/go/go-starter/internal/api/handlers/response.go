@@ -1,76 +1,221 @@
 package handlers
 
 import (
-	"encoding/json"
-	"fmt"
+	"context"
 	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/yourusername/go-starter/internal/api/jsonapi"
+	"github.com/yourusername/go-starter/internal/api/middleware"
 )
 
+// retryAfterSeconds is sent with 503 responses so clients back off briefly
+// instead of hammering an overloaded service
+const retryAfterSeconds = "5"
+
+// maxBulkItems caps how many resources a single bulk request may create
+const maxBulkItems = 100
+
+// maxBatchGetIDs caps how many users a single batch-get request may fetch
+const maxBatchGetIDs = 100
+
 // JSONAPIData represents a single resource in JSON:API format
 type JSONAPIData struct {
-	Type       string      `json:"type"`
-	ID         string      `json:"id"`
-	Attributes interface{} `json:"attributes"`
+	Type          string                         `json:"type"`
+	ID            string                         `json:"id"`
+	Attributes    interface{}                    `json:"attributes"`
+	Relationships map[string]JSONAPIRelationship `json:"relationships,omitempty"`
+	Meta          map[string]interface{}         `json:"meta,omitempty"`
+}
+
+// JSONAPIResourceIdentifier points at a resource without embedding it
+type JSONAPIResourceIdentifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
 }
 
-// JSONAPIResponse represents a successful JSON:API response
+// JSONAPIRelationship is one named relationship of a resource. Data is a
+// *JSONAPIResourceIdentifier for a to-one relationship (nil renders as
+// null, meaning "empty") or a []JSONAPIResourceIdentifier for a to-many one.
+type JSONAPIRelationship struct {
+	Data  interface{}            `json:"data"`
+	Links map[string]string      `json:"links,omitempty"`
+	Meta  map[string]interface{} `json:"meta,omitempty"`
+}
+
+// JSONAPIResponse represents a successful JSON:API response. Included
+// carries the related resources of a compound document; build it with
+// dedupeIncluded.
 type JSONAPIResponse struct {
-	Data interface{} `json:"data"`
-	Meta interface{} `json:"meta,omitempty"`
+	Data     interface{}            `json:"data"`
+	Included []JSONAPIData          `json:"included,omitempty"`
+	Meta     map[string]interface{} `json:"meta,omitempty"`
+	Links    map[string]string      `json:"links,omitempty"`
 }
 
-// JSONAPIErrorSource represents the source of an error
-type JSONAPIErrorSource struct {
-	Pointer string `json:"pointer,omitempty"`
+// dedupeIncluded drops repeated resources, keeping the first of each
+// type+id in order. JSON:API allows a resource to appear only once in a
+// compound document, but e.g. several users can share one related post.
+func dedupeIncluded(resources []JSONAPIData) []JSONAPIData {
+	seen := make(map[JSONAPIResourceIdentifier]bool, len(resources))
+	out := make([]JSONAPIData, 0, len(resources))
+	for _, resource := range resources {
+		key := JSONAPIResourceIdentifier{Type: resource.Type, ID: resource.ID}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, resource)
+	}
+	return out
 }
 
+// JSONAPIErrorSource represents the source of an error
+type JSONAPIErrorSource = jsonapi.ErrorSource
+
 // JSONAPIError represents a single error in JSON:API format
-type JSONAPIError struct {
-	Status string                 `json:"status"`
-	Code   string                 `json:"code"`
-	Title  string                 `json:"title"`
-	Detail string                 `json:"detail"`
-	Source *JSONAPIErrorSource    `json:"source,omitempty"`
-	Meta   map[string]interface{} `json:"meta,omitempty"`
-}
+type JSONAPIError = jsonapi.Error
 
 // JSONAPIErrorResponse represents an error response in JSON:API format
-type JSONAPIErrorResponse struct {
-	Errors []JSONAPIError `json:"errors"`
-}
+type JSONAPIErrorResponse = jsonapi.ErrorResponse
 
 // respondJSON writes a JSON:API success response
-func respondJSON(w http.ResponseWriter, status int, data interface{}) {
-	w.Header().Set("Content-Type", "application/vnd.api+json")
-	w.WriteHeader(status)
+func respondJSON(ctx context.Context, w http.ResponseWriter, status int, data interface{}) {
+	if response, ok := data.(JSONAPIResponse); ok {
+		data = withTiming(w, withAPIVersion(ctx, response))
+	}
+
+	jsonapi.Write(ctx, w, middleware.GetRequestID(ctx), status, data)
+}
+
+// respondError writes a JSON:API error response. The error code, status and
+// request ID are logged here, so handlers don't need to log them again.
+func respondError(ctx context.Context, w http.ResponseWriter, status int, code, detail string) {
+	var errs errorList
+	errs.add(ctx, status, code, detail)
+	errs.respond(ctx, w)
+}
+
+// errorList collects the problems found with a request, such as a bad id
+// and a bad body, so that the client learns about all of them in one
+// response rather than one per round trip. The zero value is ready to use.
+type errorList struct {
+	errs []JSONAPIError
+}
+
+// add records an error and returns it for the caller to set e.g. Source
+func (l *errorList) add(ctx context.Context, status int, code, detail string) *JSONAPIError {
+	l.errs = append(l.errs, jsonapi.NewError(middleware.GetRequestID(ctx), status, code, detail))
+	return &l.errs[len(l.errs)-1]
+}
 
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		// If encoding fails, log it but we've already written the header
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+// append records errors built elsewhere, e.g. by fieldErrors
+func (l *errorList) append(errs ...JSONAPIError) {
+	l.errs = append(l.errs, errs...)
+}
+
+// empty reports whether no error has been recorded
+func (l *errorList) empty() bool {
+	return len(l.errs) == 0
+}
+
+// clientErrorPrecedence orders client error statuses by what the client
+// has to fix first: a malformed request, then credentials, the target, the
+// media type, preconditions, conflicts and finally the attribute values.
+// Statuses not listed come after 422.
+var clientErrorPrecedence = []int{
+	http.StatusBadRequest,
+	http.StatusUnauthorized,
+	http.StatusForbidden,
+	http.StatusNotFound,
+	http.StatusUnsupportedMediaType,
+	http.StatusPreconditionRequired,
+	http.StatusPreconditionFailed,
+	http.StatusConflict,
+	http.StatusUnprocessableEntity,
+}
+
+// status is the response status for the recorded errors, always one of
+// theirs: their common status if they agree, 500 if any is a server error,
+// and otherwise the client error that comes first in
+// clientErrorPrecedence, so a 409 STALE_VERSION with a 422 is a 409.
+func (l *errorList) status() int {
+	status := 0
+	for _, e := range l.errs {
+		s, _ := strconv.Atoi(e.Status)
+		switch {
+		case status == 0 || s == status:
+			status = s
+		case s >= http.StatusInternalServerError || status >= http.StatusInternalServerError:
+			return http.StatusInternalServerError
+		case precedence(s) < precedence(status):
+			status = s
+		}
 	}
+	return status
 }
 
-// respondError writes a JSON:API error response
-func respondError(w http.ResponseWriter, reqID string, status int, code, detail string) {
-	w.Header().Set("Content-Type", "application/vnd.api+json")
-	w.WriteHeader(status)
-
-	response := JSONAPIErrorResponse{
-		Errors: []JSONAPIError{
-			{
-				Status: fmt.Sprintf("%d", status),
-				Code:   code,
-				Title:  http.StatusText(status),
-				Detail: detail,
-				Meta: map[string]interface{}{
-					"request_id": reqID,
-				},
-			},
-		},
+// precedence is status's position in clientErrorPrecedence
+func precedence(status int) int {
+	if i := slices.Index(clientErrorPrecedence, status); i >= 0 {
+		return i
 	}
+	return len(clientErrorPrecedence)
+}
+
+// respond writes every recorded error in one JSON:API error document
+func (l *errorList) respond(ctx context.Context, w http.ResponseWriter) {
+	respondErrors(ctx, w, l.status(), l.errs)
+}
 
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		// If encoding fails, there's not much we can do at this point
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+// respondErrors writes a JSON:API error response with several errors
+func respondErrors(ctx context.Context, w http.ResponseWriter, status int, errs []JSONAPIError) {
+	jsonapi.WriteErrors(ctx, w, middleware.GetRequestID(ctx), status, errs)
+}
+
+// withAPIVersion adds meta.api_version when the APIVersion middleware set one
+func withAPIVersion(ctx context.Context, response JSONAPIResponse) JSONAPIResponse {
+	version := jsonapi.APIVersion(ctx)
+	if version == "" {
+		return response
 	}
+
+	meta := make(map[string]interface{}, len(response.Meta)+1)
+	for k, v := range response.Meta {
+		meta[k] = v
+	}
+	meta["api_version"] = version
+	response.Meta = meta
+
+	return response
+}
+
+// withTiming adds meta.timing when the request was sent with ?debug=timing
+func withTiming(w http.ResponseWriter, response JSONAPIResponse) JSONAPIResponse {
+	start, ok := middleware.TimingStart(w)
+	if !ok {
+		return response
+	}
+
+	meta := make(map[string]interface{}, len(response.Meta)+1)
+	for k, v := range response.Meta {
+		meta[k] = v
+	}
+	meta["timing"] = map[string]interface{}{
+		"handler_ms": float64(time.Since(start).Microseconds()) / 1000,
+	}
+	response.Meta = meta
+
+	return response
+}
+
+// requestURL reconstructs the absolute URL the client requested, honouring
+// X-Forwarded-Proto and X-Forwarded-Host from trusted proxies
+func requestURL(r *http.Request, trustedProxies []netip.Prefix) *url.URL {
+	scheme, host := middleware.RequestOrigin(r, trustedProxies)
+	return &url.URL{Scheme: scheme, Host: host, Path: r.URL.Path, RawQuery: r.URL.RawQuery}
 }
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/go-starter/internal/api/jsonapi"
+	"github.com/yourusername/go-starter/internal/api/middleware"
+)
+
+func TestRequestURL(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		want       string
+	}{
+		{
+			name:       "trusted proxy sets scheme and host",
+			remoteAddr: "10.0.0.5:4000",
+			want:       "https://api.example.com/api/v1/users?filter%5Bname%5D=jo&limit=10",
+		},
+		{
+			name:       "untrusted client is ignored",
+			remoteAddr: "203.0.113.7:4000",
+			want:       "http://example.com/api/v1/users?filter%5Bname%5D=jo&limit=10",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/v1/users?filter%5Bname%5D=jo&limit=10", nil)
+			r.RemoteAddr = tt.remoteAddr
+			r.Header.Set("X-Forwarded-Proto", "https")
+			r.Header.Set("X-Forwarded-Host", "api.example.com")
+
+			assert.Equal(t, tt.want, requestURL(r, trusted).String())
+		})
+	}
+}
+
+func TestDedupeIncluded(t *testing.T) {
+	got := dedupeIncluded([]JSONAPIData{
+		{Type: "posts", ID: "1"},
+		{Type: "users", ID: "1"},
+		{Type: "posts", ID: "1", Attributes: "duplicate"},
+		{Type: "posts", ID: "2"},
+	})
+
+	assert.Equal(t, []JSONAPIData{
+		{Type: "posts", ID: "1"},
+		{Type: "users", ID: "1"},
+		{Type: "posts", ID: "2"},
+	}, got)
+}
+
+func TestJSONAPIResponse_CompoundDocument(t *testing.T) {
+	// Without relationships the envelope is unchanged
+	plain, err := json.Marshal(JSONAPIResponse{Data: JSONAPIData{Type: "users", ID: "1", Attributes: map[string]string{}}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"data":{"type":"users","id":"1","attributes":{}}}`, string(plain))
+
+	compound, err := json.Marshal(JSONAPIResponse{
+		Data: JSONAPIData{
+			Type:       "users",
+			ID:         "1",
+			Attributes: map[string]string{},
+			Relationships: map[string]JSONAPIRelationship{
+				"posts":   {Data: []JSONAPIResourceIdentifier{{Type: "posts", ID: "9"}}},
+				"manager": {Data: nil},
+			},
+		},
+		Included: []JSONAPIData{{Type: "posts", ID: "9", Attributes: map[string]string{"title": "Hi"}}},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"data": {
+			"type": "users", "id": "1", "attributes": {},
+			"relationships": {
+				"posts": {"data": [{"type": "posts", "id": "9"}]},
+				"manager": {"data": null}
+			}
+		},
+		"included": [{"type": "posts", "id": "9", "attributes": {"title": "Hi"}}]
+	}`, string(compound))
+}
+
+func TestRespond_APIVersion(t *testing.T) {
+	withVersion := middleware.APIVersion("1.2.0")
+
+	rec := httptest.NewRecorder()
+	withVersion(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		respondJSON(r.Context(), w, http.StatusOK, JSONAPIResponse{Data: []JSONAPIData{}, Meta: map[string]interface{}{"total": 0}})
+	})).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.JSONEq(t, `{"data":[],"meta":{"total":0,"api_version":"1.2.0"}}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	withVersion(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		respondError(r.Context(), w, http.StatusNotFound, "NOT_FOUND", "User not found")
+	})).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Contains(t, rec.Body.String(), `"meta":{"api_version":"1.2.0"}`)
+}
+
+func TestRespond_ErrorIDs(t *testing.T) {
+	serve := func(h func(http.Handler) http.Handler) JSONAPIErrorResponse {
+		rec := httptest.NewRecorder()
+		h(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			respondErrors(r.Context(), w, http.StatusUnprocessableEntity, []JSONAPIError{
+				jsonapi.NewError("req-1", http.StatusUnprocessableEntity, "VALIDATION_ERROR", "email is invalid"),
+				jsonapi.NewError("req-1", http.StatusUnprocessableEntity, "VALIDATION_ERROR", "name is required"),
+			})
+		})).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+		var doc JSONAPIErrorResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+		require.Len(t, doc.Errors, 2)
+		return doc
+	}
+
+	doc := serve(middleware.ErrorIDs)
+	assert.Len(t, doc.Errors[0].ID, 36)
+	assert.Len(t, doc.Errors[1].ID, 36)
+	assert.NotEqual(t, doc.Errors[0].ID, doc.Errors[1].ID)
+
+	rec := httptest.NewRecorder()
+	respondError(context.Background(), rec, http.StatusNotFound, "NOT_FOUND", "User not found")
+	assert.NotContains(t, rec.Body.String(), `"id"`, "off unless enabled")
+}
+
+func TestErrorList_Status(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		want     int
+	}{
+		{"single", []int{http.StatusConflict}, http.StatusConflict},
+		{"all the same", []int{http.StatusUnprocessableEntity, http.StatusUnprocessableEntity}, http.StatusUnprocessableEntity},
+		{"malformed request comes first", []int{http.StatusUnprocessableEntity, http.StatusBadRequest}, http.StatusBadRequest},
+		{"stale version and validation", []int{http.StatusUnprocessableEntity, http.StatusConflict, http.StatusUnprocessableEntity}, http.StatusConflict},
+		{"missing version and conflict", []int{http.StatusConflict, http.StatusPreconditionRequired}, http.StatusPreconditionRequired},
+		{"unlisted status comes last", []int{http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity}, http.StatusUnprocessableEntity},
+		{"server error wins", []int{http.StatusUnprocessableEntity, http.StatusBadRequest, http.StatusServiceUnavailable}, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errs errorList
+			for _, status := range tt.statuses {
+				errs.add(context.Background(), status, "CODE", "detail")
+			}
+			assert.Equal(t, tt.want, errs.status())
+		})
+	}
+}
+
+func TestRespond_EncodeFailure(t *testing.T) {
+	t.Run("success document", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		rec.Header().Set("ETag", `"3"`)
+		respondJSON(context.Background(), rec, http.StatusOK, JSONAPIResponse{
+			Data: []JSONAPIData{{Type: usersType, ID: "1"}},
+			Meta: map[string]interface{}{"broken": make(chan int)},
+		})
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Empty(t, rec.Header().Get("ETag"))
+		assert.NotContains(t, rec.Body.String(), `"data"`, "nothing of the original document is sent")
+
+		var doc JSONAPIErrorResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+		require.Len(t, doc.Errors, 1)
+		assert.Equal(t, "INTERNAL_ERROR", doc.Errors[0].Code)
+	})
+
+	t.Run("error document", func(t *testing.T) {
+		e := jsonapi.NewError("req-1", http.StatusConflict, "CONFLICT", "Conflict")
+		e.Meta["broken"] = func() {}
+
+		rec := httptest.NewRecorder()
+		respondErrors(context.Background(), rec, http.StatusConflict, []JSONAPIError{e})
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "CONFLICT")
+
+		var doc JSONAPIErrorResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+		assert.Equal(t, "INTERNAL_ERROR", doc.Errors[0].Code)
+	})
+}
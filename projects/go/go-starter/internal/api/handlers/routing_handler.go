@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// probeMethods are the methods checked when building the Allow header
+var probeMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+// NotFound handles requests that match no route
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	respondError(r.Context(), w, http.StatusNotFound, "NOT_FOUND", "The requested resource does not exist")
+}
+
+// MethodNotAllowed returns a handler for requests whose path matches a route
+// in routes but whose method doesn't. chi does not pass the allowed methods
+// to custom handlers, so they are recovered by matching the path against
+// each method on a flattened copy of routes, built on first use once every
+// route is registered.
+func MethodNotAllowed(routes chi.Routes) http.HandlerFunc {
+	var (
+		once sync.Once
+		flat *chi.Mux
+	)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() { flat = flatten(routes) })
+
+		path := r.URL.RawPath
+		if path == "" {
+			path = r.URL.Path
+		}
+
+		var allowed []string
+		for _, method := range probeMethods {
+			if flat.Match(chi.NewRouteContext(), method, path) {
+				allowed = append(allowed, method)
+			}
+		}
+		if len(allowed) > 0 {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+		}
+
+		respondError(r.Context(), w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "The "+r.Method+" method is not supported for this resource")
+	}
+}
+
+// flatten registers every route of routes, including those of sub-routers,
+// on one mux without sub-routers. Matching on routes directly would report
+// every method for the bare prefix of a sub-router ("/api/v1/users"), since
+// chi registers a mount point for all methods.
+func flatten(routes chi.Routes) *chi.Mux {
+	flat := chi.NewRouter()
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	// Walk only fails when the callback does
+	_ = chi.Walk(routes, func(method, pattern string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		flat.Method(method, pattern, noop)
+		// A sub-router's "/" route also serves its prefix without the slash
+		if pattern != "/" && strings.HasSuffix(pattern, "/") {
+			flat.Method(method, strings.TrimSuffix(pattern, "/"), noop)
+		}
+		return nil
+	})
+
+	return flat
+}
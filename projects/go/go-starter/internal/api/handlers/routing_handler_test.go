@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRoutingTestRouter mirrors the real router's layout: a top-level route
+// and a sub-router mounted under a prefix
+func newRoutingTestRouter() *chi.Mux {
+	ok := func(http.ResponseWriter, *http.Request) {}
+
+	r := chi.NewRouter()
+	r.NotFound(NotFound)
+	r.MethodNotAllowed(MethodNotAllowed(r))
+	r.Get("/health", ok)
+	r.Route("/api/v1/users", func(r chi.Router) {
+		r.Get("/", ok)
+		r.Head("/", ok)
+		r.Post("/bulk", ok)
+		r.Get("/{id}", ok)
+		r.Patch("/{id}", ok)
+		r.Delete("/{id}", ok)
+	})
+	return r
+}
+
+func TestNotFound(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newRoutingTestRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/widgets", nil))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"code":"NOT_FOUND"`)
+	assert.Empty(t, rec.Header().Get("Allow"))
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		target    string
+		wantAllow string
+	}{
+		{name: "top-level route", method: http.MethodDelete, target: "/health", wantAllow: "GET"},
+		{name: "sub-router prefix", method: http.MethodPut, target: "/api/v1/users", wantAllow: "GET, HEAD"},
+		{name: "sub-router root", method: http.MethodPut, target: "/api/v1/users/", wantAllow: "GET, HEAD"},
+		{name: "parameterized sub-route", method: http.MethodPost, target: "/api/v1/users/42", wantAllow: "GET, PATCH, DELETE"},
+	}
+
+	r := newRoutingTestRouter()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+
+			require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+			assert.Equal(t, tt.wantAllow, rec.Header().Get("Allow"))
+			assert.Contains(t, rec.Body.String(), `"code":"METHOD_NOT_ALLOWED"`)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yourusername/go-starter/internal/api/testutil"
+	"github.com/yourusername/go-starter/internal/models"
+	"github.com/yourusername/go-starter/internal/repository"
+)
+
+func TestUpdateUser(t *testing.T) {
+	id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	body := `{"data":{"type":"users","id":"` + id.String() + `","attributes":{"name":"Jane Roe"}}}`
+	target := "/api/v1/users/" + id.String()
+
+	t.Run("401 without a token", func(t *testing.T) {
+		h := testutil.New(t, repository.User{ID: id, Email: "jane@example.com", Name: "Jane"})
+		rec := h.Do(http.MethodPatch, target, body, `If-Match: "1"`)
+		testutil.AssertError(t, rec, http.StatusUnauthorized, "UNAUTHORIZED")
+	})
+
+	t.Run("403 for another user", func(t *testing.T) {
+		h := testutil.New(t, repository.User{ID: id, Email: "jane@example.com", Name: "Jane"})
+		rec := h.Do(http.MethodPatch, target, body, `If-Match: "1"`, h.AuthHeader(models.RoleUser))
+		testutil.AssertError(t, rec, http.StatusForbidden, "FORBIDDEN")
+
+		rec = h.Do(http.MethodGet, target, "")
+		user := testutil.AssertResource(t, rec, http.StatusOK, "users")
+		assert.Equal(t, "Jane", user.Attributes["name"], "nothing was changed")
+	})
+
+	t.Run("200 for the user themselves", func(t *testing.T) {
+		h := testutil.New(t, repository.User{ID: id, Email: "jane@example.com", Name: "Jane"})
+		rec := h.Do(http.MethodPatch, target, body, `If-Match: "1"`, h.AuthHeaderFor(id, models.RoleUser))
+		user := testutil.AssertResource(t, rec, http.StatusOK, "users")
+		assert.Equal(t, "Jane Roe", user.Attributes["name"])
+	})
+
+	t.Run("200 for an admin", func(t *testing.T) {
+		h := testutil.New(t, repository.User{ID: id, Email: "jane@example.com", Name: "Jane"})
+		rec := h.Do(http.MethodPatch, target, body, `If-Match: "1"`, h.AuthHeader(models.RoleAdmin))
+		user := testutil.AssertResource(t, rec, http.StatusOK, "users")
+		assert.Equal(t, "Jane Roe", user.Attributes["name"])
+	})
+}
@@ -21,11 +21,110 @@ func NewUserResponse(user *repository.User) *UserResponse {
 	}
 }
 
+// usersType is the JSON:API resource type of users
+const usersType = "users"
+
 // ToJSONAPIData converts a user to JSON:API data format
 func ToJSONAPIData(user *repository.User) JSONAPIData {
 	return JSONAPIData{
-		Type:       "users",
+		Type:       usersType,
 		ID:         uuid.UUID(user.ID).String(),
 		Attributes: NewUserResponse(user),
 	}
 }
+
+// ProfileResponse represents the profile data returned in API responses
+type ProfileResponse struct {
+	Bio       string  `json:"bio"`
+	AvatarURL *string `json:"avatar_url"`
+}
+
+// profilesType is the JSON:API resource type of profiles
+const profilesType = "profiles"
+
+// ProfileToJSONAPIData converts a profile to JSON:API data format
+func ProfileToJSONAPIData(profile *repository.Profile) JSONAPIData {
+	return JSONAPIData{
+		Type: profilesType,
+		ID:   profile.ID.String(),
+		Attributes: &ProfileResponse{
+			Bio:       profile.Bio,
+			AvatarURL: profile.AvatarURL,
+		},
+	}
+}
+
+// CreateUserAttributes are the attributes accepted when creating a user
+type CreateUserAttributes struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// CreateUserData is a single user resource object in a create request
+type CreateUserData struct {
+	Type       string               `json:"type"`
+	Attributes CreateUserAttributes `json:"attributes"`
+}
+
+// BulkCreateUsersRequest is the body of POST /api/v1/users/bulk
+type BulkCreateUsersRequest struct {
+	Data []CreateUserData `json:"data"`
+}
+
+// BatchGetUsersRequest is the body of POST /api/v1/users/batch-get: the
+// resource identifiers of the users to fetch
+type BatchGetUsersRequest struct {
+	Data []JSONAPIResourceIdentifier `json:"data"`
+}
+
+// BulkItemResult reports the outcome of one element of a bulk request. Index
+// is the element's position in the request's data array.
+type BulkItemResult struct {
+	Index  int            `json:"index"`
+	Status string         `json:"status"`
+	ID     string         `json:"id,omitempty"`
+	Errors []JSONAPIError `json:"errors,omitempty"`
+}
+
+// UpdateUserAttributes are the attributes accepted by PATCH; omitted fields
+// are left unchanged
+type UpdateUserAttributes struct {
+	Name  *string `json:"name"`
+	Email *string `json:"email"`
+}
+
+// VersionMeta carries the resource version a client last read
+type VersionMeta struct {
+	Version *int32 `json:"version"`
+}
+
+// UpdateUserData is the user resource object in a PATCH request
+type UpdateUserData struct {
+	Type       string               `json:"type"`
+	ID         string               `json:"id"`
+	Attributes UpdateUserAttributes `json:"attributes"`
+	Meta       *VersionMeta         `json:"meta,omitempty"`
+}
+
+// UpdateUserRequest is the body of PATCH /api/v1/users/{id}
+type UpdateUserRequest struct {
+	Data UpdateUserData `json:"data"`
+}
+
+// ChangePasswordAttributes are the attributes of a password change
+type ChangePasswordAttributes struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// ChangePasswordData is the user resource object in a password change
+type ChangePasswordData struct {
+	Type       string                   `json:"type"`
+	Attributes ChangePasswordAttributes `json:"attributes"`
+}
+
+// ChangePasswordRequest is the body of POST /api/v1/users/{id}/password
+type ChangePasswordRequest struct {
+	Data ChangePasswordData `json:"data"`
+}
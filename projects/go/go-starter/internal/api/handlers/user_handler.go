@@ -1,15 +1,24 @@
 package handlers
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
+	"github.com/yourusername/go-starter/internal/api/jsonapi"
 	"github.com/yourusername/go-starter/internal/api/middleware"
+	"github.com/yourusername/go-starter/internal/api/pagination"
 	"github.com/yourusername/go-starter/internal/models"
+	"github.com/yourusername/go-starter/internal/repository"
 	"github.com/yourusername/go-starter/internal/service"
 )
 
@@ -17,68 +26,791 @@ import (
 type UserHandler struct {
 	userService service.UserService
 	logger      *slog.Logger
+
+	// trustedProxies are allowed to tell us the public scheme and host for
+	// absolute links
+	trustedProxies []netip.Prefix
+
+	// quietErrors suppresses the handler's own error-path logs, leaving
+	// only the line respondError emits for each error code
+	quietErrors bool
+}
+
+// UserHandlerOption configures a UserHandler
+type UserHandlerOption func(*UserHandler)
+
+// WithQuietErrors stops the handler from logging its own context on error
+// paths, for deployments that only want the centralized error_code log
+func WithQuietErrors() UserHandlerOption {
+	return func(h *UserHandler) {
+		h.quietErrors = true
+	}
+}
+
+// WithTrustedProxies lets the forwarding headers of these proxies decide the
+// scheme and host used in pagination links
+func WithTrustedProxies(prefixes []netip.Prefix) UserHandlerOption {
+	return func(h *UserHandler) {
+		h.trustedProxies = prefixes
+	}
 }
 
 // NewUserHandler creates a new UserHandler
-func NewUserHandler(userService service.UserService, logger *slog.Logger) *UserHandler {
-	return &UserHandler{
+func NewUserHandler(userService service.UserService, logger *slog.Logger, opts ...UserHandlerOption) *UserHandler {
+	h := &UserHandler{
 		userService: userService,
 		logger:      logger,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
-// GetUser handles GET /api/v1/users/{id} requests
+// userIncludes lists the relationships GetUser can include
+var userIncludes = []string{"profile"}
+
+// GetUser handles GET /api/v1/users/{id} requests. With ?include=profile
+// the user's profile comes back as a relationship plus an included
+// resource, read in the same query as the user.
 func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	reqID := middleware.GetRequestID(ctx)
 
-	// Parse user ID from URL parameter
-	idStr := chi.URLParam(r, "id")
-	if idStr == "" {
-		h.logger.WarnContext(ctx, "missing user id parameter")
-		respondError(w, reqID, http.StatusBadRequest, "INVALID_ID", "User ID is required")
-		return
-	}
+	var errs errorList
+	id := h.userIDParam(r, &errs)
 
-	// Parse and validate UUID
-	id, err := uuid.Parse(idStr)
+	include, err := parseInclude(r, userIncludes)
 	if err != nil {
-		h.logger.WarnContext(ctx, "invalid user id format",
-			slog.String("id", idStr),
+		h.logFailure(ctx, slog.LevelWarn, "invalid include parameter",
 			slog.String("error", err.Error()),
 		)
-		respondError(w, reqID, http.StatusBadRequest, "INVALID_ID", "Invalid user ID format")
+		errs.add(ctx, http.StatusBadRequest, "INVALID_INCLUDE", err.Error())
+	}
+
+	if !errs.empty() {
+		errs.respond(ctx, w)
+		return
+	}
+
+	if slices.Contains(include, "profile") {
+		result, err := h.userService.GetUserWithProfile(ctx, id)
+		if err != nil {
+			h.writeAppError(ctx, w, err, slog.String("id", id.String()))
+			return
+		}
+
+		h.logger.InfoContext(ctx, "user retrieved successfully",
+			slog.String("id", id.String()),
+			slog.Bool("profile", result.Profile != nil),
+		)
+
+		respondUserWithProfile(ctx, w, result)
 		return
 	}
 
 	// Get user from service
 	user, err := h.userService.GetUser(ctx, id)
 	if err != nil {
-		if errors.Is(err, models.ErrNotFound) {
-			h.logger.InfoContext(ctx, "user not found",
-				slog.String("id", id.String()),
-			)
-			respondError(w, reqID, http.StatusNotFound, "NOT_FOUND", "User not found")
-			return
+		h.writeAppError(ctx, w, err, slog.String("id", id.String()))
+		return
+	}
+
+	h.logger.InfoContext(ctx, "user retrieved successfully",
+		slog.String("id", id.String()),
+	)
+
+	respondUser(ctx, w, http.StatusOK, user)
+}
+
+// UpdateUser handles PATCH /api/v1/users/{id} requests. The client must send
+// the version it last read, as If-Match or data.meta.version, so that it
+// can't overwrite a change it hasn't seen.
+func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Everything wrong with the request is reported at once: the id, the
+	// body and the version are checked independently
+	var errs errorList
+	id := h.userIDParam(r, &errs)
+
+	if id != uuid.Nil && middleware.GetUserID(ctx) != id.String() && middleware.GetRole(ctx) != models.RoleAdmin {
+		h.logFailure(ctx, slog.LevelWarn, "update of another user",
+			slog.String("id", id.String()),
+			slog.String("user_id", middleware.GetUserID(ctx)),
+		)
+		respondError(ctx, w, http.StatusForbidden, "FORBIDDEN", "You can only update your own user")
+		return
+	}
+
+	if isMergePatch(r) {
+		h.mergePatchUser(w, r, id, &errs)
+		return
+	}
+
+	var req UpdateUserRequest
+	var input service.UpdateUserInput
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.logFailure(ctx, slog.LevelWarn, "invalid update body",
+			slog.String("error", err.Error()),
+		)
+		errs.add(ctx, http.StatusBadRequest, "INVALID_BODY", err.Error())
+	} else {
+		if _, e, ok := checkResourceType(ctx, "/data/type", req.Data.Type, usersType); !ok {
+			errs.append(e)
 		}
+		input = service.UpdateUserInput{
+			Name:  req.Data.Attributes.Name,
+			Email: req.Data.Attributes.Email,
+		}
+		// Also done by the service; checked here too so that invalid
+		// attributes are reported along with the other problems
+		var invalid models.ValidationErrors
+		if errors.As(input.Validate(), &invalid) {
+			errs.append(fieldErrors(ctx, "/data/attributes", invalid)...)
+		}
+	}
+
+	version, err := requestVersion(r, req.Data.Meta)
+	if errors.Is(err, errVersionRequired) {
+		errs.add(ctx, http.StatusPreconditionRequired, "VERSION_REQUIRED", "Send the current version in If-Match or data.meta.version")
+	} else if err != nil {
+		errs.add(ctx, http.StatusBadRequest, "INVALID_VERSION", err.Error())
+	}
+
+	if !errs.empty() {
+		errs.respond(ctx, w)
+		return
+	}
+
+	user, err := h.userService.UpdateUser(ctx, id, version, input)
+	if err != nil {
+		h.writeAppError(ctx, w, err, slog.String("id", id.String()), slog.Int("version", int(version)))
+		return
+	}
 
-		// Internal server error
-		h.logger.ErrorContext(ctx, "failed to get user",
+	h.logger.InfoContext(ctx, "user updated successfully",
+		slog.String("id", id.String()),
+		slog.Int("version", int(user.Version)),
+	)
+
+	respondUser(ctx, w, http.StatusOK, user)
+}
+
+// ChangePassword handles POST /api/v1/users/{id}/password requests. It must
+// run behind middleware.Authenticate: users may change their own password,
+// admins anyone's.
+func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, ok := h.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if middleware.GetUserID(ctx) != id.String() && middleware.GetRole(ctx) != models.RoleAdmin {
+		h.logFailure(ctx, slog.LevelWarn, "password change for another user",
 			slog.String("id", id.String()),
+			slog.String("user_id", middleware.GetUserID(ctx)),
+		)
+		respondError(ctx, w, http.StatusForbidden, "FORBIDDEN", "You can only change your own password")
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.logFailure(ctx, slog.LevelWarn, "invalid change password body",
 			slog.String("error", err.Error()),
 		)
-		respondError(w, reqID, http.StatusInternalServerError, "INTERNAL_ERROR", "An unexpected error occurred")
+		respondError(ctx, w, http.StatusBadRequest, "INVALID_BODY", err.Error())
 		return
 	}
 
-	// Convert to JSON:API format and respond
-	response := JSONAPIResponse{
-		Data: ToJSONAPIData(user),
+	if status, e, ok := checkResourceType(ctx, "/data/type", req.Data.Type, usersType); !ok {
+		respondErrors(ctx, w, status, []JSONAPIError{e})
+		return
 	}
 
-	h.logger.InfoContext(ctx, "user retrieved successfully",
+	err := h.userService.ChangePassword(ctx, id, service.ChangePasswordInput{
+		CurrentPassword: req.Data.Attributes.CurrentPassword,
+		NewPassword:     req.Data.Attributes.NewPassword,
+	})
+	if err != nil {
+		h.writeAppError(ctx, w, err, slog.String("id", id.String()))
+		return
+	}
+
+	h.logger.InfoContext(ctx, "user password changed",
+		slog.String("id", id.String()),
+	)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteUser handles DELETE /api/v1/users/{id} requests
+func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, ok := h.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.userService.DeleteUser(ctx, id); err != nil {
+		h.writeAppError(ctx, w, err, slog.String("id", id.String()))
+		return
+	}
+
+	h.logger.InfoContext(ctx, "user deleted successfully",
 		slog.String("id", id.String()),
 	)
 
-	respondJSON(w, http.StatusOK, response)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListUsers handles GET /api/v1/users requests
+func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	page, ok := h.parseOffsetPage(w, r)
+	if !ok {
+		return
+	}
+	limit, offset := page.Limit, page.Offset
+
+	filter, ok := h.parseUserFilter(w, r)
+	if !ok {
+		return
+	}
+
+	sort, ok := h.parseUserSort(w, r)
+	if !ok {
+		return
+	}
+
+	users, err := h.userService.ListUsers(ctx, filter, sort, limit, offset)
+	if err != nil {
+		h.writeAppError(ctx, w, err)
+		return
+	}
+
+	// The total rides along in meta so clients don't need a second call
+	total, err := h.userService.CountUsers(ctx, filter)
+	if err != nil {
+		h.writeAppError(ctx, w, err)
+		return
+	}
+
+	data := make([]JSONAPIData, len(users))
+	for i, user := range users {
+		data[i] = ToJSONAPIData(user)
+	}
+
+	respondJSON(ctx, w, http.StatusOK, JSONAPIResponse{
+		Data: data,
+		Meta: map[string]interface{}{
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+		},
+		Links: pagination.Links(requestURL(r, h.trustedProxies), page, int(total)),
+	})
+}
+
+// maxSearchQueryLength bounds the q parameter of SearchUsers
+const maxSearchQueryLength = 200
+
+// SearchUsers handles GET /api/v1/users/search?q=... requests. Results are
+// ranked by relevance, which each item carries as meta.score.
+func (h *UserHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		respondError(ctx, w, http.StatusBadRequest, "INVALID_QUERY", "q must not be empty")
+		return
+	}
+	if len(query) > maxSearchQueryLength {
+		respondError(ctx, w, http.StatusBadRequest, "INVALID_QUERY", fmt.Sprintf("q must be at most %d characters", maxSearchQueryLength))
+		return
+	}
+
+	page, ok := h.parseOffsetPage(w, r)
+	if !ok {
+		return
+	}
+	limit, offset := page.Limit, page.Offset
+
+	results, err := h.userService.SearchUsers(ctx, query, limit, offset)
+	if err != nil {
+		h.writeAppError(ctx, w, err)
+		return
+	}
+
+	data := make([]JSONAPIData, len(results))
+	for i, result := range results {
+		data[i] = ToJSONAPIData(result.User)
+		data[i].Meta = map[string]interface{}{"score": result.Score}
+	}
+
+	respondJSON(ctx, w, http.StatusOK, JSONAPIResponse{
+		Data: data,
+		Meta: map[string]interface{}{
+			"limit":  limit,
+			"offset": offset,
+		},
+	})
+}
+
+// CountUsers handles GET /api/v1/users/count requests. It accepts the same
+// filters as ListUsers.
+func (h *UserHandler) CountUsers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filter, ok := h.parseUserFilter(w, r)
+	if !ok {
+		return
+	}
+
+	count, err := h.userService.CountUsers(ctx, filter)
+	if err != nil {
+		h.writeAppError(ctx, w, err)
+		return
+	}
+
+	respondJSON(ctx, w, http.StatusOK, JSONAPIResponse{
+		Data: map[string]interface{}{
+			"count": count,
+		},
+	})
+}
+
+// BatchGetUsers handles POST /api/v1/users/batch-get requests, fetching
+// several users by ID in one query. Users that don't exist are listed in
+// meta.not_found instead of failing the request.
+func (h *UserHandler) BatchGetUsers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req BatchGetUsersRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.logFailure(ctx, slog.LevelWarn, "invalid batch get body",
+			slog.String("error", err.Error()),
+		)
+		respondError(ctx, w, http.StatusBadRequest, "INVALID_BODY", err.Error())
+		return
+	}
+	if len(req.Data) == 0 {
+		respondError(ctx, w, http.StatusBadRequest, "INVALID_BODY", "data must contain at least one user")
+		return
+	}
+	if len(req.Data) > maxBatchGetIDs {
+		respondError(ctx, w, http.StatusBadRequest, "BATCH_TOO_LARGE", fmt.Sprintf("data must not contain more than %d users", maxBatchGetIDs))
+		return
+	}
+
+	ids := make([]uuid.UUID, 0, len(req.Data))
+	seen := make(map[uuid.UUID]bool, len(req.Data))
+	for i, item := range req.Data {
+		if status, e, ok := checkResourceType(ctx, fmt.Sprintf("/data/%d/type", i), item.Type, usersType); !ok {
+			respondErrors(ctx, w, status, []JSONAPIError{e})
+			return
+		}
+		id, err := uuid.Parse(item.ID)
+		if err != nil {
+			e := jsonapi.NewError(middleware.GetRequestID(ctx), http.StatusBadRequest, "INVALID_ID", "Invalid user ID format")
+			e.Source = &JSONAPIErrorSource{Pointer: fmt.Sprintf("/data/%d/id", i)}
+			respondErrors(ctx, w, http.StatusBadRequest, []JSONAPIError{e})
+			return
+		}
+		// Asking for the same user twice returns it once
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	users, err := h.userService.GetUsers(ctx, ids)
+	if err != nil {
+		h.writeAppError(ctx, w, err, slog.Int("ids", len(ids)))
+		return
+	}
+
+	data := make([]JSONAPIData, len(users))
+	found := make(map[uuid.UUID]bool, len(users))
+	for i, user := range users {
+		data[i] = ToJSONAPIData(user)
+		found[user.ID] = true
+	}
+
+	notFound := []string{}
+	for _, id := range ids {
+		if !found[id] {
+			notFound = append(notFound, id.String())
+		}
+	}
+
+	respondJSON(ctx, w, http.StatusOK, JSONAPIResponse{
+		Data: data,
+		Meta: map[string]interface{}{
+			"not_found": notFound,
+		},
+	})
+}
+
+// Outcomes of a single item in a bulk request
+const (
+	bulkStatusCreated = "created"
+	bulkStatusFailed  = "failed"
+)
+
+// CreateUsersBulk handles POST /api/v1/users/bulk requests. Each element is
+// validated and inserted independently, and meta.results reports the outcome
+// per input index so clients can retry only the failures. With
+// ?atomic=true any failure rejects the whole batch instead.
+func (h *UserHandler) CreateUsersBulk(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	atomic := false
+	if raw := r.URL.Query().Get("atomic"); raw != "" {
+		var err error
+		atomic, err = strconv.ParseBool(raw)
+		if err != nil {
+			respondError(ctx, w, http.StatusBadRequest, "INVALID_PARAMETER", "atomic must be true or false")
+			return
+		}
+	}
+
+	var req BulkCreateUsersRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.logFailure(ctx, slog.LevelWarn, "invalid bulk create body",
+			slog.String("error", err.Error()),
+		)
+		respondError(ctx, w, http.StatusBadRequest, "INVALID_BODY", err.Error())
+		return
+	}
+	if len(req.Data) == 0 {
+		respondError(ctx, w, http.StatusBadRequest, "INVALID_BODY", "data must contain at least one user")
+		return
+	}
+	if len(req.Data) > maxBulkItems {
+		respondError(ctx, w, http.StatusBadRequest, "BATCH_TOO_LARGE", fmt.Sprintf("data must not contain more than %d users", maxBulkItems))
+		return
+	}
+
+	for i, item := range req.Data {
+		if status, e, ok := checkResourceType(ctx, fmt.Sprintf("/data/%d/type", i), item.Type, usersType); !ok {
+			respondErrors(ctx, w, status, []JSONAPIError{e})
+			return
+		}
+	}
+
+	inputs := make([]service.CreateUserInput, len(req.Data))
+	for i, item := range req.Data {
+		inputs[i] = service.CreateUserInput{
+			Name:     item.Attributes.Name,
+			Email:    item.Attributes.Email,
+			Password: item.Attributes.Password,
+		}
+	}
+
+	results, err := h.userService.CreateUsers(ctx, inputs, atomic)
+	if err != nil {
+		var itemErr *models.BatchItemError
+		if errors.As(err, &itemErr) {
+			if status, errs, ok := bulkItemErrors(ctx, itemErr.Index, itemErr.Err); ok {
+				respondErrors(ctx, w, status, errs)
+				return
+			}
+		}
+		h.writeAppError(ctx, w, err, slog.Bool("atomic", atomic))
+		return
+	}
+
+	data := make([]JSONAPIData, 0, len(results))
+	items := make([]BulkItemResult, len(results))
+	failed := 0
+	for i, result := range results {
+		if result.Err == nil {
+			data = append(data, ToJSONAPIData(result.User))
+			items[i] = BulkItemResult{Index: i, Status: bulkStatusCreated, ID: result.User.ID.String()}
+			continue
+		}
+
+		failed++
+		_, errs, ok := bulkItemErrors(ctx, i, result.Err)
+		if !ok {
+			// Not the client's fault; keep the cause in the logs only
+			h.logFailure(ctx, slog.LevelError, "failed to create bulk item",
+				slog.Int("index", i),
+				slog.String("error", result.Err.Error()),
+			)
+			errs = []JSONAPIError{jsonapi.NewError(middleware.GetRequestID(ctx), http.StatusInternalServerError, "INTERNAL_ERROR", "An unexpected error occurred")}
+		}
+		items[i] = BulkItemResult{Index: i, Status: bulkStatusFailed, Errors: errs}
+	}
+
+	h.logger.InfoContext(ctx, "bulk user create finished",
+		slog.Int("created", len(data)),
+		slog.Int("failed", failed),
+	)
+
+	// 201 only when everything was created; a partial result is still a
+	// successful request, and the client has to read meta.results
+	status := http.StatusCreated
+	if failed > 0 {
+		status = http.StatusOK
+	}
+
+	respondJSON(ctx, w, status, JSONAPIResponse{
+		Data: data,
+		Meta: map[string]interface{}{
+			"created": len(data),
+			"failed":  failed,
+			"results": items,
+		},
+	})
+}
+
+// bulkItemErrors converts the AppError of bulk element index into JSON:API
+// errors pointing at that element. ok is false for any other error.
+func bulkItemErrors(ctx context.Context, index int, err error) (status int, errs []JSONAPIError, ok bool) {
+	var appErr *models.AppError
+	if !errors.As(err, &appErr) {
+		return 0, nil, false
+	}
+
+	prefix := fmt.Sprintf("/data/%d/attributes", index)
+
+	var validationErrs models.ValidationErrors
+	if errors.As(appErr, &validationErrs) {
+		return appErr.Status, fieldErrors(ctx, prefix, validationErrs), true
+	}
+
+	e := jsonapi.NewError(middleware.GetRequestID(ctx), appErr.Status, appErr.Code, appErr.Detail)
+	if errors.Is(appErr, models.ErrEmailAlreadyExists) {
+		e.Source = &JSONAPIErrorSource{Pointer: prefix + "/email"}
+	}
+	return appErr.Status, []JSONAPIError{e}, true
+}
+
+// respondUser writes a single user with its version in meta.version and as
+// an ETag, ready to be echoed back in If-Match
+func respondUser(ctx context.Context, w http.ResponseWriter, status int, user *repository.User) {
+	respondUserDocument(ctx, w, status, user, ToJSONAPIData(user), nil)
+}
+
+// respondUserWithProfile is respondUser with the profile as a to-one
+// relationship. A user without a profile gets "data": null there and
+// nothing included.
+func respondUserWithProfile(ctx context.Context, w http.ResponseWriter, result *repository.UserWithProfile) {
+	data := ToJSONAPIData(result.User)
+	var profile JSONAPIRelationship
+	var included []JSONAPIData
+	if result.Profile != nil {
+		profileData := ProfileToJSONAPIData(result.Profile)
+		profile.Data = &JSONAPIResourceIdentifier{Type: profileData.Type, ID: profileData.ID}
+		included = dedupeIncluded([]JSONAPIData{profileData})
+	}
+	data.Relationships = map[string]JSONAPIRelationship{"profile": profile}
+
+	respondUserDocument(ctx, w, http.StatusOK, result.User, data, included)
+}
+
+// respondUserDocument writes data, the resource of user, with the user's
+// version in meta.version and the ETag
+func respondUserDocument(ctx context.Context, w http.ResponseWriter, status int, user *repository.User, data JSONAPIData, included []JSONAPIData) {
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(int(user.Version))))
+	respondJSON(ctx, w, status, JSONAPIResponse{
+		Data:     data,
+		Included: included,
+		Meta: map[string]interface{}{
+			"version": user.Version,
+		},
+	})
+}
+
+// fieldErrors renders one VALIDATION_ERROR per invalid field, pointing at
+// the field below prefix
+func fieldErrors(ctx context.Context, prefix string, validationErrs models.ValidationErrors) []JSONAPIError {
+	reqID := middleware.GetRequestID(ctx)
+	errs := make([]JSONAPIError, len(validationErrs))
+	for i, fieldErr := range validationErrs {
+		pointer, detail := prefix, fieldErr.Message
+		if fieldErr.Field != "" {
+			pointer += "/" + fieldErr.Field
+			detail = fieldErr.Field + " " + detail
+		}
+		errs[i] = jsonapi.NewError(reqID, http.StatusUnprocessableEntity, "VALIDATION_ERROR", detail)
+		errs[i].Source = &JSONAPIErrorSource{Pointer: pointer}
+	}
+	return errs
+}
+
+// userFilterFields is the complete set of filter[...] keys accepted on the
+// users collection. Anything else is rejected rather than passed through,
+// so new columns are never filterable by accident.
+var userFilterFields = []string{"email", "name"}
+
+// parseUserFilter reads filter[email] and filter[name], writing a 400 for
+// unknown filter keys
+func (h *UserHandler) parseUserFilter(w http.ResponseWriter, r *http.Request) (repository.UserFilter, bool) {
+	ctx := r.Context()
+
+	filters, err := parseFilters(r, userFilterFields)
+	if err != nil {
+		h.logFailure(ctx, slog.LevelWarn, "invalid filter parameters",
+			slog.String("error", err.Error()),
+		)
+		respondError(ctx, w, http.StatusBadRequest, "INVALID_FILTER", err.Error())
+		return repository.UserFilter{}, false
+	}
+
+	return repository.UserFilter{
+		Email: filters["email"],
+		Name:  filters["name"],
+	}, true
+}
+
+// userSortColumns maps the sort fields accepted on the users collection to
+// repository columns; like the filters, the set is closed
+var userSortColumns = map[string]repository.SortColumn{
+	"name":       repository.SortByName,
+	"email":      repository.SortByEmail,
+	"created_at": repository.SortByCreatedAt,
+	"updated_at": repository.SortByUpdatedAt,
+}
+
+// userSortFields lists the keys of userSortColumns in a stable order for
+// error messages
+var userSortFields = []string{"name", "email", "created_at", "updated_at"}
+
+// parseUserSort reads the sort parameter, writing a 400 for fields that are
+// not sortable. No sort parameter means the repository default,
+// -created_at.
+func (h *UserHandler) parseUserSort(w http.ResponseWriter, r *http.Request) ([]repository.SortField, bool) {
+	ctx := r.Context()
+
+	keys, err := parseSort(r, userSortFields)
+	if err != nil {
+		h.logFailure(ctx, slog.LevelWarn, "invalid sort parameter",
+			slog.String("error", err.Error()),
+		)
+		respondError(ctx, w, http.StatusBadRequest, "INVALID_SORT", err.Error())
+		return nil, false
+	}
+
+	sort := make([]repository.SortField, len(keys))
+	for i, key := range keys {
+		sort[i] = repository.SortField{Column: userSortColumns[key.Field], Desc: key.Desc}
+	}
+	return sort, true
+}
+
+// parseOffsetPage reads the paging parameters of a collection that only
+// supports offset paging, writing a 400 if they are invalid
+func (h *UserHandler) parseOffsetPage(w http.ResponseWriter, r *http.Request) (pagination.Page, bool) {
+	ctx := r.Context()
+
+	page, err := pagination.Parse(r)
+	if err == nil && page.Cursor != "" {
+		err = errors.New("cursor is not supported here, use offset")
+	}
+	if err != nil {
+		h.logFailure(ctx, slog.LevelWarn, "invalid pagination parameters",
+			slog.String("error", err.Error()),
+		)
+		respondError(ctx, w, http.StatusBadRequest, "INVALID_PAGINATION", err.Error())
+		return pagination.Page{}, false
+	}
+
+	return page, true
+}
+
+// parseUserID reads the {id} URL parameter, writing a 400 if it is not a
+// UUID
+func (h *UserHandler) parseUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	var errs errorList
+	id := h.userIDParam(r, &errs)
+	if !errs.empty() {
+		errs.respond(r.Context(), w)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// userIDParam reads the {id} URL parameter, recording a 400 in errs if it
+// is not a UUID, for handlers that go on to check the rest of the request
+func (h *UserHandler) userIDParam(r *http.Request, errs *errorList) uuid.UUID {
+	ctx := r.Context()
+
+	idStr := chi.URLParam(r, "id")
+	if idStr == "" {
+		h.logFailure(ctx, slog.LevelWarn, "missing user id parameter")
+		errs.add(ctx, http.StatusBadRequest, "INVALID_ID", "User ID is required")
+		return uuid.Nil
+	}
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logFailure(ctx, slog.LevelWarn, "invalid user id format",
+			slog.String("id", idStr),
+			slog.String("error", err.Error()),
+		)
+		errs.add(ctx, http.StatusBadRequest, "INVALID_ID", "Invalid user ID format")
+		return uuid.Nil
+	}
+
+	return id
+}
+
+// writeAppError is the single place where service errors become responses.
+// AppErrors render with their own status, code and detail, with validation
+// failures split into one error per field. Errors without an AppError are
+// infrastructure problems: client disconnects, an overloaded database,
+// timeouts, and unexpected failures. A client that disconnected gets no
+// response at all; there is nobody left to read it.
+func (h *UserHandler) writeAppError(ctx context.Context, w http.ResponseWriter, err error, attrs ...slog.Attr) {
+	h.writeAppErrorAt(ctx, w, "/data/attributes", err, attrs...)
+}
+
+// writeAppErrorAt is writeAppError for bodies whose fields live under
+// pointer rather than /data/attributes
+func (h *UserHandler) writeAppErrorAt(ctx context.Context, w http.ResponseWriter, pointer string, err error, attrs ...slog.Attr) {
+	attrs = append(attrs, slog.String("error", err.Error()))
+
+	var appErr *models.AppError
+	var validationErrs models.ValidationErrors
+	switch {
+	case errors.As(err, &appErr) && errors.As(appErr, &validationErrs):
+		h.logFailure(ctx, slog.LevelInfo, "invalid input", attrs...)
+		respondErrors(ctx, w, appErr.Status, fieldErrors(ctx, pointer, validationErrs))
+	case errors.As(err, &appErr):
+		h.logFailure(ctx, slog.LevelInfo, "request rejected", attrs...)
+		respondError(ctx, w, appErr.Status, appErr.Code, appErr.Detail)
+	case errors.Is(err, context.Canceled) && errors.Is(ctx.Err(), context.Canceled):
+		h.logFailure(ctx, slog.LevelDebug, "client closed request", attrs...)
+	case errors.Is(err, models.ErrUnavailable):
+		h.logFailure(ctx, slog.LevelWarn, "database unavailable", attrs...)
+		w.Header().Set("Retry-After", retryAfterSeconds)
+		respondError(ctx, w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "The service is temporarily overloaded, please retry later")
+	case errors.Is(err, context.Canceled):
+		// The client is still there, so the server gave up on the work,
+		// e.g. while shutting down
+		h.logFailure(ctx, slog.LevelWarn, "request canceled", attrs...)
+		w.Header().Set("Retry-After", retryAfterSeconds)
+		respondError(ctx, w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "The service is temporarily overloaded, please retry later")
+	case errors.Is(err, context.DeadlineExceeded):
+		h.logFailure(ctx, slog.LevelWarn, "request timed out", attrs...)
+		respondError(ctx, w, http.StatusGatewayTimeout, "TIMEOUT", "The request took too long to complete")
+	default:
+		h.logFailure(ctx, slog.LevelError, "unexpected error", attrs...)
+		respondError(ctx, w, http.StatusInternalServerError, "INTERNAL_ERROR", "An unexpected error occurred")
+	}
+}
+
+// logFailure logs an error-path event unless quietErrors is set
+func (h *UserHandler) logFailure(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr) {
+	if h.quietErrors {
+		return
+	}
+	h.logger.LogAttrs(ctx, level, msg, attrs...)
 }
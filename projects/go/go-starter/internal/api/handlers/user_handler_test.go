@@ -0,0 +1,897 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/yourusername/go-starter/internal/api/middleware"
+	"github.com/yourusername/go-starter/internal/auth"
+	"github.com/yourusername/go-starter/internal/models"
+	"github.com/yourusername/go-starter/internal/repository"
+	"github.com/yourusername/go-starter/internal/service"
+)
+
+// stubUserService returns a fixed error from every call
+type stubUserService struct {
+	service.UserService
+	err error
+}
+
+func (s stubUserService) GetUser(context.Context, uuid.UUID) (*repository.User, error) {
+	return nil, s.err
+}
+
+func TestUserHandler_GetUser_Unavailable(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := NewUserHandler(stubUserService{err: fmt.Errorf("get user: %w", models.ErrUnavailable)}, logger)
+
+	r := chi.NewRouter()
+	r.Get("/users/{id}", h.GetUser)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/"+uuid.NewString(), nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, retryAfterSeconds, rec.Header().Get("Retry-After"))
+	assert.Contains(t, rec.Body.String(), `"code":"SERVICE_UNAVAILABLE"`)
+}
+
+func TestUserHandler_GetUser_ClientClosedRequest(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := NewUserHandler(stubUserService{err: fmt.Errorf("get user: %w", context.Canceled)}, logger)
+
+	r := chi.NewRouter()
+	r.Get("/users/{id}", h.GetUser)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/"+uuid.NewString(), nil).WithContext(ctx))
+
+	// Nothing is written for a client that is gone
+	assert.Empty(t, rec.Body.String())
+	assert.Empty(t, rec.Header())
+}
+
+func TestUserHandler_GetUser_ContextErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "deadline exceeded",
+			err:        fmt.Errorf("get user: %w", context.DeadlineExceeded),
+			wantStatus: http.StatusGatewayTimeout,
+			wantCode:   "TIMEOUT",
+		},
+		{
+			name:       "canceled by the server",
+			err:        fmt.Errorf("get user: %w", context.Canceled),
+			wantStatus: http.StatusServiceUnavailable,
+			wantCode:   "SERVICE_UNAVAILABLE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			h := NewUserHandler(stubUserService{err: tt.err}, logger)
+
+			r := chi.NewRouter()
+			r.Get("/users/{id}", h.GetUser)
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/"+uuid.NewString(), nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"code":"`+tt.wantCode+`"`)
+		})
+	}
+}
+
+func TestUserHandler_CreateUsersBulk(t *testing.T) {
+	body := `{"data":[
+		{"type":"users","attributes":{"name":"Jane","email":"jane@example.com","password":"correct-horse"}},
+		{"type":"users","attributes":{"name":"","email":"bad","password":"correct-horse"}}
+	]}`
+
+	tests := []struct {
+		name       string
+		query      string
+		body       string
+		wantStatus int
+		wantBody   []string
+	}{
+		{
+			name:       "partial success reports each index",
+			body:       body,
+			wantStatus: http.StatusOK,
+			wantBody:   []string{`"created":1`, `"failed":1`, `"index":1,"status":"failed"`, `"pointer":"/data/1/attributes/email"`},
+		},
+		{
+			name:       "atomic rejects the whole batch",
+			query:      "?atomic=true",
+			body:       body,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantBody:   []string{`"code":"VALIDATION_ERROR"`, `"pointer":"/data/1/attributes/name"`},
+		},
+		{
+			name:       "invalid atomic flag",
+			query:      "?atomic=maybe",
+			body:       body,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   []string{`"code":"INVALID_PARAMETER"`},
+		},
+		{
+			name:       "empty batch",
+			body:       `{"data":[]}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   []string{`"code":"INVALID_BODY"`},
+		},
+		{
+			name:       "wrong resource type",
+			body:       `{"data":[{"type":"users","attributes":{}},{"type":"posts","attributes":{}}]}`,
+			wantStatus: http.StatusConflict,
+			wantBody:   []string{`"code":"TYPE_MISMATCH"`, `"pointer":"/data/1/type"`},
+		},
+		{
+			name:       "missing resource type",
+			body:       `{"data":[{"attributes":{}}]}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   []string{`"code":"INVALID_BODY"`, `"pointer":"/data/0/type"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			h := NewUserHandler(service.NewUserService(repository.NewMemoryUserRepository()), logger)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/users/bulk"+tt.query, strings.NewReader(tt.body))
+			h.CreateUsersBulk(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			for _, want := range tt.wantBody {
+				assert.Contains(t, rec.Body.String(), want)
+			}
+		})
+	}
+}
+
+func TestUserHandler_BatchGetUsers(t *testing.T) {
+	jane, john, missing := uuid.New(), uuid.New(), uuid.New()
+	repo, err := repository.NewSeededMemoryUserRepository(
+		repository.User{ID: jane, Email: "jane@example.com", Name: "Jane"},
+		repository.User{ID: john, Email: "john@example.com", Name: "John"},
+	)
+	require.NoError(t, err)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := NewUserHandler(service.NewUserService(repo), logger)
+
+	identifiers := func(ids ...string) string {
+		data := make([]string, len(ids))
+		for i, id := range ids {
+			data[i] = fmt.Sprintf(`{"type":"users","id":%q}`, id)
+		}
+		return `{"data":[` + strings.Join(data, ",") + `]}`
+	}
+	tooMany := make([]string, maxBatchGetIDs+1)
+	for i := range tooMany {
+		tooMany[i] = uuid.NewString()
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantBody   []string
+	}{
+		{
+			name:       "found users in request order, missing ids in meta",
+			body:       identifiers(john.String(), missing.String(), jane.String(), john.String()),
+			wantStatus: http.StatusOK,
+			wantBody: []string{
+				`"data":[{"type":"users","id":"` + john.String() + `","attributes":{"name":"John"`,
+				// john was asked for twice but is returned once
+				`"id":"` + jane.String() + `","attributes":{"name":"Jane","email":"jane@example.com"}}],`,
+				`"not_found":["` + missing.String() + `"]`,
+			},
+		},
+		{
+			name:       "all found",
+			body:       identifiers(jane.String()),
+			wantStatus: http.StatusOK,
+			wantBody:   []string{`"not_found":[]`},
+		},
+		{
+			name:       "too many ids",
+			body:       identifiers(tooMany...),
+			wantStatus: http.StatusBadRequest,
+			wantBody:   []string{`"code":"BATCH_TOO_LARGE"`},
+		},
+		{
+			name:       "empty",
+			body:       `{"data":[]}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   []string{`"code":"INVALID_BODY"`},
+		},
+		{
+			name:       "malformed id",
+			body:       identifiers(jane.String(), "not-a-uuid"),
+			wantStatus: http.StatusBadRequest,
+			wantBody:   []string{`"code":"INVALID_ID"`, `"pointer":"/data/1/id"`},
+		},
+		{
+			name:       "wrong resource type",
+			body:       `{"data":[{"type":"posts","id":"` + jane.String() + `"}]}`,
+			wantStatus: http.StatusConflict,
+			wantBody:   []string{`"code":"TYPE_MISMATCH"`, `"pointer":"/data/0/type"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.BatchGetUsers(rec, httptest.NewRequest(http.MethodPost, "/users/batch-get", strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			for _, want := range tt.wantBody {
+				assert.Contains(t, rec.Body.String(), want)
+			}
+		})
+	}
+}
+
+// testSecret signs the tokens of authorize
+var testSecret = []byte("test-secret")
+
+// authorize sends req with a bearer token for the user with id and role
+func authorize(t *testing.T, req *http.Request, id uuid.UUID, role string) {
+	t.Helper()
+	token, err := auth.GenerateToken(id, "caller@example.com", role, testSecret, time.Minute)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+}
+
+func TestUserHandler_UpdateUser(t *testing.T) {
+	id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	body := `{"data":{"type":"users","id":"` + id.String() + `","attributes":{"name":"Jane Roe"}}}`
+
+	tests := []struct {
+		name       string
+		ifMatch    string
+		body       string
+		wantStatus int
+		wantBody   string
+		wantETag   string
+	}{
+		{
+			name:       "success bumps the version",
+			ifMatch:    `"1"`,
+			body:       body,
+			wantStatus: http.StatusOK,
+			wantBody:   `"version":2`,
+			wantETag:   `"2"`,
+		},
+		{
+			name:       "version in meta",
+			body:       `{"data":{"type":"users","attributes":{"name":"Jane Roe"},"meta":{"version":1}}}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `"name":"Jane Roe"`,
+		},
+		{
+			name:       "stale version",
+			ifMatch:    `"7"`,
+			body:       body,
+			wantStatus: http.StatusConflict,
+			wantBody:   `"code":"STALE_VERSION"`,
+		},
+		{
+			name:       "missing version",
+			body:       body,
+			wantStatus: http.StatusPreconditionRequired,
+			wantBody:   `"code":"VERSION_REQUIRED"`,
+		},
+		{
+			name:       "malformed If-Match",
+			ifMatch:    "*",
+			body:       body,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `"code":"INVALID_VERSION"`,
+		},
+		{
+			name:       "invalid attribute",
+			ifMatch:    "1",
+			body:       `{"data":{"type":"users","attributes":{"email":"nope"}}}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantBody:   `"pointer":"/data/attributes/email"`,
+		},
+		{
+			name:       "wrong resource type",
+			ifMatch:    "1",
+			body:       `{"data":{"type":"user","attributes":{"name":"Jane Roe"}}}`,
+			wantStatus: http.StatusConflict,
+			wantBody:   `"code":"TYPE_MISMATCH"`,
+		},
+		{
+			name:       "missing resource type",
+			ifMatch:    "1",
+			body:       `{"data":{"attributes":{"name":"Jane Roe"}}}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `"pointer":"/data/type"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, err := repository.NewSeededMemoryUserRepository(repository.User{ID: id, Email: "jane@example.com", Name: "Jane"})
+			assert.NoError(t, err)
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			h := NewUserHandler(service.NewUserService(repo), logger)
+
+			r := chi.NewRouter()
+			r.With(middleware.Authenticate(testSecret)).Patch("/users/{id}", h.UpdateUser)
+
+			req := httptest.NewRequest(http.MethodPatch, "/users/"+id.String(), strings.NewReader(tt.body))
+			authorize(t, req, id, models.RoleUser)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.wantBody)
+			if tt.wantETag != "" {
+				assert.Equal(t, tt.wantETag, rec.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestUserHandler_UpdateUser_ReportsAllErrors(t *testing.T) {
+	repo := repository.NewMemoryUserRepository()
+	h := NewUserHandler(service.NewUserService(repo), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	r := chi.NewRouter()
+	r.With(middleware.Authenticate(testSecret)).Patch("/users/{id}", h.UpdateUser)
+
+	req := httptest.NewRequest(http.MethodPatch, "/users/not-a-uuid",
+		strings.NewReader(`{"data":{"type":"users","attributes":{"email":"nope"}}}`))
+	authorize(t, req, uuid.New(), models.RoleUser)
+	req.Header.Set("If-Match", `"1"`)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "a malformed request outranks validation")
+	var doc JSONAPIErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	require.Len(t, doc.Errors, 2)
+	assert.Equal(t, "INVALID_ID", doc.Errors[0].Code)
+	assert.Equal(t, "400", doc.Errors[0].Status)
+	assert.Equal(t, "VALIDATION_ERROR", doc.Errors[1].Code)
+	assert.Equal(t, "422", doc.Errors[1].Status)
+	assert.Equal(t, "/data/attributes/email", doc.Errors[1].Source.Pointer)
+}
+
+func TestUserHandler_UpdateUser_ConflictAndValidation(t *testing.T) {
+	id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	repo, err := repository.NewSeededMemoryUserRepository(repository.User{ID: id, Email: "jane@example.com", Name: "Jane"})
+	require.NoError(t, err)
+	h := NewUserHandler(service.NewUserService(repo), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	r := chi.NewRouter()
+	r.With(middleware.Authenticate(testSecret)).Patch("/users/{id}", h.UpdateUser)
+
+	req := httptest.NewRequest(http.MethodPatch, "/users/"+id.String(),
+		strings.NewReader(`{"data":{"type":"posts","attributes":{"email":"nope"}}}`))
+	authorize(t, req, id, models.RoleUser)
+	req.Header.Set("If-Match", `"1"`)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code, "one of the errors' statuses, not 400")
+	var doc JSONAPIErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	require.Len(t, doc.Errors, 2)
+	assert.Equal(t, "TYPE_MISMATCH", doc.Errors[0].Code)
+	assert.Equal(t, "VALIDATION_ERROR", doc.Errors[1].Code)
+}
+
+func TestUserHandler_UpdateUser_MergePatch(t *testing.T) {
+	id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+
+	tests := []struct {
+		name        string
+		contentType string
+		ifMatch     string
+		body        string
+		wantStatus  int
+		wantBody    []string
+	}{
+		{
+			name:        "set a field",
+			contentType: "application/merge-patch+json",
+			ifMatch:     `"1"`,
+			body:        `{"name":"Jane Roe"}`,
+			wantStatus:  http.StatusOK,
+			wantBody:    []string{`"name":"Jane Roe"`, `"email":"jane@example.com"`, `"version":2`},
+		},
+		{
+			name:        "charset parameter",
+			contentType: "application/merge-patch+json; charset=utf-8",
+			ifMatch:     `"1"`,
+			body:        `{"email":"roe@example.com"}`,
+			wantStatus:  http.StatusOK,
+			wantBody:    []string{`"email":"roe@example.com"`, `"name":"Jane"`},
+		},
+		{
+			name:        "clearing a required field",
+			contentType: "application/merge-patch+json",
+			ifMatch:     `"1"`,
+			body:        `{"name":null}`,
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantBody:    []string{`"pointer":"/name"`, `name cannot be cleared`},
+		},
+		{
+			name:        "non-updatable fields",
+			contentType: "application/merge-patch+json",
+			ifMatch:     `"1"`,
+			body:        `{"name":"Jane Roe","role":"admin","id":null}`,
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantBody:    []string{`"pointer":"/id"`, `"pointer":"/role"`, `role is not updatable`},
+		},
+		{
+			name:        "service validation points at the member",
+			contentType: "application/merge-patch+json",
+			ifMatch:     `"1"`,
+			body:        `{"email":"nope"}`,
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantBody:    []string{`"pointer":"/email"`},
+		},
+		{
+			name:        "not an object",
+			contentType: "application/merge-patch+json",
+			ifMatch:     `"1"`,
+			body:        `null`,
+			wantStatus:  http.StatusBadRequest,
+			wantBody:    []string{`"code":"INVALID_BODY"`},
+		},
+		{
+			name:        "version only in If-Match",
+			contentType: "application/merge-patch+json",
+			body:        `{"name":"Jane Roe"}`,
+			wantStatus:  http.StatusPreconditionRequired,
+			wantBody:    []string{`"code":"VERSION_REQUIRED"`},
+		},
+		{
+			name:        "JSON:API stays the default",
+			contentType: "application/vnd.api+json",
+			ifMatch:     `"1"`,
+			body:        `{"name":"Jane Roe"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantBody:    []string{`"code":"INVALID_BODY"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, err := repository.NewSeededMemoryUserRepository(repository.User{ID: id, Email: "jane@example.com", Name: "Jane"})
+			require.NoError(t, err)
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			h := NewUserHandler(service.NewUserService(repo), logger)
+
+			r := chi.NewRouter()
+			r.With(middleware.Authenticate(testSecret)).Patch("/users/{id}", h.UpdateUser)
+
+			req := httptest.NewRequest(http.MethodPatch, "/users/"+id.String(), strings.NewReader(tt.body))
+			authorize(t, req, id, models.RoleUser)
+			req.Header.Set("Content-Type", tt.contentType)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+			for _, want := range tt.wantBody {
+				assert.Contains(t, rec.Body.String(), want)
+			}
+		})
+	}
+}
+
+func TestUserHandler_ListUsers_Filter(t *testing.T) {
+	repo, err := repository.NewSeededMemoryUserRepository(
+		repository.User{Email: "jane@example.com", Name: "Jane Doe"},
+		repository.User{Email: "john@example.com", Name: "John Doe"},
+		repository.User{Email: "alice@corp.test", Name: "Alice"},
+	)
+	assert.NoError(t, err)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := NewUserHandler(service.NewUserService(repo), logger)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "name substring is case-insensitive",
+			query:      "?filter[name]=DOE",
+			wantStatus: http.StatusOK,
+			wantBody:   `"total":2`,
+		},
+		{
+			name:       "filters combine",
+			query:      "?filter[name]=doe&filter[email]=jane",
+			wantStatus: http.StatusOK,
+			wantBody:   `"total":1`,
+		},
+		{
+			name:       "wildcards match literally",
+			query:      "?filter[email]=%25",
+			wantStatus: http.StatusOK,
+			wantBody:   `"total":0`,
+		},
+		{
+			name:       "unknown filter",
+			query:      "?filter[password_hash]=x",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `"code":"INVALID_FILTER"`,
+		},
+		{
+			name:       "malformed filter",
+			query:      "?filter=x",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `"code":"INVALID_FILTER"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ListUsers(rec, httptest.NewRequest(http.MethodGet, "/users"+tt.query, nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+func TestUserHandler_SearchUsers(t *testing.T) {
+	repo, err := repository.NewSeededMemoryUserRepository(
+		repository.User{Email: "jane@example.com", Name: "Jane Doe"},
+		repository.User{Email: "john@example.com", Name: "John Quincy Doe"},
+		repository.User{Email: "alice@corp.test", Name: "Alice"},
+	)
+	assert.NoError(t, err)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := NewUserHandler(service.NewUserService(repo), logger)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantBody   []string
+	}{
+		{
+			name:       "matches carry meta.score",
+			query:      "?q=doe",
+			wantStatus: http.StatusOK,
+			wantBody:   []string{`"name":"Jane Doe"`, `"meta":{"score":0.33`},
+		},
+		{
+			name:       "no match",
+			query:      "?q=bob",
+			wantStatus: http.StatusOK,
+			wantBody:   []string{`"data":[]`},
+		},
+		{
+			name:       "empty query",
+			query:      "?q=%20%20",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   []string{`"code":"INVALID_QUERY"`},
+		},
+		{
+			name:       "missing query",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   []string{`"code":"INVALID_QUERY"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.SearchUsers(rec, httptest.NewRequest(http.MethodGet, "/users/search"+tt.query, nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			for _, want := range tt.wantBody {
+				assert.Contains(t, rec.Body.String(), want)
+			}
+		})
+	}
+}
+
+func TestUserHandler_ListUsers_Sort(t *testing.T) {
+	repo, err := repository.NewSeededMemoryUserRepository(
+		repository.User{Email: "b@example.com", Name: "Bob"},
+		repository.User{Email: "c@example.com", Name: "Alice"},
+		repository.User{Email: "a@example.com", Name: "Alice"},
+	)
+	assert.NoError(t, err)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := NewUserHandler(service.NewUserService(repo), logger)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantOrder  []string
+		wantBody   string
+	}{
+		{
+			name:       "ascending",
+			query:      "?sort=email",
+			wantStatus: http.StatusOK,
+			wantOrder:  []string{"a@example.com", "b@example.com", "c@example.com"},
+		},
+		{
+			name:       "multiple fields in order",
+			query:      "?sort=name,-email",
+			wantStatus: http.StatusOK,
+			wantOrder:  []string{"c@example.com", "a@example.com", "b@example.com"},
+		},
+		{
+			name:       "column not on the allow-list",
+			query:      "?sort=password_hash",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `"code":"INVALID_SORT"`,
+		},
+		{
+			name:       "repeated field",
+			query:      "?sort=name,-name",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `"code":"INVALID_SORT"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ListUsers(rec, httptest.NewRequest(http.MethodGet, "/users"+tt.query, nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			body := rec.Body.String()
+			assert.Contains(t, body, tt.wantBody)
+
+			last := -1
+			for _, email := range tt.wantOrder {
+				i := strings.Index(body, email)
+				assert.Greater(t, i, last, "%s out of order", email)
+				last = i
+			}
+		})
+	}
+}
+
+func TestUserHandler_GetUser_AppError(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	appErr := models.NewAppError(http.StatusConflict, "SOME_CONFLICT", "Something conflicted", models.ErrConflict)
+	h := NewUserHandler(stubUserService{err: fmt.Errorf("get user: %w", appErr)}, logger)
+
+	r := chi.NewRouter()
+	r.Get("/users/{id}", h.GetUser)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/"+uuid.NewString(), nil))
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"code":"SOME_CONFLICT"`)
+	assert.Contains(t, rec.Body.String(), `"detail":"Something conflicted"`)
+}
+
+func TestUserHandler_ChangePassword(t *testing.T) {
+	id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	secret := []byte("test-secret")
+	hash, err := bcrypt.GenerateFromPassword([]byte("old-password"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	body := func(current, next string) string {
+		return `{"data":{"type":"users","attributes":{"current_password":"` + current + `","new_password":"` + next + `"}}}`
+	}
+
+	tests := []struct {
+		name       string
+		userID     uuid.UUID
+		role       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "own password",
+			userID:     id,
+			role:       models.RoleUser,
+			body:       body("old-password", "new-password"),
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "admin for another user",
+			userID:     uuid.New(),
+			role:       models.RoleAdmin,
+			body:       body("old-password", "new-password"),
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "another user",
+			userID:     uuid.New(),
+			role:       models.RoleUser,
+			body:       body("old-password", "new-password"),
+			wantStatus: http.StatusForbidden,
+			wantBody:   `"code":"FORBIDDEN"`,
+		},
+		{
+			name:       "wrong current password",
+			userID:     id,
+			role:       models.RoleUser,
+			body:       body("guessed-password", "new-password"),
+			wantStatus: http.StatusUnprocessableEntity,
+			wantBody:   `"pointer":"/data/attributes/current_password"`,
+		},
+		{
+			name:       "new password too short",
+			userID:     id,
+			role:       models.RoleUser,
+			body:       body("old-password", "short"),
+			wantStatus: http.StatusUnprocessableEntity,
+			wantBody:   `"pointer":"/data/attributes/new_password"`,
+		},
+		{
+			name:       "new password equals current",
+			userID:     id,
+			role:       models.RoleUser,
+			body:       body("old-password", "old-password"),
+			wantStatus: http.StatusUnprocessableEntity,
+			wantBody:   "must differ from the current password",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, err := repository.NewSeededMemoryUserRepository(repository.User{
+				ID: id, Email: "jane@example.com", Name: "Jane", PasswordHash: string(hash),
+			})
+			require.NoError(t, err)
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			h := NewUserHandler(service.NewUserService(repo), logger, WithQuietErrors())
+
+			r := chi.NewRouter()
+			r.With(middleware.Authenticate(secret)).Post("/users/{id}/password", h.ChangePassword)
+
+			token, err := auth.GenerateToken(tt.userID, "caller@example.com", tt.role, secret, time.Minute)
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodPost, "/users/"+id.String()+"/password", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.wantBody)
+
+			user, err := repo.GetByID(context.Background(), id)
+			require.NoError(t, err)
+			changed := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte("new-password")) == nil
+			assert.Equal(t, tt.wantStatus == http.StatusNoContent, changed)
+			assert.Equal(t, changed, user.PasswordChangedAt.Valid)
+		})
+	}
+}
+
+// profileUserService serves GetUserWithProfile from a fixed result
+type profileUserService struct {
+	service.UserService
+	result *repository.UserWithProfile
+}
+
+func (s profileUserService) GetUserWithProfile(_ context.Context, id uuid.UUID) (*repository.UserWithProfile, error) {
+	if id != s.result.User.ID {
+		return nil, fmt.Errorf("get user with profile: %w", models.ErrNotFound)
+	}
+	return s.result, nil
+}
+
+func TestUserHandler_GetUser_IncludeProfile(t *testing.T) {
+	userID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	profileID := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	user := &repository.User{ID: userID, Name: "Jane", Email: "jane@example.com", Version: 2}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name       string
+		profile    *repository.Profile
+		query      string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "with profile",
+			profile:    &repository.Profile{ID: profileID, UserID: userID, Bio: "Gopher"},
+			query:      "?include=profile",
+			wantStatus: http.StatusOK,
+			wantBody: `{
+				"data": {
+					"type": "users",
+					"id": "550e8400-e29b-41d4-a716-446655440000",
+					"attributes": {"name": "Jane", "email": "jane@example.com"},
+					"relationships": {
+						"profile": {"data": {"type": "profiles", "id": "6ba7b810-9dad-11d1-80b4-00c04fd430c8"}}
+					}
+				},
+				"included": [{
+					"type": "profiles",
+					"id": "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+					"attributes": {"bio": "Gopher", "avatar_url": null}
+				}],
+				"meta": {"version": 2}
+			}`,
+		},
+		{
+			name:       "without profile",
+			query:      "?include=profile",
+			wantStatus: http.StatusOK,
+			wantBody: `{
+				"data": {
+					"type": "users",
+					"id": "550e8400-e29b-41d4-a716-446655440000",
+					"attributes": {"name": "Jane", "email": "jane@example.com"},
+					"relationships": {"profile": {"data": null}}
+				},
+				"meta": {"version": 2}
+			}`,
+		},
+		{
+			name:       "unknown relationship",
+			query:      "?include=profile,posts",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := profileUserService{result: &repository.UserWithProfile{User: user, Profile: tt.profile}}
+			h := NewUserHandler(svc, logger)
+			r := chi.NewRouter()
+			r.Get("/users/{id}", h.GetUser)
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/"+userID.String()+tt.query, nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus != http.StatusOK {
+				assert.Contains(t, rec.Body.String(), `"code":"INVALID_INCLUDE"`)
+				return
+			}
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+			assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+		})
+	}
+}
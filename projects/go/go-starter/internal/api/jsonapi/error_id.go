@@ -0,0 +1,17 @@
+package jsonapi
+
+import "context"
+
+type errorIDsKey struct{}
+
+// WithErrorIDs returns a context whose error responses give every error
+// object a unique id, which is also logged
+func WithErrorIDs(ctx context.Context) context.Context {
+	return context.WithValue(ctx, errorIDsKey{}, true)
+}
+
+// ErrorIDs reports whether WithErrorIDs was applied to ctx
+func ErrorIDs(ctx context.Context) bool {
+	enabled, _ := ctx.Value(errorIDsKey{}).(bool)
+	return enabled
+}
@@ -0,0 +1,185 @@
+// Package jsonapi holds the JSON:API document types shared by handlers and
+// middleware, so both layers render errors the same way.
+package jsonapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/go-starter/internal/i18n"
+)
+
+// MediaType is the JSON:API content type
+const MediaType = "application/vnd.api+json"
+
+// MergePatchMediaType is the content type of RFC 7386 JSON Merge Patch
+// bodies, which PATCH endpoints may accept next to JSON:API documents
+const MergePatchMediaType = "application/merge-patch+json"
+
+// ErrorSource represents the source of an error
+type ErrorSource struct {
+	Pointer string `json:"pointer,omitempty"`
+}
+
+// Error represents a single error in JSON:API format
+type Error struct {
+	// ID identifies this occurrence of the error; set when error IDs are
+	// enabled, see WithErrorIDs
+	ID     string                 `json:"id,omitempty"`
+	Status string                 `json:"status"`
+	Code   string                 `json:"code"`
+	Title  string                 `json:"title"`
+	Detail string                 `json:"detail"`
+	Source *ErrorSource           `json:"source,omitempty"`
+	Meta   map[string]interface{} `json:"meta,omitempty"`
+}
+
+// ErrorResponse represents an error response in JSON:API format
+type ErrorResponse struct {
+	Errors []Error                `json:"errors"`
+	Meta   map[string]interface{} `json:"meta,omitempty"`
+}
+
+// NewError builds an error object with the request ID in its meta
+func NewError(reqID string, status int, code, detail string) Error {
+	return Error{
+		Status: fmt.Sprintf("%d", status),
+		Code:   code,
+		Title:  http.StatusText(status),
+		Detail: detail,
+		Meta: map[string]interface{}{
+			"request_id": reqID,
+		},
+	}
+}
+
+// WriteError writes a JSON:API error response containing a single error and
+// logs it with a machine-parseable error_code, so alerting can key on codes
+// without every caller repeating the log line. The log goes to the logger
+// set with WithLogger.
+func WriteError(ctx context.Context, w http.ResponseWriter, reqID string, status int, code, detail string) {
+	WriteErrors(ctx, w, reqID, status, []Error{NewError(reqID, status, code, detail)})
+}
+
+// WriteErrors writes a JSON:API error response with several errors, e.g. one
+// per invalid field. The first error's code is the one logged, along with
+// every error's id when error IDs are enabled. Titles and details are
+// translated into the request's language where the catalog has them.
+func WriteErrors(ctx context.Context, w http.ResponseWriter, reqID string, status int, errs []Error) {
+	if ErrorIDs(ctx) {
+		errs = withIDs(errs)
+	}
+
+	level := slog.LevelInfo
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	code, id := "", ""
+	if len(errs) > 0 {
+		code, id = errs[0].Code, errs[0].ID
+	}
+	attrs := []slog.Attr{
+		slog.String("error_code", code),
+		slog.Int("status", status),
+	}
+	if id != "" {
+		attrs = append(attrs, slog.String("error_id", id))
+	}
+	if ids := errorIDs(errs); len(ids) > 1 {
+		attrs = append(attrs, slog.Any("error_ids", ids))
+	}
+	loggerFrom(ctx).LogAttrs(ctx, level, "error response", attrs...)
+
+	lang := i18n.Language(ctx)
+	errs = localize(lang, errs)
+
+	w.Header().Set("Content-Language", lang)
+	Write(ctx, w, reqID, status, ErrorResponse{Errors: errs, Meta: VersionMeta(ctx)})
+}
+
+// encodeFailureBody is sent when an error document itself can't be encoded,
+// so there is nothing left that could fail
+const encodeFailureBody = `{"errors":[{"status":"500","code":"INTERNAL_ERROR","title":"Internal Server Error","detail":"An unexpected error occurred"}]}` + "\n"
+
+// Write sends v as a JSON:API document with status. The body is encoded
+// before anything is written, so a value that can't be encoded turns into a
+// clean 500 error document instead of a truncated body under the original
+// status.
+func Write(ctx context.Context, w http.ResponseWriter, reqID string, status int, v interface{}) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		loggerFrom(ctx).LogAttrs(ctx, slog.LevelError, "failed to encode response",
+			slog.String("error", err.Error()),
+			slog.Int("status", status),
+		)
+		// Headers describing the document that was never sent
+		w.Header().Del("ETag")
+		w.Header().Del("Location")
+
+		if _, isError := v.(ErrorResponse); isError {
+			w.Header().Set("Content-Type", MediaType)
+			w.WriteHeader(http.StatusInternalServerError)
+			io.WriteString(w, encodeFailureBody)
+			return
+		}
+		WriteError(ctx, w, reqID, http.StatusInternalServerError, "INTERNAL_ERROR", "An unexpected error occurred")
+		return
+	}
+
+	w.Header().Set("Content-Type", MediaType)
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+// withIDs returns errs with a new UUID for every error that has no id. The
+// caller's slice is left untouched.
+func withIDs(errs []Error) []Error {
+	out := make([]Error, len(errs))
+	for i, e := range errs {
+		if e.ID == "" {
+			e.ID = uuid.NewString()
+		}
+		out[i] = e
+	}
+	return out
+}
+
+// errorIDs returns the ids set on errs
+func errorIDs(errs []Error) []string {
+	var ids []string
+	for _, e := range errs {
+		if e.ID != "" {
+			ids = append(ids, e.ID)
+		}
+	}
+	return ids
+}
+
+// localize returns errs with catalog translations for lang applied. The
+// caller's slice is left untouched.
+func localize(lang string, errs []Error) []Error {
+	if lang == i18n.English {
+		return errs
+	}
+
+	out := make([]Error, len(errs))
+	for i, e := range errs {
+		if msg, ok := i18n.Lookup(lang, e.Code); ok {
+			if msg.Title != "" {
+				e.Title = msg.Title
+			}
+			if msg.Detail != "" {
+				e.Detail = msg.Detail
+			}
+		}
+		out[i] = e
+	}
+	return out
+}
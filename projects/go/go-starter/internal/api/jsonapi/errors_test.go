@@ -0,0 +1,29 @@
+package jsonapi
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteError_LogsErrorCode(t *testing.T) {
+	var logs strings.Builder
+	ctx := WithLogger(context.Background(), slog.New(slog.NewTextHandler(&logs, nil)))
+
+	rec := httptest.NewRecorder()
+	WriteError(ctx, rec, "req-1", http.StatusNotFound, "NOT_FOUND", "no such user")
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, logs.String(), "level=INFO")
+	assert.Contains(t, logs.String(), `msg="error response" error_code=NOT_FOUND status=404`)
+
+	logs.Reset()
+	WriteError(ctx, httptest.NewRecorder(), "req-2", http.StatusInternalServerError, "INTERNAL_ERROR", "boom")
+	assert.Contains(t, logs.String(), "level=ERROR")
+	assert.Contains(t, logs.String(), "error_code=INTERNAL_ERROR status=500")
+}
@@ -0,0 +1,27 @@
+package jsonapi
+
+import "context"
+
+type apiVersionKey struct{}
+
+// WithAPIVersion returns a context whose responses report version in
+// meta.api_version
+func WithAPIVersion(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, apiVersionKey{}, version)
+}
+
+// APIVersion returns the version stored by WithAPIVersion, or ""
+func APIVersion(ctx context.Context) string {
+	version, _ := ctx.Value(apiVersionKey{}).(string)
+	return version
+}
+
+// VersionMeta returns the top-level meta every document carries, or nil
+// when no version is set
+func VersionMeta(ctx context.Context) map[string]interface{} {
+	version := APIVersion(ctx)
+	if version == "" {
+		return nil
+	}
+	return map[string]interface{}{"api_version": version}
+}
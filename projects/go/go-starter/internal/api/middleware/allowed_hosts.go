@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/yourusername/go-starter/internal/api/jsonapi"
+)
+
+// AllowedHosts answers 400 to requests whose host is not in hosts, so a
+// forged host can't end up in absolute links or cached responses. The host
+// checked is the one RequestOrigin resolves with trustedProxies, i.e. the
+// Host header or a trusted proxy's Forwarded host= or X-Forwarded-Host.
+// Entries are host names without a port, matched case-insensitively
+// against the host with its port removed; "*.example.com" matches every
+// subdomain of example.com, but not example.com itself. An empty list
+// disables the check.
+func AllowedHosts(hosts []string, trustedProxies []netip.Prefix) func(http.Handler) http.Handler {
+	exact := make(map[string]bool, len(hosts))
+	var suffixes []string
+	for _, host := range hosts {
+		host = strings.ToLower(host)
+		if suffix, ok := strings.CutPrefix(host, "*"); ok {
+			suffixes = append(suffixes, suffix)
+			continue
+		}
+		exact[requestHost(host)] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		if len(hosts) == 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, origin := RequestOrigin(r, trustedProxies)
+			host := requestHost(origin)
+			if !exact[host] && !hasAnySuffix(host, suffixes) {
+				ctx := r.Context()
+				jsonapi.WriteError(ctx, w, GetRequestID(ctx), http.StatusBadRequest, "INVALID_HOST", "The Host header is not allowed")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requestHost returns the lower-cased host of a Host header without its
+// port, and without the brackets of an IPv6 literal
+func requestHost(hostport string) string {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	return strings.ToLower(host)
+}
+
+// hasAnySuffix reports whether host ends in one of suffixes such as
+// ".example.com" with at least one label in front of it
+func hasAnySuffix(host string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if len(host) > len(suffix) && strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowedHosts(t *testing.T) {
+	h := AllowedHosts([]string{"api.example.com", "*.example.org", "[::1]"}, nil)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	tests := []struct {
+		host string
+		want int
+	}{
+		{"api.example.com", http.StatusOK},
+		{"API.Example.COM", http.StatusOK},
+		{"api.example.com:8443", http.StatusOK},
+		{"example.com", http.StatusBadRequest},
+		{"evil.api.example.com", http.StatusBadRequest},
+		{"api.example.com.evil.test", http.StatusBadRequest},
+		{"eu.example.org", http.StatusOK},
+		{"a.b.example.org:80", http.StatusOK},
+		{"example.org", http.StatusBadRequest},
+		{"badexample.org", http.StatusBadRequest},
+		{"[::1]:8080", http.StatusOK},
+		{"", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+			req.Host = tt.host
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.want, rec.Code)
+			if tt.want != http.StatusOK {
+				assert.Contains(t, rec.Body.String(), `"code":"INVALID_HOST"`)
+			}
+		})
+	}
+}
+
+func TestAllowedHosts_EmptyListDisables(t *testing.T) {
+	h := AllowedHosts(nil, nil)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Host = "anything.test"
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestAllowedHosts_ForwardedHost(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	h := AllowedHosts([]string{"api.example.com"}, trusted)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     string
+		value      string
+		want       int
+	}{
+		{"trusted proxy, allowed X-Forwarded-Host", "10.0.0.5:4000", "X-Forwarded-Host", "api.example.com", http.StatusOK},
+		{"trusted proxy, disallowed X-Forwarded-Host", "10.0.0.5:4000", "X-Forwarded-Host", "evil.test", http.StatusBadRequest},
+		{"trusted proxy, disallowed Forwarded host", "10.0.0.5:4000", "Forwarded", "host=evil.test", http.StatusBadRequest},
+		{"untrusted client, header ignored", "203.0.113.7:4000", "X-Forwarded-Host", "evil.test", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+			req.Host = "api.example.com"
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set(tt.header, tt.value)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
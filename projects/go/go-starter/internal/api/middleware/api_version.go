@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/yourusername/go-starter/internal/api/jsonapi"
+)
+
+// APIVersion makes every JSON:API document, errors included, report version
+// in its top-level meta.api_version
+func APIVersion(version string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(jsonapi.WithAPIVersion(r.Context(), version)))
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/go-starter/internal/auth"
+	"github.com/yourusername/go-starter/internal/models"
+)
+
+func TestRequireRole(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	token := func(role string) string {
+		t.Helper()
+		tok, err := auth.GenerateToken(uuid.New(), "jane@example.com", role, secret, time.Hour)
+		require.NoError(t, err)
+		return "Bearer " + tok
+	}
+
+	var gotRole string
+	handler := Authenticate(secret)(RequireRole(models.RoleAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotRole = GetRole(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	})))
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+		wantCode      string
+	}{
+		{name: "admin allowed", authorization: token(models.RoleAdmin), wantStatus: http.StatusNoContent},
+		{name: "user forbidden", authorization: token(models.RoleUser), wantStatus: http.StatusForbidden, wantCode: "FORBIDDEN"},
+		{name: "missing token", wantStatus: http.StatusUnauthorized, wantCode: "UNAUTHORIZED"},
+		{name: "invalid token", authorization: "Bearer nope", wantStatus: http.StatusUnauthorized, wantCode: "UNAUTHORIZED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, "/", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantCode != "" {
+				assert.Contains(t, rec.Body.String(), `"code":"`+tt.wantCode+`"`)
+			} else {
+				assert.Equal(t, models.RoleAdmin, gotRole)
+			}
+		})
+	}
+}
+
+func TestRequireRole_WithoutAuthenticate(t *testing.T) {
+	handler := RequireRole(models.RoleAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler must not run")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ClientIP resolves the address of the client that originated r.
+//
+// Forwarding headers are only honored when the immediate peer (RemoteAddr)
+// is inside trustedProxies; otherwise any client could spoof its address by
+// sending the headers itself. When the peer is trusted, headers are checked
+// in this order and the first one that yields an address wins:
+//
+//  1. Forwarded (RFC 7239), the standardized header
+//  2. X-Forwarded-For, the de facto standard
+//  3. X-Real-IP, a single address set by nginx-style proxies
+//
+// Forwarded and X-Forwarded-For are lists that each proxy appends to, so
+// they are walked right to left, skipping hops that are themselves trusted
+// proxies. The first untrusted hop is the client. If every hop is trusted
+// the left-most one is used. With no usable header, RemoteAddr is returned.
+func ClientIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	peer, ok := parseAddr(peerAddr(r))
+	if !ok {
+		return r.RemoteAddr
+	}
+
+	if !isTrusted(peer, trustedProxies) {
+		return peer.String()
+	}
+
+	if ip, ok := fromHops(forwardedFor(r.Header.Values("Forwarded")), trustedProxies); ok {
+		return ip.String()
+	}
+
+	if ip, ok := fromHops(splitList(r.Header.Values("X-Forwarded-For")), trustedProxies); ok {
+		return ip.String()
+	}
+
+	if ip, ok := parseAddr(r.Header.Get("X-Real-IP")); ok {
+		return ip.String()
+	}
+
+	return peer.String()
+}
+
+// fromHops walks a proxy chain from the closest hop outwards
+func fromHops(hops []string, trustedProxies []netip.Prefix) (netip.Addr, bool) {
+	var leftmost netip.Addr
+	found := false
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip, ok := parseAddr(hops[i])
+		if !ok {
+			// A garbled hop means nothing further left can be trusted
+			break
+		}
+		if !isTrusted(ip, trustedProxies) {
+			return ip, true
+		}
+		leftmost, found = ip, true
+	}
+
+	return leftmost, found
+}
+
+// forwardedFor extracts the for= parameters from Forwarded header values
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, element := range splitList(values) {
+		for _, pair := range strings.Split(element, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok || !strings.EqualFold(key, "for") {
+				continue
+			}
+			hops = append(hops, strings.Trim(value, `"`))
+		}
+	}
+	return hops
+}
+
+// splitList flattens comma-separated header values
+func splitList(values []string) []string {
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// parseAddr accepts a bare IP, "ip:port", or "[ipv6]:port"
+func parseAddr(s string) (netip.Addr, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return netip.Addr{}, false
+	}
+
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+
+	ip, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ip.Unmap(), true
+}
+
+func isTrusted(ip netip.Addr, trustedProxies []netip.Prefix) bool {
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{
+			name:       "untrusted peer cannot spoof",
+			remoteAddr: "203.0.113.7:5000",
+			headers:    map[string]string{"X-Forwarded-For": "1.2.3.4"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "trusted peer, right-most untrusted hop wins",
+			remoteAddr: "10.0.0.1:5000",
+			headers:    map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.9, 10.0.0.2"},
+			want:       "198.51.100.9",
+		},
+		{
+			name:       "Forwarded takes precedence over X-Forwarded-For",
+			remoteAddr: "10.0.0.1:5000",
+			headers: map[string]string{
+				"Forwarded":       `for="[2001:db8::17]:4711";proto=https`,
+				"X-Forwarded-For": "198.51.100.9",
+			},
+			want: "2001:db8::17",
+		},
+		{
+			name:       "X-Real-IP used as last resort",
+			remoteAddr: "10.0.0.1:5000",
+			headers:    map[string]string{"X-Real-IP": "198.51.100.9"},
+			want:       "198.51.100.9",
+		},
+		{
+			name:       "trusted peer without headers",
+			remoteAddr: "[::ffff:10.0.0.1]:5000",
+			want:       "10.0.0.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			assert.Equal(t, tt.want, ClientIP(r, trusted))
+		})
+	}
+}
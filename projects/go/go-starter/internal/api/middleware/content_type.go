@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/yourusername/go-starter/internal/api/jsonapi"
+)
+
+// MediaTypeMode selects how strictly RequireJSONAPIContentType reads the
+// Content-Type of request bodies
+type MediaTypeMode string
+
+const (
+	// MediaTypeRelaxed also accepts "application/vnd.api+json;
+	// charset=utf-8", which some proxies and HTTP clients add on their own
+	MediaTypeRelaxed MediaTypeMode = "relaxed"
+	// MediaTypeStrict accepts only "application/vnd.api+json" without any
+	// media type parameters, as the JSON:API spec requires
+	MediaTypeStrict MediaTypeMode = "strict"
+)
+
+// ContentTypeOption configures RequireJSONAPIContentType
+type ContentTypeOption func(allowed map[string][]string)
+
+// AllowMediaType also accepts mediaType, with any parameters, on requests
+// with method, e.g. JSON Merge Patch on PATCH. The handler must then look at
+// the Content-Type to tell the formats apart.
+func AllowMediaType(method, mediaType string) ContentTypeOption {
+	return func(allowed map[string][]string) {
+		allowed[method] = append(allowed[method], mediaType)
+	}
+}
+
+// RequireJSONAPIContentType answers 415 to POST, PUT and PATCH requests
+// whose Content-Type isn't the JSON:API media type or one allowed with
+// AllowMediaType. Other methods carry no body here and pass through
+// untouched. Register it on the routes that decode JSON:API documents.
+func RequireJSONAPIContentType(mode MediaTypeMode, opts ...ContentTypeOption) func(http.Handler) http.Handler {
+	allowed := make(map[string][]string)
+	for _, opt := range opts {
+		opt(allowed)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost, http.MethodPut, http.MethodPatch:
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			contentType := r.Header.Get("Content-Type")
+			if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && slices.Contains(allowed[r.Method], mediaType) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if err := checkMediaType(contentType, mode); err != nil {
+				ctx := r.Context()
+				jsonapi.WriteError(ctx, w, GetRequestID(ctx), http.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE", err.Error())
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// checkMediaType explains why contentType is not acceptable in mode, or
+// returns nil if it is
+func checkMediaType(contentType string, mode MediaTypeMode) error {
+	if contentType == "" {
+		return fmt.Errorf("Content-Type must be %s", jsonapi.MediaType)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != jsonapi.MediaType {
+		return fmt.Errorf("Content-Type must be %s, got %q", jsonapi.MediaType, contentType)
+	}
+
+	for name, value := range params {
+		if mode == MediaTypeRelaxed && name == "charset" && strings.EqualFold(value, "utf-8") {
+			continue
+		}
+		return fmt.Errorf("Content-Type must not have media type parameters, got %q", contentType)
+	}
+
+	return nil
+}
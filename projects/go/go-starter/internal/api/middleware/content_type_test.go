@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireJSONAPIContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		wantRelaxed int
+		wantStrict  int
+	}{
+		{name: "exact", contentType: "application/vnd.api+json", wantRelaxed: http.StatusOK, wantStrict: http.StatusOK},
+		{name: "case-insensitive type", contentType: "Application/VND.API+JSON", wantRelaxed: http.StatusOK, wantStrict: http.StatusOK},
+		{name: "charset utf-8", contentType: "application/vnd.api+json; charset=utf-8", wantRelaxed: http.StatusOK, wantStrict: http.StatusUnsupportedMediaType},
+		{name: "charset UTF-8", contentType: "application/vnd.api+json; charset=UTF-8", wantRelaxed: http.StatusOK, wantStrict: http.StatusUnsupportedMediaType},
+		{name: "other charset", contentType: "application/vnd.api+json; charset=latin1", wantRelaxed: http.StatusUnsupportedMediaType, wantStrict: http.StatusUnsupportedMediaType},
+		{name: "other parameter", contentType: `application/vnd.api+json; ext="https://example.com/ext"`, wantRelaxed: http.StatusUnsupportedMediaType, wantStrict: http.StatusUnsupportedMediaType},
+		{name: "plain json", contentType: "application/json", wantRelaxed: http.StatusUnsupportedMediaType, wantStrict: http.StatusUnsupportedMediaType},
+		{name: "malformed", contentType: "application/vnd.api+json;;", wantRelaxed: http.StatusUnsupportedMediaType, wantStrict: http.StatusUnsupportedMediaType},
+		{name: "missing", wantRelaxed: http.StatusUnsupportedMediaType, wantStrict: http.StatusUnsupportedMediaType},
+		{name: "GET is not checked", method: http.MethodGet, contentType: "text/plain", wantRelaxed: http.StatusOK, wantStrict: http.StatusOK},
+	}
+
+	for _, mode := range []MediaTypeMode{MediaTypeRelaxed, MediaTypeStrict} {
+		h := RequireJSONAPIContentType(mode)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		for _, tt := range tests {
+			t.Run(string(mode)+"/"+tt.name, func(t *testing.T) {
+				method := tt.method
+				if method == "" {
+					method = http.MethodPost
+				}
+				req := httptest.NewRequest(method, "/users/bulk", strings.NewReader(`{"data":[]}`))
+				if tt.contentType != "" {
+					req.Header.Set("Content-Type", tt.contentType)
+				}
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, req)
+
+				want := tt.wantRelaxed
+				if mode == MediaTypeStrict {
+					want = tt.wantStrict
+				}
+				assert.Equal(t, want, rec.Code)
+				if want != http.StatusOK {
+					assert.Contains(t, rec.Body.String(), `"code":"UNSUPPORTED_MEDIA_TYPE"`)
+				}
+			})
+		}
+	}
+}
+
+func TestRequireJSONAPIContentType_AllowMediaType(t *testing.T) {
+	h := RequireJSONAPIContentType(MediaTypeStrict, AllowMediaType(http.MethodPatch, "application/merge-patch+json"))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	)
+
+	tests := []struct {
+		method      string
+		contentType string
+		want        int
+	}{
+		{http.MethodPatch, "application/merge-patch+json", http.StatusOK},
+		{http.MethodPatch, "application/merge-patch+json; charset=utf-8", http.StatusOK},
+		{http.MethodPatch, "application/vnd.api+json", http.StatusOK},
+		{http.MethodPost, "application/merge-patch+json", http.StatusUnsupportedMediaType},
+		{http.MethodPatch, "application/json", http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.contentType, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/users/1", strings.NewReader(`{}`))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
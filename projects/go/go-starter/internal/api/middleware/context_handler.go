@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+)
+
+// contextAttrs extracts the request-scoped values that ContextHandler adds
+// to every record. Add an extractor here for new values (trace ID, user ID)
+// instead of passing them to each log call.
+var contextAttrs = []func(ctx context.Context) (slog.Attr, bool){
+	func(ctx context.Context) (slog.Attr, bool) {
+		reqID := GetRequestID(ctx)
+		return slog.String("request_id", reqID), reqID != ""
+	},
+	func(ctx context.Context) (slog.Attr, bool) {
+		userID := GetUserID(ctx)
+		return slog.String("user_id", userID), userID != ""
+	},
+}
+
+// ContextHandler is a slog.Handler that copies request-scoped values such as
+// request_id from the context onto each record, so any
+// logger.InfoContext(ctx, ...) call is correlated without repeating them.
+// Calls without a context (logger.Info) carry no request values.
+type ContextHandler struct {
+	slog.Handler
+}
+
+// NewContextHandler wraps h
+func NewContextHandler(h slog.Handler) *ContextHandler {
+	return &ContextHandler{Handler: h}
+}
+
+// Handle adds the context values and passes the record on
+func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	for _, extract := range contextAttrs {
+		if attr, ok := extract(ctx); ok {
+			r.AddAttrs(attr)
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs keeps the wrapper around the derived handler
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup keeps the wrapper around the derived handler. Context values of
+// a grouped logger land inside the group.
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewContextHandler(slog.NewJSONHandler(&buf, nil))).With("component", "test")
+
+	ctx := context.WithValue(context.Background(), requestIDKey, "req-123")
+	logger.InfoContext(ctx, "with request")
+	logger.Info("without request")
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var withReq, withoutReq map[string]any
+	require.NoError(t, json.Unmarshal(lines[0], &withReq))
+	require.NoError(t, json.Unmarshal(lines[1], &withoutReq))
+
+	assert.Equal(t, "req-123", withReq["request_id"])
+	assert.Equal(t, "test", withReq["component"])
+	assert.NotContains(t, withoutReq, "request_id")
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Deprecation describes an endpoint that is going away
+type Deprecation struct {
+	// Since is when the endpoint was deprecated, sent as the Deprecation
+	// header (RFC 9745)
+	Since time.Time
+	// Sunset, if set, is when the endpoint stops working, sent as the
+	// Sunset header (RFC 8594)
+	Sunset time.Time
+	// Link, if set, points at migration docs and is sent as a Link header
+	// with rel="deprecation"
+	Link string
+}
+
+// DeprecationRegistry records which routes are deprecated. Routes are
+// identified by method and full chi pattern, e.g. "GET" and
+// "/api/v1/users/{id}", as listed by api.Routes; a trailing slash is
+// ignored.
+type DeprecationRegistry struct {
+	mu     sync.RWMutex
+	routes map[string]Deprecation
+}
+
+// NewDeprecationRegistry creates an empty registry
+func NewDeprecationRegistry() *DeprecationRegistry {
+	return &DeprecationRegistry{routes: make(map[string]Deprecation)}
+}
+
+// Deprecate marks method + pattern as deprecated
+func (d *DeprecationRegistry) Deprecate(method, pattern string, dep Deprecation) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.routes[routeKey(method, pattern)] = dep
+}
+
+// Lookup returns the deprecation of method + pattern, if any
+func (d *DeprecationRegistry) Lookup(method, pattern string) (Deprecation, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	dep, ok := d.routes[routeKey(method, pattern)]
+	return dep, ok
+}
+
+func routeKey(method, pattern string) string {
+	if pattern != "/" {
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	return method + " " + pattern
+}
+
+// Middleware adds the deprecation headers to responses of deprecated
+// routes. The matched route is only known once chi has finished routing,
+// so the headers are added just before the response header is written
+// rather than up front; this lets the middleware sit on a parent router.
+func (d *DeprecationRegistry) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&deprecationWriter{ResponseWriter: w, r: r, registry: d}, r)
+	})
+}
+
+// SetHeaders writes the headers for dep
+func (dep Deprecation) SetHeaders(h http.Header) {
+	h.Set("Deprecation", fmt.Sprintf("@%d", dep.Since.Unix()))
+	if !dep.Sunset.IsZero() {
+		h.Set("Sunset", dep.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if dep.Link != "" {
+		h.Add("Link", fmt.Sprintf(`<%s>; rel="deprecation"`, dep.Link))
+	}
+}
+
+// deprecationWriter looks up the route on the first write
+type deprecationWriter struct {
+	http.ResponseWriter
+	r        *http.Request
+	registry *DeprecationRegistry
+	done     bool
+}
+
+func (dw *deprecationWriter) WriteHeader(status int) {
+	dw.setHeaders()
+	dw.ResponseWriter.WriteHeader(status)
+}
+
+func (dw *deprecationWriter) Write(b []byte) (int, error) {
+	dw.setHeaders()
+	return dw.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (dw *deprecationWriter) Unwrap() http.ResponseWriter {
+	return dw.ResponseWriter
+}
+
+func (dw *deprecationWriter) setHeaders() {
+	if dw.done {
+		return
+	}
+	dw.done = true
+
+	// The handler is writing, so routing is done
+	if dep, ok := dw.registry.Lookup(dw.r.Method, RoutePattern(dw.r)); ok {
+		dep.SetHeaders(dw.Header())
+	}
+}
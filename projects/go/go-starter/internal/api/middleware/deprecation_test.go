@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeprecationRegistry(t *testing.T) {
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	deprecations := NewDeprecationRegistry()
+	deprecations.Deprecate(http.MethodGet, "/api/v1/users/{id}", Deprecation{
+		Since:  since,
+		Sunset: sunset,
+		Link:   "https://example.com/migrate",
+	})
+	deprecations.Deprecate(http.MethodGet, "/api/v1/users", Deprecation{Since: since})
+
+	ok := func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }
+	r := chi.NewRouter()
+	// Registered on the root router, before the route is known
+	r.Use(deprecations.Middleware)
+	r.Route("/api/v1/users", func(r chi.Router) {
+		r.Get("/", ok)
+		r.Get("/{id}", ok)
+		r.Patch("/{id}", ok)
+	})
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		wantDep     string
+		wantSunset  string
+		wantLinkRel bool
+	}{
+		{
+			name:        "deprecated route",
+			method:      http.MethodGet,
+			path:        "/api/v1/users/42",
+			wantDep:     "@1767225600",
+			wantSunset:  "Wed, 01 Jul 2026 00:00:00 GMT",
+			wantLinkRel: true,
+		},
+		{
+			name:    "sub-router root without sunset",
+			method:  http.MethodGet,
+			path:    "/api/v1/users/",
+			wantDep: "@1767225600",
+		},
+		{
+			name:   "other method on the same pattern",
+			method: http.MethodPatch,
+			path:   "/api/v1/users/42",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tt.wantDep, rec.Header().Get("Deprecation"))
+			assert.Equal(t, tt.wantSunset, rec.Header().Get("Sunset"))
+			if tt.wantLinkRel {
+				assert.Equal(t, `<https://example.com/migrate>; rel="deprecation"`, rec.Header().Get("Link"))
+			} else {
+				assert.Empty(t, rec.Header().Get("Link"))
+			}
+		})
+	}
+}
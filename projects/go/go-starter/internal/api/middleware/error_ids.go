@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/yourusername/go-starter/internal/api/jsonapi"
+)
+
+// ErrorIDs gives every JSON:API error object a UUID id that is logged with
+// its code, so an id a client reports leads to the exact log line
+func ErrorIDs(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(jsonapi.WithErrorIDs(r.Context())))
+	})
+}
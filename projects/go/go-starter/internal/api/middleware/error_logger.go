@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/yourusername/go-starter/internal/api/jsonapi"
+)
+
+// ErrorLogger sends the error_code line logged for every JSON:API error
+// response to logger
+func ErrorLogger(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(jsonapi.WithLogger(r.Context(), logger)))
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+)
+
+// HandlerNames maps routes to the Go function serving them, so request
+// logs point straight at the code. Routes are identified by method and full
+// chi pattern, like in DeprecationRegistry. The router fills it in once all
+// routes are registered.
+type HandlerNames struct {
+	mu    sync.RWMutex
+	names map[string]string
+}
+
+// NewHandlerNames creates an empty HandlerNames
+func NewHandlerNames() *HandlerNames {
+	return &HandlerNames{names: make(map[string]string)}
+}
+
+// Set records name, e.g. "handlers.(*UserHandler).GetUser", as the handler
+// of method + pattern
+func (n *HandlerNames) Set(method, pattern, name string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.names[routeKey(method, pattern)] = name
+}
+
+// Lookup returns the handler name of method + pattern, if any
+func (n *HandlerNames) Lookup(method, pattern string) (string, bool) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	name, ok := n.names[routeKey(method, pattern)]
+	return name, ok && name != ""
+}
+
+// handlerName returns the name of the handler that served r, falling back
+// to its route pattern when there is none. It must be called once routing
+// is done.
+func (n *HandlerNames) handlerName(r *http.Request) string {
+	pattern := RoutePattern(r)
+	if n != nil {
+		if name, ok := n.Lookup(r.Method, pattern); ok {
+			return name
+		}
+	}
+	return pattern
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// Head lets a GET handler answer HEAD requests. The handler runs as usual
+// but its body is discarded, and the status is held back until it returns
+// so that Content-Length can announce the size the GET body would have
+// had. Register it on the HEAD route only, e.g.
+//
+//	r.With(middleware.Head).Head("/{id}", userHandler.GetUser)
+func Head(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hw := &headWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(hw, r)
+		hw.flush()
+	})
+}
+
+// headWriter counts body bytes instead of sending them
+type headWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	size        int
+}
+
+func (hw *headWriter) WriteHeader(status int) {
+	if hw.wroteHeader {
+		return
+	}
+	hw.wroteHeader = true
+	hw.status = status
+}
+
+func (hw *headWriter) Write(b []byte) (int, error) {
+	hw.WriteHeader(http.StatusOK)
+	hw.size += len(b)
+	return len(b), nil
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (hw *headWriter) Unwrap() http.ResponseWriter {
+	return hw.ResponseWriter
+}
+
+// flush sends the held-back status with the counted Content-Length. A
+// length the handler set itself wins, and responses that never carry a
+// body (1xx, 204, 304) get none.
+func (hw *headWriter) flush() {
+	h := hw.Header()
+	if h.Get("Content-Length") == "" && bodyAllowed(hw.status) {
+		h.Set("Content-Length", strconv.Itoa(hw.size))
+	}
+	hw.ResponseWriter.WriteHeader(hw.status)
+}
+
+func bodyAllowed(status int) bool {
+	return status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHead(t *testing.T) {
+	get := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"3"`)
+		if r.Header.Get("If-None-Match") == `"3"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data":`))
+		w.Write([]byte(`{"type":"users","id":"42"}}`))
+	}
+
+	r := chi.NewRouter()
+	r.Get("/users/{id}", get)
+	r.With(Head).Head("/users/{id}", get)
+
+	getRec := httptest.NewRecorder()
+	r.ServeHTTP(getRec, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	require.Equal(t, http.StatusOK, getRec.Code)
+
+	headRec := httptest.NewRecorder()
+	r.ServeHTTP(headRec, httptest.NewRequest(http.MethodHead, "/users/42", nil))
+
+	assert.Equal(t, http.StatusOK, headRec.Code)
+	assert.Empty(t, headRec.Body.Bytes())
+	assert.Equal(t, strconv.Itoa(getRec.Body.Len()), headRec.Header().Get("Content-Length"))
+	assert.Equal(t, `"3"`, headRec.Header().Get("ETag"))
+	assert.Equal(t, "application/vnd.api+json", headRec.Header().Get("Content-Type"))
+
+	t.Run("not modified", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodHead, "/users/42", nil)
+		req.Header.Set("If-None-Match", `"3"`)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+		assert.Empty(t, rec.Header().Get("Content-Length"))
+		assert.Equal(t, `"3"`, rec.Header().Get("ETag"))
+	})
+
+	t.Run("implicit status", func(t *testing.T) {
+		implicit := chi.NewRouter()
+		implicit.With(Head).Head("/", func(w http.ResponseWriter, _ *http.Request) {
+			w.Write([]byte("hello"))
+		})
+		rec := httptest.NewRecorder()
+		implicit.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "5", rec.Header().Get("Content-Length"))
+		assert.Empty(t, rec.Body.Bytes())
+	})
+}
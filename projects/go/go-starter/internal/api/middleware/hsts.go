@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HSTSMaxAge is how long browsers remember to use HTTPS only
+const HSTSMaxAge = 365 * 24 * time.Hour
+
+// HSTSValue is the Strict-Transport-Security header value sent by HSTS
+var HSTSValue = "max-age=" + strconv.Itoa(int(HSTSMaxAge.Seconds())) + "; includeSubDomains"
+
+// HSTS sets Strict-Transport-Security on responses to TLS requests. Browsers
+// ignore the header over plain HTTP, so it has to come from the HTTPS side.
+func HSTS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil {
+			w.Header().Set("Strict-Transport-Security", HSTSValue)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
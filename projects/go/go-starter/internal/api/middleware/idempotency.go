@@ -0,0 +1,389 @@
+package middleware
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/yourusername/go-starter/internal/api/jsonapi"
+)
+
+// IdempotencyKeyHeader is the request header clients use to make a POST safe
+// to retry
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// ErrIdempotencyInFlight is returned by an IdempotencyStore when another
+// request holding the same key has not finished yet
+var ErrIdempotencyInFlight = errors.New("idempotency key in flight")
+
+// idempotencyMaxBody bounds how much of a body is read up front to
+// fingerprint it. It matches the handlers' own limit, which rejects anything
+// longer anyway.
+const idempotencyMaxBody = 1 << 20
+
+// StoredResponse is a captured response that can be replayed byte for byte
+type StoredResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+	// Fingerprint identifies the request the response belongs to, see
+	// fingerprint. A retry with a different one is rejected, not replayed.
+	Fingerprint string
+}
+
+// IdempotencyStore persists responses keyed by idempotency key
+type IdempotencyStore interface {
+	// Lock reserves key for the calling request. If key already has a
+	// completed response it is returned instead; if another request holds
+	// key, ErrIdempotencyInFlight is returned.
+	Lock(ctx context.Context, key string) (*StoredResponse, error)
+	// Save stores resp under key and releases the reservation
+	Save(ctx context.Context, key string, resp *StoredResponse) error
+	// Unlock releases the reservation without storing anything, so a retry
+	// will run the handler again
+	Unlock(ctx context.Context, key string) error
+}
+
+// Idempotency middleware replays the first response for POST requests that
+// carry an Idempotency-Key header. Keys are scoped to the client IP, so
+// RealIP must run first behind a proxy. Retries within the store's TTL get
+// the stored status, headers and body; a retry arriving while the original
+// is still running gets 409, and reusing a key for a different method,
+// path or body gets 422. Server errors are not stored so that a retry can
+// succeed.
+func Idempotency(store IdempotencyStore, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if r.Method != http.MethodPost || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+			reqID := GetRequestID(ctx)
+			// Scope the key to the client so two clients picking the same
+			// key never see each other's responses
+			storeKey := remoteIP(r) + " " + key
+
+			fp, err := fingerprint(r)
+			if err != nil {
+				jsonapi.WriteError(ctx, w, reqID, http.StatusBadRequest, "INVALID_BODY", "The request body could not be read")
+				return
+			}
+
+			stored, err := store.Lock(ctx, storeKey)
+			if errors.Is(err, ErrIdempotencyInFlight) {
+				jsonapi.WriteError(ctx, w, reqID, http.StatusConflict, "IDEMPOTENCY_KEY_IN_USE", "A request with this Idempotency-Key is still being processed")
+				return
+			}
+			if err != nil {
+				logger.ErrorContext(ctx, "idempotency store lock failed",
+					slog.String("error", err.Error()),
+				)
+				jsonapi.WriteError(ctx, w, reqID, http.StatusInternalServerError, "INTERNAL_ERROR", "An unexpected error occurred")
+				return
+			}
+			if stored != nil {
+				if stored.Fingerprint != fp {
+					jsonapi.WriteError(ctx, w, reqID, http.StatusUnprocessableEntity, "IDEMPOTENCY_KEY_REUSED", "This Idempotency-Key was already used for a different request")
+					return
+				}
+				replay(w, stored)
+				return
+			}
+
+			rec := &recordingWriter{ResponseWriter: w}
+			completed := false
+			defer func() {
+				// Release the key if the handler panicked or failed so the
+				// client isn't locked out of retrying
+				if completed {
+					return
+				}
+				if err := store.Unlock(context.WithoutCancel(ctx), storeKey); err != nil {
+					logger.ErrorContext(ctx, "idempotency store unlock failed",
+						slog.String("error", err.Error()),
+					)
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+
+			// Nothing was written for a client that went away, so there is
+			// nothing to replay when it retries
+			if rec.code == 0 && ctx.Err() != nil {
+				return
+			}
+			if rec.status() >= http.StatusInternalServerError {
+				return
+			}
+
+			resp := rec.response()
+			resp.Fingerprint = fp
+			if err := store.Save(context.WithoutCancel(ctx), storeKey, resp); err != nil {
+				logger.ErrorContext(ctx, "idempotency store save failed",
+					slog.String("error", err.Error()),
+				)
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+// fingerprint hashes the method, path and body of r, leaving the body in
+// place for the handler
+func fingerprint(r *http.Request) (string, error) {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.Path+"\n")
+
+	if r.Body != nil {
+		body, err := io.ReadAll(io.LimitReader(r.Body, idempotencyMaxBody+1))
+		if err != nil {
+			return "", err
+		}
+		h.Write(body)
+		// Whatever is past the limit stays unread for the handler to reject
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// replay writes a stored response, keeping the current request's ID header
+func replay(w http.ResponseWriter, stored *StoredResponse) {
+	for name, values := range stored.Header {
+		if name == "X-Request-Id" {
+			continue
+		}
+		w.Header()[name] = append([]string(nil), values...)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(stored.Status)
+	w.Write(stored.Body)
+}
+
+// recordingWriter passes the response through while keeping a copy of it
+type recordingWriter struct {
+	http.ResponseWriter
+	code   int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(code int) {
+	if rw.code == 0 {
+		rw.code = code
+		// Snapshot now: header changes after this point never reach the client
+		rw.header = rw.ResponseWriter.Header().Clone()
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	if rw.code == 0 {
+		rw.WriteHeader(http.StatusOK)
+	}
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (rw *recordingWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+func (rw *recordingWriter) status() int {
+	if rw.code == 0 {
+		return http.StatusOK
+	}
+	return rw.code
+}
+
+func (rw *recordingWriter) response() *StoredResponse {
+	header := rw.header
+	if header == nil {
+		header = rw.ResponseWriter.Header().Clone()
+	}
+	return &StoredResponse{
+		Status: rw.status(),
+		Header: header,
+		Body:   bytes.Clone(rw.body.Bytes()),
+	}
+}
+
+// Default bounds of the in-memory store. A client picks its own keys, so
+// without them rotating keys would grow the store without limit.
+const (
+	defaultIdempotencyMaxEntries = 10_000
+	defaultIdempotencyMaxBytes   = 64 << 20
+)
+
+// idempotencySweepInterval is the longest expired entries are kept
+const idempotencySweepInterval = time.Minute
+
+// MemoryIdempotencyOption configures NewMemoryIdempotencyStore
+type MemoryIdempotencyOption func(*memoryIdempotencyStore)
+
+// WithMaxEntries caps the number of keys held, 10000 by default
+func WithMaxEntries(n int) MemoryIdempotencyOption {
+	return func(s *memoryIdempotencyStore) {
+		s.maxEntries = n
+	}
+}
+
+// WithMaxBytes caps the total size of the stored keys and responses,
+// 64 MiB by default
+func WithMaxBytes(n int) MemoryIdempotencyOption {
+	return func(s *memoryIdempotencyStore) {
+		s.maxBytes = n
+	}
+}
+
+// memoryIdempotencyStore keeps responses in process memory, evicting the
+// least recently used key once it holds too many keys or bytes
+type memoryIdempotencyStore struct {
+	ttl        time.Duration
+	maxEntries int
+	maxBytes   int
+	now        func() time.Time
+
+	mu        sync.Mutex
+	order     *list.List // front is most recently used
+	entries   map[string]*list.Element
+	bytes     int
+	lastSweep time.Time
+}
+
+type idempotencyEntry struct {
+	key       string
+	response  *StoredResponse // nil while the request is in flight
+	expiresAt time.Time
+	size      int
+}
+
+// NewMemoryIdempotencyStore creates an in-memory IdempotencyStore whose
+// responses expire after ttl. It is only suitable for a single instance.
+// An evicted key is forgotten, so a retry with it runs the request again.
+func NewMemoryIdempotencyStore(ttl time.Duration, opts ...MemoryIdempotencyOption) IdempotencyStore {
+	s := &memoryIdempotencyStore{
+		ttl:        ttl,
+		maxEntries: defaultIdempotencyMaxEntries,
+		maxBytes:   defaultIdempotencyMaxBytes,
+		now:        time.Now,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *memoryIdempotencyStore) Lock(_ context.Context, key string) (*StoredResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	if elem, ok := s.entries[key]; ok {
+		entry := elem.Value.(*idempotencyEntry)
+		if now.Before(entry.expiresAt) {
+			s.order.MoveToFront(elem)
+			if entry.response == nil {
+				return nil, ErrIdempotencyInFlight
+			}
+			return entry.response, nil
+		}
+	}
+
+	s.put(key, nil, now)
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) Save(_ context.Context, key string, resp *StoredResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.put(key, resp, s.now())
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Unlock(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[key]; ok {
+		s.remove(elem)
+	}
+	return nil
+}
+
+// put stores resp under key as the most recently used entry and evicts
+// from the back until the store is within its bounds again; a response
+// larger than maxBytes evicts itself. Callers must hold s.mu.
+func (s *memoryIdempotencyStore) put(key string, resp *StoredResponse, now time.Time) {
+	if elem, ok := s.entries[key]; ok {
+		s.remove(elem)
+	}
+
+	entry := &idempotencyEntry{key: key, response: resp, expiresAt: now.Add(s.ttl), size: len(key) + resp.size()}
+	s.entries[key] = s.order.PushFront(entry)
+	s.bytes += entry.size
+
+	for s.order.Len() > s.maxEntries || s.bytes > s.maxBytes {
+		s.remove(s.order.Back())
+	}
+}
+
+// remove drops an entry. Callers must hold s.mu.
+func (s *memoryIdempotencyStore) remove(elem *list.Element) {
+	entry := s.order.Remove(elem).(*idempotencyEntry)
+	delete(s.entries, entry.key)
+	s.bytes -= entry.size
+}
+
+// sweep drops expired entries at most once per idempotencySweepInterval, or
+// per ttl if that is shorter. Callers must hold s.mu.
+func (s *memoryIdempotencyStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < min(s.ttl, idempotencySweepInterval) {
+		return
+	}
+	for elem := s.order.Front(); elem != nil; {
+		next := elem.Next()
+		if !now.Before(elem.Value.(*idempotencyEntry).expiresAt) {
+			s.remove(elem)
+		}
+		elem = next
+	}
+	s.lastSweep = now
+}
+
+// size approximates the memory held by resp; nil for an in-flight entry
+// is 0
+func (resp *StoredResponse) size() int {
+	if resp == nil {
+		return 0
+	}
+	n := len(resp.Body) + len(resp.Fingerprint)
+	for name, values := range resp.Header {
+		n += len(name)
+		for _, v := range values {
+			n += len(v)
+		}
+	}
+	return n
+}
@@ -0,0 +1,194 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotency_ReplaysFirstResponse(t *testing.T) {
+	calls := 0
+	handler := Idempotency(NewMemoryIdempotencyStore(time.Minute), slog.New(slog.NewTextHandler(io.Discard, nil)))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("Content-Type", "application/vnd.api+json")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"data":{"id":"1"}}`))
+		}),
+	)
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/users", nil)
+		req.Header.Set(IdempotencyKeyHeader, "abc")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send()
+	second := send()
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, first.Code, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "application/vnd.api+json", second.Header().Get("Content-Type"))
+	assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+}
+
+func TestIdempotency_InFlightConflict(t *testing.T) {
+	store := NewMemoryIdempotencyStore(time.Minute)
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	handler := Idempotency(store, slog.New(slog.NewTextHandler(io.Discard, nil)))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+			w.WriteHeader(http.StatusCreated)
+		}),
+	)
+
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/users", nil)
+		req.Header.Set(IdempotencyKeyHeader, "abc")
+		return req
+	}
+
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest())
+		close(done)
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newRequest())
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	close(release)
+	<-done
+}
+
+func TestIdempotency_Fingerprint(t *testing.T) {
+	var bodies []string
+	handler := Idempotency(NewMemoryIdempotencyStore(time.Minute), slog.New(slog.NewTextHandler(io.Discard, nil)))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			w.WriteHeader(http.StatusCreated)
+		}),
+	)
+
+	send := func(remoteAddr, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		req.RemoteAddr = remoteAddr
+		req.Header.Set(IdempotencyKeyHeader, "abc")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send("192.0.2.1:1234", "/users", `{"name":"a"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, []string{`{"name":"a"}`}, bodies, "the handler still reads the whole body")
+
+	rec = send("192.0.2.1:5678", "/users", `{"name":"a"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "true", rec.Header().Get("Idempotent-Replayed"), "same client, new port")
+
+	rec = send("192.0.2.1:1234", "/users", `{"name":"b"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, "different body")
+	assert.Contains(t, rec.Body.String(), "IDEMPOTENCY_KEY_REUSED")
+
+	rec = send("192.0.2.1:1234", "/users/bulk", `{"name":"a"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, "different path")
+
+	rec = send("198.51.100.9:1234", "/users", `{"name":"b"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code, "another client's key is its own")
+	assert.Empty(t, rec.Header().Get("Idempotent-Replayed"))
+
+	assert.Len(t, bodies, 2)
+}
+
+func TestMemoryIdempotencyStore_Bounds(t *testing.T) {
+	ctx := context.Background()
+	saved := func(body string) *StoredResponse {
+		return &StoredResponse{Status: http.StatusCreated, Body: []byte(body)}
+	}
+
+	t.Run("entries", func(t *testing.T) {
+		store := NewMemoryIdempotencyStore(time.Hour, WithMaxEntries(2)).(*memoryIdempotencyStore)
+		for _, key := range []string{"a", "b"} {
+			_, err := store.Lock(ctx, key)
+			require.NoError(t, err)
+			require.NoError(t, store.Save(ctx, key, saved(key)))
+		}
+
+		// a is used again, so b is the least recently used
+		resp, err := store.Lock(ctx, "a")
+		require.NoError(t, err)
+		require.NotNil(t, resp)
+
+		_, err = store.Lock(ctx, "c")
+		require.NoError(t, err)
+		assert.Equal(t, 2, store.order.Len())
+
+		resp, err = store.Lock(ctx, "b")
+		require.NoError(t, err)
+		assert.Nil(t, resp, "b was evicted")
+	})
+
+	t.Run("bytes", func(t *testing.T) {
+		store := NewMemoryIdempotencyStore(time.Hour, WithMaxBytes(100)).(*memoryIdempotencyStore)
+		require.NoError(t, store.Save(ctx, "a", saved(strings.Repeat("x", 60))))
+		require.NoError(t, store.Save(ctx, "b", saved(strings.Repeat("x", 60))))
+
+		assert.Equal(t, 1, store.order.Len())
+		assert.LessOrEqual(t, store.bytes, 100)
+		_, ok := store.entries["a"]
+		assert.False(t, ok, "a was evicted to make room for b")
+
+		require.NoError(t, store.Save(ctx, "c", saved(strings.Repeat("x", 200))))
+		assert.Zero(t, store.order.Len(), "a response over the cap is not kept")
+		assert.Zero(t, store.bytes)
+	})
+
+	t.Run("rotating keys", func(t *testing.T) {
+		store := NewMemoryIdempotencyStore(time.Hour, WithMaxEntries(100), WithMaxBytes(10_000)).(*memoryIdempotencyStore)
+		for i := 0; i < 1000; i++ {
+			key := strconv.Itoa(i)
+			_, err := store.Lock(ctx, key)
+			require.NoError(t, err)
+			require.NoError(t, store.Save(ctx, key, saved(strings.Repeat("x", 500))))
+		}
+
+		assert.LessOrEqual(t, store.order.Len(), 100)
+		assert.LessOrEqual(t, store.bytes, 10_000)
+		assert.Equal(t, store.order.Len(), len(store.entries))
+	})
+
+	t.Run("expired entries are swept within a minute", func(t *testing.T) {
+		now := time.Now()
+		store := NewMemoryIdempotencyStore(time.Hour).(*memoryIdempotencyStore)
+		store.now = func() time.Time { return now }
+
+		require.NoError(t, store.Save(ctx, "a", saved("a")))
+		_, err := store.Lock(ctx, "b")
+		require.NoError(t, err)
+
+		now = now.Add(time.Hour + time.Minute)
+		_, err = store.Lock(ctx, "c")
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, store.order.Len(), "a and b expired and were swept")
+	})
+}
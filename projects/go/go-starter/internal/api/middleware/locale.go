@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/yourusername/go-starter/internal/i18n"
+)
+
+// Locale picks the response language from Accept-Language and stores it in
+// the request context, where error rendering looks it up. Unsupported or
+// malformed headers fall back to English.
+func Locale(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.Match(r.Header.Get("Accept-Language"))
+
+		// Caches must not hand a German error to an English client
+		w.Header().Add("Vary", "Accept-Language")
+
+		next.ServeHTTP(w, r.WithContext(i18n.WithLanguage(r.Context(), lang)))
+	})
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yourusername/go-starter/internal/api/jsonapi"
+)
+
+func TestLocale(t *testing.T) {
+	handler := Locale(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jsonapi.WriteError(r.Context(), w, "req-1", http.StatusNotFound, "NOT_FOUND", "User not found")
+	}))
+
+	tests := []struct {
+		name         string
+		header       string
+		wantLanguage string
+		wantBody     string
+	}{
+		{name: "german", header: "de-DE,de;q=0.9,en;q=0.8", wantLanguage: "de", wantBody: `"title":"Nicht gefunden"`},
+		{name: "unsupported falls back to english", header: "fr-FR", wantLanguage: "en", wantBody: `"detail":"User not found"`},
+		{name: "malformed header", header: ";q=;;,\x00", wantLanguage: "en", wantBody: `"title":"Not Found"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Language", tt.header)
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantLanguage, rec.Header().Get("Content-Language"))
+			assert.Equal(t, "Accept-Language", rec.Header().Get("Vary"))
+			assert.Contains(t, rec.Body.String(), tt.wantBody)
+		})
+	}
+}
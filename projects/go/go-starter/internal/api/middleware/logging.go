@@ -1,14 +1,24 @@
 package middleware
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"log/slog"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 )
 
+// StatusClientClosedRequest is the nginx-style status logged for requests
+// whose client disconnected before a response was written. It keeps
+// cancellations out of the 5xx error rate.
+const StatusClientClosedRequest = 499
+
 type responseWriter struct {
 	http.ResponseWriter
 	status      int
+	bytes       int
 	wroteHeader bool
 }
 
@@ -20,6 +30,11 @@ func (rw *responseWriter) Status() int {
 	return rw.status
 }
 
+// BytesWritten returns the number of body bytes written so far
+func (rw *responseWriter) BytesWritten() int {
+	return rw.bytes
+}
+
 func (rw *responseWriter) WriteHeader(code int) {
 	if rw.wroteHeader {
 		return
@@ -30,25 +45,159 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.wroteHeader = true
 }
 
-// Logging middleware logs HTTP requests
-func Logging(logger *slog.Logger) func(http.Handler) http.Handler {
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	// An implicit WriteHeader happens on the first Write, mirror it so the
+	// logged status is 200 rather than 0
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += n
+	return n, err
+}
+
+// Flush implements http.Flusher so streaming handlers keep working behind
+// the wrapper
+func (rw *responseWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		if !rw.wroteHeader {
+			rw.WriteHeader(http.StatusOK)
+		}
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// LoggingOption configures Logging
+type LoggingOption func(*loggingOptions)
+
+type loggingOptions struct {
+	handlerNames *HandlerNames
+	startLog     bool
+	headers      bool
+	redact       map[string]bool
+}
+
+// WithHandlerNames adds the name of the Go function that served each
+// request to its log line as "handler", falling back to the route pattern
+// for routes names has no entry for
+func WithHandlerNames(names *HandlerNames) LoggingOption {
+	return func(o *loggingOptions) {
+		o.handlerNames = names
+	}
+}
+
+// WithStartLog also logs "request.start" as soon as a request comes in.
+// Matched with "request.end" by span_id, a start without an end marks a
+// request that hung or took the process down with it.
+func WithStartLog() LoggingOption {
+	return func(o *loggingOptions) {
+		o.startLog = true
+	}
+}
+
+// redacted replaces the values of sensitive headers in the log
+const redacted = "***"
+
+// WithHeaders adds the request headers to the log line as the "headers"
+// group. The values of the redact headers (matched case-insensitively) are
+// replaced by "***" so credentials never reach the logs.
+func WithHeaders(redact []string) LoggingOption {
+	return func(o *loggingOptions) {
+		o.headers = true
+		o.redact = make(map[string]bool, len(redact))
+		for _, name := range redact {
+			o.redact[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+}
+
+// headersAttr renders h as a group with one attribute per header, sorted by
+// name, repeated headers joined by ", "
+func headersAttr(h http.Header, redact map[string]bool) slog.Attr {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	attrs := make([]any, len(names))
+	for i, name := range names {
+		value := redacted
+		if !redact[name] {
+			value = strings.Join(h[name], ", ")
+		}
+		attrs[i] = slog.String(name, value)
+	}
+	return slog.Group("headers", attrs...)
+}
+
+// newSpanID returns a random 16 hex digit id pairing a request's log lines
+func newSpanID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// Logging middleware logs HTTP requests as "request.end" once they have
+// been served. request_id comes from the context, see ContextHandler.
+func Logging(logger *slog.Logger, opts ...LoggingOption) func(http.Handler) http.Handler {
+	var options loggingOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
+			spanID := newSpanID()
 			wrapped := wrapResponseWriter(w)
-			reqID := GetRequestID(r.Context())
+
+			if options.startLog {
+				logger.InfoContext(r.Context(), "request.start",
+					slog.String("span_id", spanID),
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.String("remote_addr", r.RemoteAddr),
+				)
+			}
 
 			next.ServeHTTP(wrapped, r)
 
-			logger.InfoContext(r.Context(), "request completed",
-				slog.String("request_id", reqID),
+			duration := time.Since(start)
+
+			status := wrapped.status
+			if !wrapped.wroteHeader && r.Context().Err() != nil {
+				// Handlers write nothing once the client is gone
+				status = StatusClientClosedRequest
+			}
+
+			attrs := []any{
+				slog.String("span_id", spanID),
 				slog.String("method", r.Method),
 				slog.String("path", r.URL.Path),
-				slog.Int("status", wrapped.status),
-				slog.Duration("duration", time.Since(start)),
+				// Routing is done once next returns
+				slog.String("route", RoutePattern(r)),
+			}
+			if options.handlerNames != nil {
+				attrs = append(attrs, slog.String("handler", options.handlerNames.handlerName(r)))
+			}
+			attrs = append(attrs,
+				slog.Int("status", status),
+				slog.Int("bytes", wrapped.bytes),
+				slog.Float64("duration_ms", float64(duration.Microseconds())/1000),
 				slog.String("remote_addr", r.RemoteAddr),
 				slog.String("user_agent", r.UserAgent()),
 			)
+			if options.headers {
+				attrs = append(attrs, headersAttr(r.Header, options.redact))
+			}
+			logger.InfoContext(r.Context(), "request.end", attrs...)
 		})
 	}
 }
@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogging_ClientClosedRequest(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	handler := Logging(logger)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil).WithContext(ctx))
+	assert.Contains(t, buf.String(), "status=499")
+}
+
+func TestLogging_HandlerName(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	names := NewHandlerNames()
+	names.Set(http.MethodGet, "/users/{id}", "handlers.(*UserHandler).GetUser")
+
+	r := chi.NewRouter()
+	r.Use(Logging(logger, WithHandlerNames(names)))
+	noop := func(http.ResponseWriter, *http.Request) {}
+	r.Get("/users/{id}", noop)
+	r.Get("/users", noop)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	assert.Contains(t, buf.String(), "handler=handlers.(*UserHandler).GetUser")
+
+	// Unknown routes are logged under their pattern
+	buf.Reset()
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+	assert.Contains(t, buf.String(), "handler=/users ")
+}
+
+func TestLogging_StartAndEnd(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewContextHandler(slog.NewJSONHandler(&buf, nil)))
+	handler := RequestID(Logging(logger, WithStartLog())(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var lines []map[string]any
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var line map[string]any
+		require.NoError(t, dec.Decode(&line))
+		lines = append(lines, line)
+	}
+	require.Len(t, lines, 2)
+	start, end := lines[0], lines[1]
+
+	assert.Equal(t, "request.start", start["msg"])
+	assert.Equal(t, "GET", start["method"])
+	assert.Equal(t, "/users", start["path"])
+	assert.Equal(t, "192.0.2.1:1234", start["remote_addr"])
+
+	assert.Equal(t, "request.end", end["msg"])
+	assert.EqualValues(t, http.StatusTeapot, end["status"])
+	assert.Contains(t, end, "duration_ms")
+
+	assert.NotEmpty(t, start["span_id"])
+	assert.Equal(t, start["span_id"], end["span_id"])
+	assert.Equal(t, "req-1", start["request_id"])
+	assert.Equal(t, "req-1", end["request_id"])
+}
+
+func TestLogging_StartLogOff(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	handler := Logging(logger)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+	assert.NotContains(t, buf.String(), "request.start")
+	assert.Contains(t, buf.String(), "msg=request.end")
+}
+
+func TestLogging_RedactHeaders(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	handler := Logging(logger, WithHeaders([]string{"authorization", "X-Api-Key"}))(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("Authorization", "Bearer secret-token")
+	req.Header.Set("X-Api-Key", "secret-key")
+	req.Header.Set("Accept", "application/vnd.api+json")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.NotContains(t, buf.String(), "secret")
+
+	var line struct {
+		Headers map[string]string `json:"headers"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "***", line.Headers["Authorization"])
+	assert.Equal(t, "***", line.Headers["X-Api-Key"])
+	assert.Equal(t, "application/vnd.api+json", line.Headers["Accept"])
+}
+
+func TestLogging_BytesAndDuration(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	r := chi.NewRouter()
+	r.Use(Logging(logger))
+	r.Get("/users/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+		w.Write([]byte("hello"))
+		w.Write([]byte(", world"))
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.EqualValues(t, http.StatusOK, line["status"], "implicit WriteHeader is logged as 200")
+	assert.EqualValues(t, len("hello, world"), line["bytes"])
+	assert.GreaterOrEqual(t, line["duration_ms"], 5.0)
+	assert.Equal(t, "/users/{id}", line["route"])
+}
+
+func TestLogging_Flush(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	var isFlusher bool
+	handler := Logging(logger)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		var flusher http.Flusher
+		flusher, isFlusher = w.(http.Flusher)
+		if !isFlusher {
+			return
+		}
+		flusher.Flush()
+		w.Write([]byte("event"))
+		flusher.Flush()
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	require.True(t, isFlusher, "the wrapper must not hide http.Flusher")
+	assert.True(t, rec.Flushed)
+	assert.Equal(t, "event", rec.Body.String())
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.EqualValues(t, http.StatusOK, line["status"], "a Flush before any Write commits a 200")
+	assert.EqualValues(t, len("event"), line["bytes"])
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/yourusername/go-starter/internal/api/jsonapi"
+)
+
+// maxInFlightRetryAfter is how long rejected clients are asked to wait, in
+// seconds
+const maxInFlightRetryAfter = "1"
+
+// MaxInFlight caps the number of requests being served at once. Requests
+// beyond n are rejected immediately with 503 and Retry-After instead of
+// queuing, so a traffic spike can't pile up unbounded work in front of the
+// database. n <= 0 disables the limit.
+func MaxInFlight(n int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if n <= 0 {
+			return next
+		}
+
+		sem := make(chan struct{}, n)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case sem <- struct{}{}:
+			default:
+				ctx := r.Context()
+				w.Header().Set("Retry-After", maxInFlightRetryAfter)
+				jsonapi.WriteError(ctx, w, GetRequestID(ctx), http.StatusServiceUnavailable, "TOO_MANY_IN_FLIGHT", "The server is at capacity, please retry shortly")
+				return
+			}
+			defer func() { <-sem }()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
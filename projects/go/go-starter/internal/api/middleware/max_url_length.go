@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/yourusername/go-starter/internal/api/jsonapi"
+)
+
+// MaxURLLength rejects requests whose request URI (path and query) is longer
+// than n bytes with 414, before anything parses the query. It belongs ahead
+// of Logging so oversized URLs never reach the request log. n <= 0 disables
+// the check.
+func MaxURLLength(n int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if n <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(r.RequestURI) > n {
+				ctx := r.Context()
+				jsonapi.WriteError(ctx, w, GetRequestID(ctx), http.StatusRequestURITooLong, "URI_TOO_LONG", fmt.Sprintf("The request URI must not exceed %d bytes", n))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
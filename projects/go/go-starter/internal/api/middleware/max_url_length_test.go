@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxURLLength(t *testing.T) {
+	const limit = 32
+	h := MaxURLLength(limit)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// "/users?q=" is 9 bytes
+	atLimit := "/users?q=" + strings.Repeat("a", limit-9)
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+	}{
+		{name: "at the limit", target: atLimit, wantStatus: http.StatusOK},
+		{name: "one byte over", target: atLimit + "a", wantStatus: http.StatusRequestURITooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus != http.StatusOK {
+				assert.Contains(t, rec.Body.String(), `"code":"URI_TOO_LONG"`)
+			}
+		})
+	}
+}
+
+func TestMaxURLLength_Disabled(t *testing.T) {
+	h := MaxURLLength(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users?q="+strings.Repeat("a", 10000), nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// RequestOrigin returns the scheme and host the client used to reach the
+// service, for building absolute URLs.
+//
+// As with ClientIP, forwarding headers are only honored when the immediate
+// peer is inside trustedProxies. A trusted peer's Forwarded proto= and host=
+// parameters win over X-Forwarded-Proto and X-Forwarded-Host. When a header
+// lists several proxies the left-most value is used, since that is what the
+// outermost proxy, the one the client talked to, saw.
+func RequestOrigin(r *http.Request, trustedProxies []netip.Prefix) (scheme, host string) {
+	scheme, host = "http", r.Host
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	peer, ok := parseAddr(peerAddr(r))
+	if !ok || !isTrusted(peer, trustedProxies) {
+		return scheme, host
+	}
+
+	fwdProto, fwdHost := forwardedOrigin(r.Header.Values("Forwarded"))
+	if fwdProto == "" {
+		fwdProto = firstListValue(r.Header.Values("X-Forwarded-Proto"))
+	}
+	if fwdHost == "" {
+		fwdHost = firstListValue(r.Header.Values("X-Forwarded-Host"))
+	}
+
+	if proto := strings.ToLower(fwdProto); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	if validHost(fwdHost) {
+		host = fwdHost
+	}
+
+	return scheme, host
+}
+
+// forwardedOrigin extracts proto= and host= from the first Forwarded element
+func forwardedOrigin(values []string) (proto, host string) {
+	elements := splitList(values)
+	if len(elements) == 0 {
+		return "", ""
+	}
+
+	for _, pair := range strings.Split(elements[0], ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(value, `"`)
+		switch strings.ToLower(key) {
+		case "proto":
+			proto = value
+		case "host":
+			host = value
+		}
+	}
+	return proto, host
+}
+
+func firstListValue(values []string) string {
+	items := splitList(values)
+	if len(items) == 0 {
+		return ""
+	}
+	return items[0]
+}
+
+// validHost rejects values that would change the meaning of a URL built
+// from them
+func validHost(host string) bool {
+	return host != "" && !strings.ContainsAny(host, "/\\?#@ \t")
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestOrigin(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		tls        bool
+		headers    map[string]string
+		wantScheme string
+		wantHost   string
+	}{
+		{
+			name:       "direct request",
+			remoteAddr: "203.0.113.7:1234",
+			wantScheme: "http",
+			wantHost:   "api.internal",
+		},
+		{
+			name:       "direct TLS request",
+			remoteAddr: "203.0.113.7:1234",
+			tls:        true,
+			wantScheme: "https",
+			wantHost:   "api.internal",
+		},
+		{
+			name:       "untrusted peer headers are ignored",
+			remoteAddr: "203.0.113.7:1234",
+			headers:    map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-Host": "evil.example"},
+			wantScheme: "http",
+			wantHost:   "api.internal",
+		},
+		{
+			name:       "trusted proxy X-Forwarded headers",
+			remoteAddr: "10.0.0.2:1234",
+			headers:    map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-Host": "api.example.com, lb.internal"},
+			wantScheme: "https",
+			wantHost:   "api.example.com",
+		},
+		{
+			name:       "Forwarded wins over X-Forwarded",
+			remoteAddr: "10.0.0.2:1234",
+			headers: map[string]string{
+				"Forwarded":         `for=198.51.100.1;proto=https;host="api.example.com"`,
+				"X-Forwarded-Host":  "other.example.com",
+				"X-Forwarded-Proto": "http",
+			},
+			wantScheme: "https",
+			wantHost:   "api.example.com",
+		},
+		{
+			name:       "garbage values fall back",
+			remoteAddr: "10.0.0.2:1234",
+			headers:    map[string]string{"X-Forwarded-Proto": "javascript", "X-Forwarded-Host": "evil.example/path"},
+			wantScheme: "http",
+			wantHost:   "api.internal",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/v1/users", nil)
+			r.Host = "api.internal"
+			r.RemoteAddr = tt.remoteAddr
+			if tt.tls {
+				r.TLS = &tls.ConnectionState{}
+			}
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			scheme, host := RequestOrigin(r, trusted)
+			assert.Equal(t, tt.wantScheme, scheme)
+			assert.Equal(t, tt.wantHost, host)
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/yourusername/go-starter/internal/api/jsonapi"
+)
+
+// RateLimit throttles each client IP with a token bucket holding requests
+// tokens that refills evenly over window, so a client may burst up to
+// requests and then sustain requests per window. Every response carries
+// the client's budget:
+//
+//   - X-RateLimit-Limit: the bucket size
+//   - X-RateLimit-Remaining: tokens left after this request
+//   - X-RateLimit-Reset: Unix time at which the bucket is full again
+//
+// A request without a token gets 429 with Retry-After. The client IP is
+// RemoteAddr, so RealIP must run first behind a proxy. requests <= 0
+// disables the limit and the headers.
+func RateLimit(requests int, window time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if requests <= 0 || window <= 0 {
+			return next
+		}
+		return newRateLimiter(requests, window, time.Now).middleware(next)
+	}
+}
+
+// bucket is one client's tokens as of updated
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// rateLimiter holds the buckets of all clients
+type rateLimiter struct {
+	limit int
+	// rate is tokens added per second
+	rate   float64
+	window time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+func newRateLimiter(requests int, window time.Duration, now func() time.Time) *rateLimiter {
+	return &rateLimiter{
+		limit:   requests,
+		rate:    float64(requests) / window.Seconds(),
+		window:  window,
+		now:     now,
+		buckets: make(map[string]*bucket),
+		swept:   now(),
+	}
+}
+
+// take refills key's bucket, takes a token if there is one and returns
+// the state the headers report. retryAfter is only set when denied.
+func (l *rateLimiter) take(key string) (allowed bool, remaining int, reset time.Time, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.limit), updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(l.limit), b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+	b.updated = now
+
+	allowed = b.tokens >= 1
+	if allowed {
+		b.tokens--
+	} else {
+		retryAfter = l.until(1 - b.tokens)
+	}
+
+	return allowed, int(b.tokens), now.Add(l.until(float64(l.limit) - b.tokens)), retryAfter
+}
+
+// until returns how long the bucket takes to gain tokens
+func (l *rateLimiter) until(tokens float64) time.Duration {
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
+
+// sweep drops buckets that have refilled completely, which behave exactly
+// like a missing one, so clients that went away don't pile up. It runs at
+// most once per window.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < l.window {
+		return
+	}
+	l.swept = now
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*l.rate >= float64(l.limit) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func (l *rateLimiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed, remaining, reset, retryAfter := l.take(remoteIP(r))
+
+		h := w.Header()
+		h.Set("X-RateLimit-Limit", strconv.Itoa(l.limit))
+		h.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		// Rounded up so a client waiting until then finds a full bucket
+		h.Set("X-RateLimit-Reset", strconv.FormatInt(ceilUnix(reset), 10))
+
+		if !allowed {
+			ctx := r.Context()
+			h.Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			jsonapi.WriteError(ctx, w, GetRequestID(ctx), http.StatusTooManyRequests, "RATE_LIMIT_EXCEEDED", "Too many requests, please retry after the time in Retry-After")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ceilUnix returns t in Unix seconds, rounded up
+func ceilUnix(t time.Time) int64 {
+	if t.Nanosecond() > 0 {
+		return t.Unix() + 1
+	}
+	return t.Unix()
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a settable time source
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func rateLimited(clock *fakeClock, requests int, window time.Duration) http.Handler {
+	return newRateLimiter(requests, window, clock.now).middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func serveFrom(h http.Handler, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRateLimit_HeadersDecrement(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	clock := &fakeClock{t: start}
+	// One token per 20s
+	h := rateLimited(clock, 3, time.Minute)
+
+	for i, want := range []string{"2", "1", "0"} {
+		rec := serveFrom(h, "192.0.2.1:1234")
+		require.Equal(t, http.StatusOK, rec.Code, "request %d", i)
+		assert.Equal(t, "3", rec.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, want, rec.Header().Get("X-RateLimit-Remaining"), "request %d", i)
+		// Each token taken is 20s more until the bucket is full
+		wantReset := start.Add(time.Duration(i+1) * 20 * time.Second).Unix()
+		assert.Equal(t, strconv.FormatInt(wantReset, 10), rec.Header().Get("X-RateLimit-Reset"), "request %d", i)
+	}
+
+	clock.t = start.Add(5 * time.Second)
+	rec := serveFrom(h, "192.0.2.1:5678")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "15", rec.Header().Get("Retry-After"))
+	assert.Contains(t, rec.Body.String(), `"code":"RATE_LIMIT_EXCEEDED"`)
+
+	// Another client has its own bucket
+	rec = serveFrom(h, "192.0.2.2:1234")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("X-RateLimit-Remaining"))
+
+	// A token comes back after 20s
+	clock.t = start.Add(20 * time.Second)
+	rec = serveFrom(h, "192.0.2.1:1234")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("X-RateLimit-Remaining"))
+}
+
+func TestRateLimit_SweepsFullBuckets(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1_700_000_000, 0)}
+	l := newRateLimiter(2, time.Minute, clock.now)
+
+	l.take("192.0.2.1")
+	clock.t = clock.t.Add(time.Minute)
+	l.take("192.0.2.2")
+
+	assert.Len(t, l.buckets, 1)
+	assert.Contains(t, l.buckets, "192.0.2.2")
+}
+
+func TestRateLimit_Disabled(t *testing.T) {
+	h := RateLimit(0, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := serveFrom(h, "192.0.2.1:1234")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("X-RateLimit-Limit"))
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+)
+
+const peerAddrKey contextKey = "peer_addr"
+
+// RealIP rewrites r.RemoteAddr to the bare client IP resolved by ClientIP, so
+// handlers and logs further down see "203.0.113.7" or "2001:db8::1" rather
+// than a proxy's "ip:port" or "[ipv6]:port". An unparseable RemoteAddr is
+// left as is.
+//
+// The connection's real peer is kept on the context: ClientIP and
+// RequestOrigin still decide trust on the peer, not on the rewritten
+// address, so running them after RealIP gives the same answer as before.
+func RealIP(trustedProxies []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := parseAddr(peerAddr(r)); !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), peerAddrKey, peerAddr(r))
+			r = r.WithContext(ctx)
+			r.RemoteAddr = ClientIP(r, trustedProxies)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// peerAddr returns the address of the immediate peer, as it was before
+// RealIP rewrote RemoteAddr
+func peerAddr(r *http.Request) string {
+	if peer, ok := r.Context().Value(peerAddrKey).(string); ok {
+		return peer
+	}
+	return r.RemoteAddr
+}
+
+// remoteIP returns the host part of r.RemoteAddr, which is the client IP
+// once RealIP has run
+func remoteIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
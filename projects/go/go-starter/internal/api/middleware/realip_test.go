@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRealIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		wantAddr   string
+		wantScheme string
+	}{
+		{
+			name:       "strips the port",
+			remoteAddr: "203.0.113.7:5000",
+			wantAddr:   "203.0.113.7",
+			wantScheme: "http",
+		},
+		{
+			name:       "strips IPv6 brackets and port",
+			remoteAddr: "[2001:db8::1]:5000",
+			wantAddr:   "2001:db8::1",
+			wantScheme: "http",
+		},
+		{
+			name:       "untrusted peer cannot spoof",
+			remoteAddr: "203.0.113.7:5000",
+			headers:    map[string]string{"X-Forwarded-For": "1.2.3.4", "X-Forwarded-Proto": "https"},
+			wantAddr:   "203.0.113.7",
+			wantScheme: "http",
+		},
+		{
+			name:       "trusted proxy is replaced by the client",
+			remoteAddr: "10.0.0.1:5000",
+			headers:    map[string]string{"X-Forwarded-For": "198.51.100.9", "X-Forwarded-Proto": "https"},
+			wantAddr:   "198.51.100.9",
+			// The proxy's headers still count even though RemoteAddr no
+			// longer names it
+			wantScheme: "https",
+		},
+		{
+			name:       "garbled address is left alone",
+			remoteAddr: "pipe",
+			wantAddr:   "pipe",
+			wantScheme: "http",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotAddr, gotClient, gotScheme string
+			handler := RealIP(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotAddr = r.RemoteAddr
+				gotClient = ClientIP(r, trusted)
+				gotScheme, _ = RequestOrigin(r, trusted)
+			}))
+
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			assert.Equal(t, tt.wantAddr, gotAddr)
+			assert.Equal(t, tt.wantAddr, gotClient, "ClientIP must agree after RealIP")
+			assert.Equal(t, tt.wantScheme, gotScheme)
+		})
+	}
+}
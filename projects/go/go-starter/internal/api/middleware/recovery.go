@@ -1,30 +1,106 @@
 package middleware
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"runtime/debug"
+
+	"github.com/yourusername/go-starter/internal/api/jsonapi"
 )
 
+// maxPanicStackBytes caps the stack WithPanicDetails puts in a response;
+// the log always gets all of it
+const maxPanicStackBytes = 4096
+
+// PanicHook is called after a panic has been recovered, e.g. to report it to
+// an error tracker. stack is the goroutine stack at the point of recovery.
+type PanicHook func(ctx context.Context, recovered any, stack []byte)
+
+// RecoveryOption configures Recovery
+type RecoveryOption func(*recoveryOptions)
+
+type recoveryOptions struct {
+	hooks   []PanicHook
+	details bool
+}
+
+// WithPanicHook adds a hook that is called for every recovered panic
+func WithPanicHook(hook PanicHook) RecoveryOption {
+	return func(o *recoveryOptions) {
+		o.hooks = append(o.hooks, hook)
+	}
+}
+
+// WithPanicDetails puts the panic value and the start of the stack into the
+// 500's meta.panic and meta.stack, to save a trip to the logs while
+// developing. It only takes effect when env is "development"; NewRouter
+// also requires PANIC_DETAILS, so a default SERVER_ENV alone never exposes
+// internals.
+func WithPanicDetails(env string) RecoveryOption {
+	return func(o *recoveryOptions) {
+		o.details = env == "development"
+	}
+}
+
 // Recovery middleware recovers from panics
-func Recovery(logger *slog.Logger) func(http.Handler) http.Handler {
+func Recovery(logger *slog.Logger, opts ...RecoveryOption) func(http.Handler) http.Handler {
+	var options recoveryOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			defer func() {
-				if err := recover(); err != nil {
-					reqID := GetRequestID(r.Context())
-					logger.ErrorContext(r.Context(), "panic recovered",
-						slog.String("request_id", reqID),
-						slog.Any("error", err),
-						slog.String("stack", string(debug.Stack())),
-					)
-
-					w.WriteHeader(http.StatusInternalServerError)
-					w.Write([]byte(`{"errors":[{"status":"500","code":"INTERNAL_ERROR","title":"Internal Server Error"}]}`))
+				err := recover()
+				if err == nil {
+					return
+				}
+
+				// http.ErrAbortHandler is net/http's way of aborting a response
+				// on purpose; let the server handle it as intended
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+
+				ctx := r.Context()
+				reqID := GetRequestID(ctx)
+				stack := debug.Stack()
+
+				// The full stack trace always goes to the logs
+				logger.ErrorContext(ctx, "panic recovered",
+					slog.Any("error", err),
+					slog.String("stack", string(stack)),
+				)
+
+				for _, hook := range options.hooks {
+					hook(ctx, err, stack)
 				}
+
+				e := jsonapi.NewError(reqID, http.StatusInternalServerError, "INTERNAL_ERROR", "An unexpected error occurred")
+				if options.details {
+					e.Meta["panic"] = fmt.Sprint(err)
+					e.Meta["stack"] = truncateStack(stack)
+				}
+				jsonapi.WriteErrors(ctx, w, reqID, http.StatusInternalServerError, []jsonapi.Error{e})
 			}()
 
 			next.ServeHTTP(w, r)
 		})
 	}
 }
+
+// truncateStack cuts stack to maxPanicStackBytes at a line boundary
+func truncateStack(stack []byte) string {
+	if len(stack) <= maxPanicStackBytes {
+		return string(stack)
+	}
+	cut := stack[:maxPanicStackBytes]
+	if i := bytes.LastIndexByte(cut, '\n'); i > 0 {
+		cut = cut[:i+1]
+	}
+	return string(cut) + "...(truncated)\n"
+}
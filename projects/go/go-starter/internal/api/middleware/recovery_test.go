@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/go-starter/internal/api/jsonapi"
+)
+
+func TestRecovery_PanicDetails(t *testing.T) {
+	tests := []struct {
+		env         string
+		wantDetails bool
+	}{
+		{env: "development", wantDetails: true},
+		{env: "production"},
+		{env: "staging"},
+		{env: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run("env "+tt.env, func(t *testing.T) {
+			var logs strings.Builder
+			logger := slog.New(slog.NewTextHandler(&logs, nil))
+			h := Recovery(logger, WithPanicDetails(tt.env))(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				panic("nil map write in handler")
+			}))
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+			var body jsonapi.ErrorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			require.Len(t, body.Errors, 1)
+			meta := body.Errors[0].Meta
+
+			if tt.wantDetails {
+				assert.Equal(t, "nil map write in handler", meta["panic"])
+				assert.Contains(t, meta["stack"], "runtime/debug.Stack")
+			} else {
+				assert.NotContains(t, meta, "panic")
+				assert.NotContains(t, meta, "stack")
+				assert.NotContains(t, rec.Body.String(), "nil map write")
+			}
+
+			// The log has the whole story in every environment
+			assert.Contains(t, logs.String(), "nil map write in handler")
+			assert.Contains(t, logs.String(), "goroutine")
+		})
+	}
+}
+
+func TestRecovery_Hook(t *testing.T) {
+	var got any
+	h := Recovery(slog.New(slog.NewTextHandler(io.Discard, nil)),
+		WithPanicHook(func(_ context.Context, recovered any, _ []byte) { got = recovered }),
+	)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "boom", got)
+}
+
+func TestTruncateStack(t *testing.T) {
+	short := "goroutine 1 [running]:\nmain.main()\n"
+	assert.Equal(t, short, truncateStack([]byte(short)))
+
+	long := strings.Repeat("frame line that is forty bytes long...\n", 200)
+	got := truncateStack([]byte(long))
+	assert.LessOrEqual(t, len(got), maxPanicStackBytes+len("...(truncated)\n"))
+	assert.True(t, strings.HasSuffix(got, "long...\n...(truncated)\n"), "cut at a line boundary")
+}
@@ -5,6 +5,8 @@ import (
 	"net/http"
 
 	"github.com/google/uuid"
+
+	"github.com/yourusername/go-starter/internal/audit"
 )
 
 type contextKey string
@@ -24,6 +26,8 @@ func RequestID(next http.Handler) http.Handler {
 
 		// Add request ID to context
 		ctx := context.WithValue(r.Context(), requestIDKey, reqID)
+		// Audit entries written while serving the request carry it too
+		ctx = audit.WithRequestID(ctx, reqID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RoutePattern returns the chi route that matched r, such as
+// "/api/v1/users/{id}", for use as a low-cardinality label in logs and
+// metrics. It is "" until routing has happened, which constrains where it
+// can be called:
+//
+//   - in middleware registered with Use on the root router, which runs
+//     before routing, only after next.ServeHTTP returns or once the handler
+//     writes the response (see Logging and DeprecationRegistry)
+//   - in route-level middleware (With, or Use inside Route) and handlers, at
+//     any time
+//
+// Requests that match no route report "".
+func RoutePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return ""
+	}
+	return rctx.RoutePattern()
+}
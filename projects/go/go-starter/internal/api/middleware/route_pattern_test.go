@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoutePattern(t *testing.T) {
+	var before, after, inRoute, inHandler string
+
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			before = RoutePattern(req)
+			next.ServeHTTP(w, req)
+			after = RoutePattern(req)
+		})
+	})
+	r.Route("/api/v1/users", func(r chi.Router) {
+		r.With(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				inRoute = RoutePattern(req)
+				next.ServeHTTP(w, req)
+			})
+		}).Get("/{id}", func(w http.ResponseWriter, req *http.Request) {
+			inHandler = RoutePattern(req)
+		})
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/users/42", nil))
+
+	assert.Empty(t, before, "root middleware runs before routing")
+	assert.Equal(t, "/api/v1/users/{id}", after)
+	assert.Equal(t, "/api/v1/users/{id}", inRoute)
+	assert.Equal(t, "/api/v1/users/{id}", inHandler)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nowhere", nil))
+	assert.Empty(t, after, "no route matched")
+
+	assert.Empty(t, RoutePattern(httptest.NewRequest(http.MethodGet, "/", nil)), "outside chi")
+}
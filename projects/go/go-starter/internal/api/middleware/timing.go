@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+)
+
+// timingWriter marks a response as opted in to debug timing and remembers
+// when the request started
+type timingWriter struct {
+	http.ResponseWriter
+	start time.Time
+}
+
+// Flush implements http.Flusher so streaming handlers keep working behind
+// the wrapper
+func (tw *timingWriter) Flush() {
+	if flusher, ok := tw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (tw *timingWriter) Unwrap() http.ResponseWriter {
+	return tw.ResponseWriter
+}
+
+// Timing middleware enables meta.timing on JSON:API responses for requests
+// sent with ?debug=timing. Other requests pass through untouched.
+func Timing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("debug") != "timing" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(&timingWriter{ResponseWriter: w, start: time.Now()}, r)
+	})
+}
+
+// TimingStart returns when the request started if timing was requested.
+// It looks through any wrappers that implement Unwrap, so other middleware
+// may sit between Timing and the handler.
+func TimingStart(w http.ResponseWriter) (time.Time, bool) {
+	for {
+		switch rw := w.(type) {
+		case *timingWriter:
+			return rw.start, true
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = rw.Unwrap()
+		default:
+			return time.Time{}, false
+		}
+	}
+}
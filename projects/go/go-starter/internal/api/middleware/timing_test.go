@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTiming(t *testing.T) {
+	t.Run("debug=timing", func(t *testing.T) {
+		var started, flusher bool
+		handler := Timing(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, started = TimingStart(w)
+			_, flusher = w.(http.Flusher)
+			w.Write([]byte("chunk"))
+			require.NoError(t, http.NewResponseController(w).Flush())
+		}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users?debug=timing", nil))
+
+		assert.True(t, started)
+		assert.True(t, flusher, "the wrapper must not hide http.Flusher")
+		assert.True(t, rec.Flushed)
+		assert.Equal(t, "chunk", rec.Body.String())
+	})
+
+	t.Run("other requests get the original writer", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		var got http.ResponseWriter
+		handler := Timing(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			got = w
+		}))
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users?debug=other", nil))
+
+		assert.Same(t, rec, got)
+		_, started := TimingStart(got)
+		assert.False(t, started)
+	})
+}
+
+func TestTimingStart_ThroughWrappers(t *testing.T) {
+	var started bool
+	handler := Timing(Logging(slog.New(slog.NewTextHandler(io.Discard, nil)))(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, started = TimingStart(w)
+	})))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users?debug=timing", nil))
+
+	assert.True(t, started)
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SlashMode selects how TrailingSlash treats a path ending in "/"
+type SlashMode string
+
+const (
+	// SlashRedirect answers GET and HEAD with a 308 to the path without the
+	// slash, so clients and caches learn the canonical URL, and strips the
+	// slash for every other method
+	SlashRedirect SlashMode = "redirect"
+	// SlashStrip routes every method as if the slash were not there
+	SlashStrip SlashMode = "strip"
+)
+
+// SlashOption configures TrailingSlash
+type SlashOption func(*slashOptions)
+
+type slashOptions struct {
+	exempt []string
+}
+
+// SlashExempt leaves paths under prefix as they are, for handlers whose
+// canonical URL ends in "/", such as pprof.Index at /debug/pprof/
+func SlashExempt(prefix string) SlashOption {
+	return func(o *slashOptions) {
+		o.exempt = append(o.exempt, prefix)
+	}
+}
+
+// TrailingSlash makes "/api/v1/users/" and "/api/v1/users" the same route.
+// It must be registered on the root router: it rewrites the path chi
+// routes on, which only works before routing has happened.
+func TrailingSlash(mode SlashMode, opts ...SlashOption) func(http.Handler) http.Handler {
+	var o slashOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rctx := chi.RouteContext(r.Context())
+			path := r.URL.Path
+			if rctx != nil && rctx.RoutePath != "" {
+				path = rctx.RoutePath
+			}
+			if len(path) <= 1 || !strings.HasSuffix(path, "/") || o.isExempt(path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Collapsing leading slashes keeps "//evil.example/" from
+			// turning into a protocol-relative redirect
+			canonical := "/" + strings.Trim(path, "/")
+
+			if mode == SlashRedirect && (r.Method == http.MethodGet || r.Method == http.MethodHead) {
+				target := canonical
+				if r.URL.RawQuery != "" {
+					target += "?" + r.URL.RawQuery
+				}
+				http.Redirect(w, r, target, http.StatusPermanentRedirect)
+				return
+			}
+
+			if rctx != nil {
+				rctx.RoutePath = canonical
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (o *slashOptions) isExempt(path string) bool {
+	for _, prefix := range o.exempt {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
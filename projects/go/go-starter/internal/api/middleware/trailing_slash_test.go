@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSlashRouter(mode SlashMode) *chi.Mux {
+	r := chi.NewRouter()
+	r.Use(TrailingSlash(mode, SlashExempt("/debug/pprof/")))
+	r.Get("/debug/pprof/*", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("index " + chi.URLParam(r, "*")))
+	})
+	r.Route("/api/v1/users", func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("list"))
+		})
+		r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("get " + chi.URLParam(r, "id")))
+		})
+		r.Patch("/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("patch " + chi.URLParam(r, "id")))
+		})
+	})
+	return r
+}
+
+func TestTrailingSlash(t *testing.T) {
+	tests := []struct {
+		name         string
+		mode         SlashMode
+		method       string
+		target       string
+		wantStatus   int
+		wantLocation string
+		wantBody     string
+	}{
+		{
+			name:       "redirect: canonical path untouched",
+			mode:       SlashRedirect,
+			method:     http.MethodGet,
+			target:     "/api/v1/users/42",
+			wantStatus: http.StatusOK,
+			wantBody:   "get 42",
+		},
+		{
+			name:         "redirect: GET is redirected with its query",
+			mode:         SlashRedirect,
+			method:       http.MethodGet,
+			target:       "/api/v1/users/?limit=5",
+			wantStatus:   http.StatusPermanentRedirect,
+			wantLocation: "/api/v1/users?limit=5",
+		},
+		{
+			name:         "redirect: id param keeps its value",
+			mode:         SlashRedirect,
+			method:       http.MethodHead,
+			target:       "/api/v1/users/42/",
+			wantStatus:   http.StatusPermanentRedirect,
+			wantLocation: "/api/v1/users/42",
+		},
+		{
+			name:         "redirect: no protocol-relative location",
+			mode:         SlashRedirect,
+			method:       http.MethodGet,
+			target:       "//evil.example/",
+			wantStatus:   http.StatusPermanentRedirect,
+			wantLocation: "/evil.example",
+		},
+		{
+			name:       "redirect: other methods are stripped",
+			mode:       SlashRedirect,
+			method:     http.MethodPatch,
+			target:     "/api/v1/users/42/",
+			wantStatus: http.StatusOK,
+			wantBody:   "patch 42",
+		},
+		{
+			name:       "strip: GET is served in place",
+			mode:       SlashStrip,
+			method:     http.MethodGet,
+			target:     "/api/v1/users/42/",
+			wantStatus: http.StatusOK,
+			wantBody:   "get 42",
+		},
+		{
+			name:       "strip: collection",
+			mode:       SlashStrip,
+			method:     http.MethodGet,
+			target:     "/api/v1/users/",
+			wantStatus: http.StatusOK,
+			wantBody:   "list",
+		},
+		{
+			name:       "redirect: exempt prefix is served in place",
+			mode:       SlashRedirect,
+			method:     http.MethodGet,
+			target:     "/debug/pprof/",
+			wantStatus: http.StatusOK,
+			wantBody:   "index ",
+		},
+		{
+			name:       "strip: exempt prefix keeps its slash",
+			mode:       SlashStrip,
+			method:     http.MethodGet,
+			target:     "/debug/pprof/heap/",
+			wantStatus: http.StatusOK,
+			wantBody:   "index heap/",
+		},
+		{
+			name:       "strip: root is left alone",
+			mode:       SlashStrip,
+			method:     http.MethodGet,
+			target:     "/",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			newSlashRouter(tt.mode).ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantLocation, rec.Header().Get("Location"))
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package pagination
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// Links builds the JSON:API pagination links for an offset-paginated
+// collection of total items. base is the absolute URL of the request; its
+// other query parameters are kept so filters and sorting carry over, while
+// limit and offset are rewritten and cursor is dropped.
+//
+// self is always present. A collection that fits on one page gets nothing
+// else; otherwise first and last are set, next while items remain past the
+// page, and prev when the page doesn't start at zero. prev never points
+// past last, so an offset beyond the end leads back into the collection.
+func Links(base *url.URL, page Page, total int) map[string]string {
+	limit, offset := page.Limit, page.Offset
+
+	pageURL := func(offset int) string {
+		query := base.Query()
+		query.Del("cursor")
+		query.Set("limit", strconv.Itoa(limit))
+		query.Set("offset", strconv.Itoa(offset))
+		u := *base
+		u.RawQuery = query.Encode()
+		return u.String()
+	}
+
+	links := map[string]string{"self": pageURL(offset)}
+	if offset == 0 && total <= limit {
+		return links
+	}
+
+	last := 0
+	if total > 0 {
+		last = (total - 1) / limit * limit
+	}
+	links["first"] = pageURL(0)
+	links["last"] = pageURL(last)
+	if offset+limit < total {
+		links["next"] = pageURL(offset + limit)
+	}
+	if offset > 0 {
+		links["prev"] = pageURL(min(max(offset-limit, 0), last))
+	}
+	return links
+}
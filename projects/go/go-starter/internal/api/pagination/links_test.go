@@ -0,0 +1,125 @@
+package pagination
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinks(t *testing.T) {
+	const base = "https://api.example.com/api/v1/users"
+
+	tests := []struct {
+		name  string
+		query string
+		page  Page
+		total int
+		want  map[string]string
+	}{
+		{
+			name:  "single page has only self",
+			page:  Page{Limit: 10},
+			total: 10,
+			want: map[string]string{
+				"self": base + "?limit=10&offset=0",
+			},
+		},
+		{
+			name:  "zero results",
+			page:  Page{Limit: 10},
+			total: 0,
+			want: map[string]string{
+				"self": base + "?limit=10&offset=0",
+			},
+		},
+		{
+			name:  "first page keeps other parameters",
+			query: "filter[name]=jo&limit=10",
+			page:  Page{Limit: 10},
+			total: 25,
+			want: map[string]string{
+				"self":  base + "?filter%5Bname%5D=jo&limit=10&offset=0",
+				"first": base + "?filter%5Bname%5D=jo&limit=10&offset=0",
+				"next":  base + "?filter%5Bname%5D=jo&limit=10&offset=10",
+				"last":  base + "?filter%5Bname%5D=jo&limit=10&offset=20",
+			},
+		},
+		{
+			name:  "middle page",
+			page:  Page{Limit: 10, Offset: 10},
+			total: 25,
+			want: map[string]string{
+				"self":  base + "?limit=10&offset=10",
+				"first": base + "?limit=10&offset=0",
+				"prev":  base + "?limit=10&offset=0",
+				"next":  base + "?limit=10&offset=20",
+				"last":  base + "?limit=10&offset=20",
+			},
+		},
+		{
+			name:  "last page has no next, prev clamps at zero",
+			page:  Page{Limit: 10, Offset: 5},
+			total: 15,
+			want: map[string]string{
+				"self":  base + "?limit=10&offset=5",
+				"first": base + "?limit=10&offset=0",
+				"prev":  base + "?limit=10&offset=0",
+				"last":  base + "?limit=10&offset=10",
+			},
+		},
+		{
+			name:  "total divisible by limit",
+			page:  Page{Limit: 10, Offset: 10},
+			total: 20,
+			want: map[string]string{
+				"self":  base + "?limit=10&offset=10",
+				"first": base + "?limit=10&offset=0",
+				"prev":  base + "?limit=10&offset=0",
+				"last":  base + "?limit=10&offset=10",
+			},
+		},
+		{
+			name:  "offset past the end leads back to last",
+			page:  Page{Limit: 10, Offset: 100},
+			total: 25,
+			want: map[string]string{
+				"self":  base + "?limit=10&offset=100",
+				"first": base + "?limit=10&offset=0",
+				"prev":  base + "?limit=10&offset=20",
+				"last":  base + "?limit=10&offset=20",
+			},
+		},
+		{
+			name:  "offset into an empty collection",
+			page:  Page{Limit: 10, Offset: 30},
+			total: 0,
+			want: map[string]string{
+				"self":  base + "?limit=10&offset=30",
+				"first": base + "?limit=10&offset=0",
+				"prev":  base + "?limit=10&offset=0",
+				"last":  base + "?limit=10&offset=0",
+			},
+		},
+		{
+			name:  "cursor is dropped",
+			query: "cursor=abc",
+			page:  Page{Limit: 10},
+			total: 5,
+			want: map[string]string{
+				"self": base + "?limit=10&offset=0",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(base + "?" + tt.query)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, Links(u, tt.page, tt.total))
+			assert.Equal(t, tt.query, u.RawQuery, "base is not modified")
+		})
+	}
+}
@@ -0,0 +1,58 @@
+// Package pagination reads the paging query parameters shared by every
+// collection endpoint, so defaults and caps are the same everywhere
+package pagination
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// Limits applied by Parse. MaxOffset is what the int32 OFFSET of the
+// queries can hold.
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+	MaxOffset    = math.MaxInt32
+)
+
+// Page is the slice of a collection a client asked for. Cursor is the
+// opaque position from a previous response; when it is empty the page is
+// addressed by Offset.
+type Page struct {
+	Limit  int
+	Offset int
+	Cursor string
+}
+
+// Parse reads limit, offset and cursor from the query string. Missing
+// values take their defaults; anything out of range is an error whose
+// message is safe to return to the client.
+func Parse(r *http.Request) (Page, error) {
+	query := r.URL.Query()
+	page := Page{Limit: DefaultLimit, Cursor: query.Get("cursor")}
+
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return Page{}, fmt.Errorf("limit must be an integer between 1 and %d", MaxLimit)
+		}
+		page.Limit = limit
+	}
+
+	if raw := query.Get("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 || offset > MaxOffset {
+			return Page{}, fmt.Errorf("offset must be an integer between 0 and %d", MaxOffset)
+		}
+		page.Offset = offset
+	}
+
+	if page.Cursor != "" && query.Has("offset") {
+		return Page{}, errors.New("cursor and offset cannot be combined")
+	}
+
+	return page, nil
+}
@@ -0,0 +1,49 @@
+package pagination
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    Page
+		wantErr string
+	}{
+		{name: "defaults", query: "", want: Page{Limit: DefaultLimit}},
+		{name: "smallest limit", query: "?limit=1", want: Page{Limit: 1}},
+		{name: "largest limit", query: "?limit=100", want: Page{Limit: MaxLimit}},
+		{name: "limit over cap", query: "?limit=101", wantErr: "limit must be an integer between 1 and 100"},
+		{name: "zero limit", query: "?limit=0", wantErr: "limit must be an integer between 1 and 100"},
+		{name: "negative limit", query: "?limit=-5", wantErr: "limit must be an integer between 1 and 100"},
+		{name: "non-numeric limit", query: "?limit=ten", wantErr: "limit must be an integer between 1 and 100"},
+		{name: "zero offset", query: "?offset=0", want: Page{Limit: DefaultLimit}},
+		{name: "offset", query: "?limit=10&offset=30", want: Page{Limit: 10, Offset: 30}},
+		{name: "negative offset", query: "?offset=-1", wantErr: "offset must be an integer between 0 and 2147483647"},
+		{name: "largest offset", query: "?offset=2147483647", want: Page{Limit: DefaultLimit, Offset: MaxOffset}},
+		{name: "offset over int32", query: "?offset=2147483648", wantErr: "offset must be an integer between 0 and 2147483647"},
+		{name: "offset over int64", query: "?offset=99999999999999999999", wantErr: "offset must be an integer between 0 and 2147483647"},
+		{name: "non-numeric offset", query: "?offset=1.5", wantErr: "offset must be an integer between 0 and 2147483647"},
+		{name: "cursor", query: "?cursor=abc&limit=5", want: Page{Limit: 5, Cursor: "abc"}},
+		{name: "cursor with offset", query: "?cursor=abc&offset=0", wantErr: "cursor and offset cannot be combined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := Parse(httptest.NewRequest(http.MethodGet, "/users"+tt.query, nil))
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, page)
+		})
+	}
+}
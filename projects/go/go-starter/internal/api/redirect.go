@@ -0,0 +1,37 @@
+package api
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/yourusername/go-starter/internal/api/middleware"
+)
+
+// NewHTTPSRedirect returns a handler that permanently redirects every
+// request to the same host, path and query over HTTPS. httpsAddr is the
+// TLS listener's address; its port is kept in the target unless it is 443.
+func NewHTTPSRedirect(httpsAddr string) http.Handler {
+	_, httpsPort, err := net.SplitHostPort(httpsAddr)
+	if err != nil || httpsPort == "443" {
+		httpsPort = ""
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+
+		target := *r.URL
+		target.Scheme = "https"
+		target.Host = host
+
+		// Ignored by browsers over plain HTTP, but some clients honor it and
+		// it documents the intent
+		w.Header().Set("Strict-Transport-Security", middleware.HSTSValue)
+		http.Redirect(w, r, target.String(), http.StatusMovedPermanently)
+	})
+}
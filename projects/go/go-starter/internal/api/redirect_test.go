@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHTTPSRedirect(t *testing.T) {
+	tests := []struct {
+		name      string
+		httpsAddr string
+		target    string
+		host      string
+		want      string
+	}{
+		{
+			name:      "default port is omitted",
+			httpsAddr: ":443",
+			target:    "/api/v1/users?filter[name]=jo&limit=5",
+			host:      "api.example.com",
+			want:      "https://api.example.com/api/v1/users?filter[name]=jo&limit=5",
+		},
+		{
+			name:      "non-default port is kept, request port dropped",
+			httpsAddr: ":8443",
+			target:    "/health",
+			host:      "localhost:8080",
+			want:      "https://localhost:8443/health",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			r.Host = tt.host
+			rec := httptest.NewRecorder()
+
+			NewHTTPSRedirect(tt.httpsAddr).ServeHTTP(rec, r)
+
+			assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+			assert.Equal(t, tt.want, rec.Header().Get("Location"))
+			assert.NotEmpty(t, rec.Header().Get("Strict-Transport-Security"))
+		})
+	}
+}
@@ -1,46 +1,239 @@
 package api
 
 import (
+	"context"
+	"expvar"
 	"net/http"
+	"net/http/pprof"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/yourusername/go-starter/internal/api/handlers"
+	"github.com/yourusername/go-starter/internal/api/jsonapi"
 	"github.com/yourusername/go-starter/internal/api/middleware"
+	"github.com/yourusername/go-starter/internal/api/openapi"
+	"github.com/yourusername/go-starter/internal/cache"
+	"github.com/yourusername/go-starter/internal/config"
 	"github.com/yourusername/go-starter/internal/db"
+	"github.com/yourusername/go-starter/internal/models"
 	"github.com/yourusername/go-starter/internal/repository"
 	"github.com/yourusername/go-starter/internal/service"
+	"github.com/yourusername/go-starter/migrations"
 	"log/slog"
 )
 
-func NewRouter(queries *db.Queries, logger *slog.Logger) *chi.Mux {
+// idempotencyTTL is how long a response is replayed for a repeated
+// Idempotency-Key
+const idempotencyTTL = 24 * time.Hour
+
+// NewRouter wires handlers, services and repositories onto a chi mux.
+// redisClient may be nil when Redis is not configured. /health fails while
+// draining is set; it may be nil too.
+func NewRouter(cfg *config.Config, dbpool *pgxpool.Pool, redisClient *cache.Client, draining *atomic.Bool, logger *slog.Logger) *chi.Mux {
 	r := chi.NewRouter()
 
-	// Middleware stack
+	// Middleware stack. Everything registered with r.Use here runs before
+	// routing, so middleware.RoutePattern is only known to it after the
+	// handler returns or starts writing; middleware that needs the route
+	// up front belongs on the route (r.With) instead.
+	// First, so every error response below logs to the app logger
+	r.Use(middleware.ErrorLogger(logger))
 	r.Use(middleware.RequestID)
-	r.Use(middleware.Logging(logger))
-	r.Use(middleware.Recovery(logger))
+	if cfg.ErrorIDs {
+		r.Use(middleware.ErrorIDs)
+	}
+	// Before Logging so remote_addr is the client, not the proxy
+	r.Use(middleware.RealIP(cfg.TrustedProxies))
+	r.Use(middleware.Locale)
+	// Before Logging so megabyte URLs never reach the request log
+	r.Use(middleware.MaxURLLength(cfg.MaxURLLength))
+	// Filled in below once every route is registered
+	handlerNames := middleware.NewHandlerNames()
+	loggingOpts := []middleware.LoggingOption{middleware.WithHandlerNames(handlerNames)}
+	if cfg.LogRequestStart {
+		loggingOpts = append(loggingOpts, middleware.WithStartLog())
+	}
+	if cfg.LogRequestHeaders {
+		loggingOpts = append(loggingOpts, middleware.WithHeaders(cfg.LogRedactHeaders))
+	}
+	r.Use(middleware.Logging(logger, loggingOpts...))
+	var recoveryOpts []middleware.RecoveryOption
+	if cfg.PanicDetails {
+		recoveryOpts = append(recoveryOpts, middleware.WithPanicDetails(cfg.ServerEnv))
+	}
+	r.Use(middleware.Recovery(logger, recoveryOpts...))
+	// Before routing, so "/users/" and "/users" reach the same route.
+	// pprof.Index lives at /debug/pprof/, with the slash.
+	r.Use(middleware.TrailingSlash(middleware.SlashMode(cfg.TrailingSlash), middleware.SlashExempt("/debug/pprof/")))
+	r.Use(middleware.Timing)
+	r.Use(middleware.APIVersion(cfg.APIVersion))
+	// Deprecate routes here so clients see Deprecation and Sunset headers
+	// ahead of their removal, e.g.
+	//   deprecations.Deprecate(http.MethodGet, "/api/v1/users/count", middleware.Deprecation{
+	//   	Since: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Sunset: time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+	//   })
+	deprecations := middleware.NewDeprecationRegistry()
+	r.Use(deprecations.Middleware)
+	if cfg.TLSEnabled() {
+		r.Use(middleware.HSTS)
+	}
 	// CORS middleware can be added here if needed
 	// r.Use(middleware.CORS(allowedOrigins, allowedMethods, allowedHeaders))
 
+	// JSON:API responses for unmatched routes. Set before any routes are
+	// added so sub-routers inherit them.
+	r.NotFound(handlers.NotFound)
+	r.MethodNotAllowed(handlers.MethodNotAllowed(r))
+
 	// Health check endpoint
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status":"healthy"}`))
-	})
+	healthChecks := []handlers.HealthCheck{
+		{Name: "database", Required: true, Check: dbpool.Ping},
+	}
+	if cfg.CheckSchemaVersion {
+		// Keeps a new binary out of rotation until its migrations ran
+		healthChecks = append(healthChecks, handlers.HealthCheck{
+			Name:     "schema",
+			Required: true,
+			Check: func(ctx context.Context) error {
+				return db.CheckSchemaVersion(ctx, dbpool, migrations.Latest())
+			},
+		})
+	}
+	if redisClient != nil {
+		healthChecks = append(healthChecks, handlers.HealthCheck{
+			Name: "redis",
+			Check: func(ctx context.Context) error {
+				return redisClient.Redis().Ping(ctx).Err()
+			},
+		})
+	}
+	healthHandler := handlers.NewHealthHandler(logger, healthChecks...)
+	healthHandler.SetDraining(draining)
+	r.Get("/health", healthHandler.Health)
+
+	// API description; the interactive UI is for development only
+	r.Get("/openapi.json", openapi.Handler)
+	if !cfg.IsProduction() {
+		r.Get("/docs", openapi.Docs)
+	}
+
+	if cfg.PprofEnabled() {
+		logger.Warn("pprof endpoints enabled", slog.String("path", "/debug/pprof"), slog.String("env", cfg.ServerEnv))
+		// Profiles and metrics expose internals, so admins only
+		adminOnly := []func(http.Handler) http.Handler{
+			middleware.Authenticate([]byte(cfg.JWTSecret)),
+			middleware.RequireRole(models.RoleAdmin),
+		}
+		mountPprof(r, adminOnly...)
+		// expvar metrics such as redis_connected, under the same guard
+		r.With(adminOnly...).Handle("/debug/vars", expvar.Handler())
+	}
+
+	// Idempotency keys live in Redis when it is configured and reachable so
+	// that every instance sees them, and in memory otherwise
+	idempotencyStore := middleware.NewMemoryIdempotencyStore(idempotencyTTL)
+	if redisClient != nil {
+		idempotencyStore = cache.NewFallbackIdempotencyStore(redisClient, idempotencyTTL, cfg.ServerWriteTimeout)
+	}
 
 	// Initialize dependencies (following clean architecture)
-	userRepo := repository.NewUserRepository(queries)
+	userRepo := repository.NewUserRepository(db.New(dbpool), dbpool, repository.WithQueryTimeout(cfg.DBQueryTimeout))
 	userService := service.NewUserService(userRepo)
-	userHandler := handlers.NewUserHandler(userService, logger)
+	if cfg.UserCacheSize > 0 {
+		var cacheOpts []cache.UserServiceOption
+		if cfg.UserCacheStaleOnError {
+			cacheOpts = append(cacheOpts, cache.WithStaleOnError())
+		}
+		userService = cache.NewCachedUserService(userService, cfg.UserCacheSize, cfg.UserCacheTTL, cacheOpts...)
+	}
+	handlerOpts := []handlers.UserHandlerOption{handlers.WithTrustedProxies(cfg.TrustedProxies)}
+	if cfg.LogQuietHandlerErrors {
+		handlerOpts = append(handlerOpts, handlers.WithQuietErrors())
+	}
+	userHandler := handlers.NewUserHandler(userService, logger, handlerOpts...)
 
 	// API routes
 	r.Route("/api/v1", func(r chi.Router) {
+		// API only: links are built from the Host here, while /health is
+		// probed by IP
+		r.Use(middleware.AllowedHosts(cfg.AllowedHosts, cfg.TrustedProxies))
+		// Before MaxInFlight so throttled clients never take a slot
+		r.Use(middleware.RateLimit(cfg.RateLimitRequests, cfg.RateLimitWindow))
+		// The cap lives here rather than on the root router so that /health
+		// keeps answering probes while the API sheds load
+		r.Use(middleware.MaxInFlight(cfg.MaxConcurrentRequests))
+		r.Use(middleware.Idempotency(idempotencyStore, logger))
+
 		// User routes
 		r.Route("/users", func(r chi.Router) {
-			r.Get("/{id}", userHandler.GetUser)
+			MountUserRoutes(r, cfg, userHandler)
 		})
 	})
 
+	routes, err := Routes(r)
+	if err != nil {
+		// Logs then carry the route pattern instead
+		logger.Warn("failed to resolve handler names", slog.Any("error", err))
+	}
+	for _, ri := range routes {
+		handlerNames.Set(ri.Method, ri.Pattern, ri.Handler)
+	}
+
 	return r
 }
+
+// MountUserRoutes registers the /users endpoints on r, which NewRouter
+// mounts at /api/v1/users. It is separate so handler tests can serve the
+// same routes on top of an in-memory repository.
+func MountUserRoutes(r chi.Router, cfg *config.Config, userHandler *handlers.UserHandler) {
+	// Only on the routes that take a body, after method matching, so an
+	// unsupported method gets 405 rather than 415
+	mode := middleware.MediaTypeMode(cfg.JSONAPIContentType)
+	jsonAPIBody := middleware.RequireJSONAPIContentType(mode)
+	// UpdateUser also takes JSON Merge Patch
+	patchBody := middleware.RequireJSONAPIContentType(mode,
+		middleware.AllowMediaType(http.MethodPatch, jsonapi.MergePatchMediaType),
+	)
+
+	authenticate := middleware.Authenticate([]byte(cfg.JWTSecret))
+	adminOnly := []func(http.Handler) http.Handler{
+		authenticate,
+		middleware.RequireRole(models.RoleAdmin),
+	}
+
+	r.Get("/", userHandler.ListUsers)
+	// Counting is an admin report, not part of browsing the collection
+	r.With(adminOnly...).Get("/count", userHandler.CountUsers)
+	r.Get("/search", userHandler.SearchUsers)
+	r.With(jsonAPIBody).Post("/bulk", userHandler.CreateUsersBulk)
+	r.With(jsonAPIBody).Post("/batch-get", userHandler.BatchGetUsers)
+	r.Get("/{id}", userHandler.GetUser)
+	// HEAD reuses GET, so the headers match without a body
+	r.With(middleware.Head).Head("/", userHandler.ListUsers)
+	r.With(append(adminOnly, middleware.Head)...).Head("/count", userHandler.CountUsers)
+	r.With(middleware.Head).Head("/search", userHandler.SearchUsers)
+	r.With(middleware.Head).Head("/{id}", userHandler.GetUser)
+	// The handlers let users change their own record and password and
+	// admins anyone's
+	r.With(authenticate, patchBody).Patch("/{id}", userHandler.UpdateUser)
+	r.With(authenticate, jsonAPIBody).Post("/{id}/password", userHandler.ChangePassword)
+	r.With(adminOnly...).Delete("/{id}", userHandler.DeleteUser)
+}
+
+// mountPprof exposes the net/http/pprof handlers under /debug/pprof behind
+// middlewares. They sit outside /api/v1, so neither the API middleware nor
+// MaxInFlight applies, and chi reports a single /debug/pprof/* route
+// pattern for every named profile.
+func mountPprof(r chi.Router, middlewares ...func(http.Handler) http.Handler) {
+	r.Route("/debug/pprof", func(r chi.Router) {
+		r.Use(middlewares...)
+		r.HandleFunc("/cmdline", pprof.Cmdline)
+		r.HandleFunc("/profile", pprof.Profile)
+		r.HandleFunc("/symbol", pprof.Symbol)
+		r.HandleFunc("/trace", pprof.Trace)
+		// Index also serves named profiles such as /debug/pprof/heap
+		r.HandleFunc("/*", pprof.Index)
+	})
+}
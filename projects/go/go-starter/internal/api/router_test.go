@@ -0,0 +1,209 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/go-starter/internal/api/openapi"
+	"github.com/yourusername/go-starter/internal/auth"
+	"github.com/yourusername/go-starter/internal/config"
+	"github.com/yourusername/go-starter/internal/models"
+)
+
+// undocumentedPrefixes are routes that are not part of the API
+var undocumentedPrefixes = []string{"/debug/", "/docs", "/openapi.json"}
+
+func isUndocumented(pattern string) bool {
+	for _, prefix := range undocumentedPrefixes {
+		if strings.HasPrefix(pattern, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// newTestRouter builds the full router. The pool connects lazily, so no
+// database is needed.
+func newTestRouter(t *testing.T) *chi.Mux {
+	t.Helper()
+	pool, err := pgxpool.New(context.Background(), "postgres://localhost:1/unused")
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	cfg := &config.Config{ServerEnv: "development", JWTSecret: "secret", TrailingSlash: "redirect"}
+	return NewRouter(cfg, pool, nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestRoutes(t *testing.T) {
+	routes, err := Routes(newTestRouter(t))
+	require.NoError(t, err)
+
+	// The route table, for reviewers: go test ./internal/api -run TestRoutes -v
+	var table strings.Builder
+	tw := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	for _, route := range routes {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", route.Method, route.Pattern, route.Handler)
+	}
+	tw.Flush()
+	t.Log("\n" + table.String())
+
+	byRoute := make(map[string]RouteInfo, len(routes))
+	for _, route := range routes {
+		byRoute[route.Method+" "+route.Pattern] = route
+	}
+
+	get := byRoute["GET /api/v1/users/{id}"]
+	assert.Equal(t, "handlers.(*UserHandler).GetUser", get.Handler)
+	assert.True(t, get.HasMiddleware("middleware.RequestID"))
+	assert.False(t, get.HasMiddleware("middleware.Authenticate"))
+
+	// Routes that change users, their credentials or remove data must be
+	// authenticated
+	for _, key := range []string{"PATCH /api/v1/users/{id}", "DELETE /api/v1/users/{id}", "POST /api/v1/users/{id}/password"} {
+		assert.True(t, byRoute[key].HasMiddleware("middleware.Authenticate"), key)
+	}
+	assert.True(t, byRoute["DELETE /api/v1/users/{id}"].HasMiddleware("middleware.RequireRole"))
+	for _, key := range []string{"GET /api/v1/users/count", "HEAD /api/v1/users/count"} {
+		assert.True(t, byRoute[key].HasMiddleware("middleware.Authenticate"), key)
+		assert.True(t, byRoute[key].HasMiddleware("middleware.RequireRole"), key)
+	}
+
+	// Only routes with a body check the Content-Type
+	for _, key := range []string{"POST /api/v1/users/bulk", "POST /api/v1/users/batch-get", "PATCH /api/v1/users/{id}", "POST /api/v1/users/{id}/password"} {
+		assert.True(t, byRoute[key].HasMiddleware("middleware.RequireJSONAPIContentType"), key)
+	}
+	assert.False(t, get.HasMiddleware("middleware.RequireJSONAPIContentType"))
+
+	// Debug endpoints are for admins only
+	for _, key := range []string{"GET /debug/pprof/*", "GET /debug/pprof/profile", "GET /debug/vars"} {
+		require.Contains(t, byRoute, key)
+		assert.True(t, byRoute[key].HasMiddleware("middleware.Authenticate"), key)
+		assert.True(t, byRoute[key].HasMiddleware("middleware.RequireRole"), key)
+	}
+}
+
+func TestNewRouter_LogsErrorCode(t *testing.T) {
+	pool, err := pgxpool.New(context.Background(), "postgres://localhost:1/unused")
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	for _, quiet := range []bool{false, true} {
+		var logs strings.Builder
+		cfg := &config.Config{ServerEnv: "development", JWTSecret: "secret", TrailingSlash: "redirect", LogQuietHandlerErrors: quiet}
+		r := NewRouter(cfg, pool, nil, nil, slog.New(slog.NewTextHandler(&logs, nil)))
+
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/users/not-a-uuid", nil))
+
+		assert.Contains(t, logs.String(), `msg="error response" error_code=INVALID_ID status=400`, "quiet=%v", quiet)
+		if quiet {
+			assert.NotContains(t, logs.String(), "invalid user id format")
+		} else {
+			assert.Contains(t, logs.String(), "invalid user id format")
+		}
+	}
+}
+
+func TestNewRouter_UnsupportedMethodWithBody(t *testing.T) {
+	r := newTestRouter(t)
+
+	for _, target := range []string{"/api/v1/users", "/api/v1/users/550e8400-e29b-41d4-a716-446655440000"} {
+		req := httptest.NewRequest(http.MethodPut, target, strings.NewReader(`{"data":{}}`))
+		req.Header.Set("Content-Type", "text/plain")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code, target)
+	}
+
+	// A supported method still gets the Content-Type check
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/bulk", strings.NewReader(`{"data":[]}`))
+	req.Header.Set("Content-Type", "text/plain")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+}
+
+func TestNewRouter_DebugEndpointsRequireAdmin(t *testing.T) {
+	r := newTestRouter(t)
+
+	token := func(role string) string {
+		t.Helper()
+		tok, err := auth.GenerateToken(uuid.New(), "ops@example.com", role, []byte("secret"), time.Minute)
+		require.NoError(t, err)
+		return tok
+	}
+
+	for _, target := range []string{"/debug/vars", "/debug/pprof/", "/debug/pprof/cmdline"} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, target)
+
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Authorization", "Bearer "+token(models.RoleUser))
+		rec = httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusForbidden, rec.Code, target)
+
+		req = httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Authorization", "Bearer "+token(models.RoleAdmin))
+		rec = httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code, target)
+		assert.Empty(t, rec.Header().Get("Location"), "TrailingSlash must not redirect %s", target)
+	}
+}
+
+func TestRouter_RoutesMatchOpenAPI(t *testing.T) {
+	all, err := Routes(newTestRouter(t))
+	require.NoError(t, err)
+
+	var routes []string
+	for _, route := range all {
+		if !isUndocumented(route.Pattern) {
+			routes = append(routes, route.Method+" "+route.Pattern)
+		}
+	}
+
+	ops, err := openapi.Operations()
+	require.NoError(t, err)
+	var documented []string
+	for method, paths := range ops {
+		for _, path := range paths {
+			documented = append(documented, method+" "+path)
+		}
+	}
+
+	sort.Strings(routes)
+	sort.Strings(documented)
+	assert.Equal(t, documented, routes, "internal/api/openapi/openapi.json is out of sync with the router")
+}
+
+func TestNewRouter_LogsHandlerName(t *testing.T) {
+	pool, err := pgxpool.New(context.Background(), "postgres://localhost:1/unused")
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	var logs strings.Builder
+	cfg := &config.Config{ServerEnv: "development", JWTSecret: "secret", TrailingSlash: "redirect"}
+	r := NewRouter(cfg, pool, nil, nil, slog.New(slog.NewTextHandler(&logs, nil)))
+
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		logs.Reset()
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/api/v1/users/not-a-uuid", nil))
+		assert.Contains(t, logs.String(), "handler=handlers.(*UserHandler).GetUser", method)
+	}
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RouteInfo describes one method + pattern registered on a router
+type RouteInfo struct {
+	Method  string
+	Pattern string
+	// Handler is the endpoint's function name, e.g.
+	// "handlers.(*UserHandler).GetUser"
+	Handler string
+	// Middlewares are the names of every middleware the request passes
+	// through, outermost first, e.g. "middleware.Authenticate"
+	Middlewares []string
+}
+
+// HasMiddleware reports whether the route runs the named middleware
+func (ri RouteInfo) HasMiddleware(name string) bool {
+	for _, mw := range ri.Middlewares {
+		if mw == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Routes lists every route of r, sorted by pattern and then method. Trailing
+// slashes left by sub-router roots are trimmed, so "/api/v1/users/" is
+// reported as "/api/v1/users".
+func Routes(r chi.Routes) ([]RouteInfo, error) {
+	var routes []RouteInfo
+	err := chi.Walk(r, func(method, pattern string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		if pattern != "/" {
+			pattern = strings.TrimSuffix(pattern, "/")
+		}
+		names := make([]string, len(middlewares))
+		for i, mw := range middlewares {
+			names[i] = funcName(mw)
+		}
+		routes = append(routes, RouteInfo{
+			Method:      method,
+			Pattern:     pattern,
+			Handler:     handlerName(handler),
+			Middlewares: names,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk routes: %w", err)
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Pattern != routes[j].Pattern {
+			return routes[i].Pattern < routes[j].Pattern
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes, nil
+}
+
+func handlerName(h http.Handler) string {
+	if fn, ok := h.(http.HandlerFunc); ok {
+		return funcName(fn)
+	}
+	return fmt.Sprintf("%T", h)
+}
+
+// closureSuffix matches what the runtime appends to the names of closures
+// and method values: ".func1", ".func1.2", ".1" (inlined closures) and "-fm"
+var closureSuffix = regexp.MustCompile(`((\.func\d+)|(\.\d+))*(-fm)?$`)
+
+// funcName returns a function's package-qualified name without the module
+// path, reporting closures returned by a constructor (as most middleware
+// are) under the constructor's name
+func funcName(fn interface{}) string {
+	name := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	name = name[strings.LastIndex(name, "/")+1:]
+	return closureSuffix.ReplaceAllString(name, "")
+}
@@ -0,0 +1,159 @@
+// Package testutil serves the user API on top of the in-memory repository
+// for handler tests, with helpers to assert on JSON:API documents. Requests
+// run through the real routes and the middleware that shapes responses, so
+// tests exercise what clients see without a database.
+package testutil
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/go-starter/internal/api"
+	"github.com/yourusername/go-starter/internal/api/handlers"
+	"github.com/yourusername/go-starter/internal/api/jsonapi"
+	"github.com/yourusername/go-starter/internal/api/middleware"
+	"github.com/yourusername/go-starter/internal/auth"
+	"github.com/yourusername/go-starter/internal/config"
+	"github.com/yourusername/go-starter/internal/repository"
+	"github.com/yourusername/go-starter/internal/service"
+)
+
+// JWTSecret signs tokens for the authenticated routes of a Harness
+const JWTSecret = "test-secret"
+
+// Harness is the user API backed by an in-memory repository
+type Harness struct {
+	// Router serves /api/v1/users like the real router
+	Router *chi.Mux
+	// Users is the repository behind the API, for seeding and checking
+	// state directly
+	Users repository.UserRepository
+
+	t testing.TB
+}
+
+// New builds a Harness whose repository holds users
+func New(t testing.TB, users ...repository.User) *Harness {
+	t.Helper()
+
+	repo, err := repository.NewSeededMemoryUserRepository(users...)
+	require.NoError(t, err)
+	return NewWithService(t, repo, service.NewUserService(repo))
+}
+
+// NewWithService builds a Harness around svc, e.g. a stub that fails in a
+// specific way. repo is exposed as Users and may be nil.
+func NewWithService(t testing.TB, repo repository.UserRepository, svc service.UserService) *Harness {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := &config.Config{JWTSecret: JWTSecret, JSONAPIContentType: string(middleware.MediaTypeRelaxed)}
+
+	r := chi.NewRouter()
+	r.Use(middleware.ErrorLogger(logger))
+	r.Use(middleware.RequestID)
+	r.NotFound(handlers.NotFound)
+	r.MethodNotAllowed(handlers.MethodNotAllowed(r))
+	r.Route("/api/v1/users", func(r chi.Router) {
+		api.MountUserRoutes(r, cfg, handlers.NewUserHandler(svc, logger))
+	})
+
+	return &Harness{Router: r, Users: repo, t: t}
+}
+
+// Do serves a request with body, which may be empty, and returns the
+// recorded response. Bodies are sent as JSON:API documents; headers are
+// "Name: value" pairs that override that.
+func (h *Harness) Do(method, target, body string, headers ...string) *httptest.ResponseRecorder {
+	h.t.Helper()
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", jsonapi.MediaType)
+	}
+	for _, header := range headers {
+		name, value, ok := strings.Cut(header, ":")
+		require.True(h.t, ok, "header %q is not Name: value", header)
+		req.Header.Set(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	rec := httptest.NewRecorder()
+	h.Router.ServeHTTP(rec, req)
+	return rec
+}
+
+// AuthHeader returns an Authorization header for Do carrying a token with
+// role, e.g. models.RoleAdmin, for a user that does not exist
+func (h *Harness) AuthHeader(role string) string {
+	h.t.Helper()
+	return h.AuthHeaderFor(uuid.New(), role)
+}
+
+// AuthHeaderFor is AuthHeader for the user with id
+func (h *Harness) AuthHeaderFor(id uuid.UUID, role string) string {
+	h.t.Helper()
+
+	token, err := auth.GenerateToken(id, "harness@example.com", role, []byte(JWTSecret), time.Hour)
+	require.NoError(h.t, err)
+	return "Authorization: Bearer " + token
+}
+
+// Server starts an httptest.Server for the harness, closed when the test
+// ends, for tests that need a real connection
+func (h *Harness) Server() *httptest.Server {
+	srv := httptest.NewServer(h.Router)
+	h.t.Cleanup(srv.Close)
+	return srv
+}
+
+// Resource is a decoded JSON:API resource object
+type Resource struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id"`
+	Attributes map[string]interface{} `json:"attributes"`
+	Meta       map[string]interface{} `json:"meta"`
+}
+
+// AssertResource checks that rec is a JSON:API document with status and a
+// single resource of type as its primary data, and returns the resource
+func AssertResource(t testing.TB, rec *httptest.ResponseRecorder, status int, typ string) Resource {
+	t.Helper()
+
+	require.Equal(t, status, rec.Code, rec.Body.String())
+	assert.Equal(t, jsonapi.MediaType, rec.Header().Get("Content-Type"))
+
+	var doc struct {
+		Data Resource `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc), rec.Body.String())
+	assert.Equal(t, typ, doc.Data.Type)
+	return doc.Data
+}
+
+// AssertError checks that rec is a JSON:API error document with status
+// whose first error has code, and returns the errors
+func AssertError(t testing.TB, rec *httptest.ResponseRecorder, status int, code string) []jsonapi.Error {
+	t.Helper()
+
+	require.Equal(t, status, rec.Code, rec.Body.String())
+	assert.Equal(t, jsonapi.MediaType, rec.Header().Get("Content-Type"))
+
+	var doc jsonapi.ErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc), rec.Body.String())
+	require.NotEmpty(t, doc.Errors)
+	assert.Equal(t, code, doc.Errors[0].Code)
+	assert.Equal(t, http.StatusText(status), doc.Errors[0].Title)
+	assert.NotEmpty(t, doc.Errors[0].Meta["request_id"])
+	return doc.Errors
+}
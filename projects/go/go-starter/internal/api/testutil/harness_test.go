@@ -0,0 +1,26 @@
+package testutil
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHarness_Server(t *testing.T) {
+	h := New(t)
+
+	resp, err := http.Get(h.Server().URL + "/api/v1/users/" + uuid.NewString())
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestHarness_Do_Headers(t *testing.T) {
+	h := New(t)
+
+	rec := h.Do(http.MethodPost, "/api/v1/users/bulk", `{"data":[]}`, "Content-Type: text/plain")
+	AssertError(t, rec, http.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE")
+}
@@ -0,0 +1,118 @@
+// Package audit records who created, changed or deleted a resource. Entries
+// are written with the same db.Querier as the mutation, so an entry commits
+// exactly when the change does. Reads are deliberately not audited.
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/go-starter/internal/db"
+)
+
+// Actions
+const (
+	ActionCreate         = "create"
+	ActionUpdate         = "update"
+	ActionChangePassword = "change_password"
+	ActionDelete         = "delete"
+)
+
+// Entry is one audit_log row
+type Entry struct {
+	ID           int64
+	Action       string
+	ResourceType string
+	ResourceID   string
+	// ActorID and RequestID are empty when unknown, e.g. for
+	// unauthenticated requests
+	ActorID   string
+	RequestID string
+	CreatedAt time.Time
+}
+
+type (
+	actorKey     struct{}
+	requestIDKey struct{}
+)
+
+// WithActor returns a copy of ctx carrying the ID of the authenticated user
+// making the change
+func WithActor(ctx context.Context, actorID string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actorID)
+}
+
+// Actor returns the actor stored in ctx, or ""
+func Actor(ctx context.Context) string {
+	actorID, _ := ctx.Value(actorKey{}).(string)
+	return actorID
+}
+
+// WithRequestID returns a copy of ctx carrying the ID of the request making
+// the change, so entries can be matched with request logs
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// Audit records that actorID performed action on a resource. The request ID
+// is taken from ctx. q must be bound to the transaction of the mutation.
+func Audit(ctx context.Context, q db.Querier, action, resourceType, resourceID, actorID string) error {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+
+	err := q.InsertAuditEntry(ctx, db.InsertAuditEntryParams{
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		ActorID:      nullable(actorID),
+		RequestID:    nullable(requestID),
+	})
+	if err != nil {
+		return fmt.Errorf("insert audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// List returns the entries for one resource, newest first
+func List(ctx context.Context, q db.Querier, resourceType, resourceID string, limit, offset int32) ([]Entry, error) {
+	rows, err := q.ListAuditEntries(ctx, db.ListAuditEntriesParams{
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Limit:        limit,
+		Offset:       offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list audit entries: %w", err)
+	}
+
+	entries := make([]Entry, len(rows))
+	for i, row := range rows {
+		entries[i] = Entry{
+			ID:           row.ID,
+			Action:       row.Action,
+			ResourceType: row.ResourceType,
+			ResourceID:   row.ResourceID,
+			ActorID:      deref(row.ActorID),
+			RequestID:    deref(row.RequestID),
+			CreatedAt:    row.CreatedAt.Time,
+		}
+	}
+
+	return entries, nil
+}
+
+// nullable maps "" to NULL
+func nullable(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/go-starter/internal/db"
+)
+
+// stubQuerier keeps audit entries in memory. Embedding db.Querier means
+// anything else panics.
+type stubQuerier struct {
+	db.Querier
+	inserted []db.InsertAuditEntryParams
+	rows     []db.AuditLog
+}
+
+func (s *stubQuerier) InsertAuditEntry(_ context.Context, arg db.InsertAuditEntryParams) error {
+	s.inserted = append(s.inserted, arg)
+	return nil
+}
+
+func (s *stubQuerier) ListAuditEntries(context.Context, db.ListAuditEntriesParams) ([]db.AuditLog, error) {
+	return s.rows, nil
+}
+
+func TestAudit(t *testing.T) {
+	q := &stubQuerier{}
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	require.NoError(t, Audit(ctx, q, ActionDelete, "users", "42", "admin-1"))
+	// Unknown actor and request are stored as NULL, not ""
+	require.NoError(t, Audit(context.Background(), q, ActionCreate, "users", "43", ""))
+
+	require.Len(t, q.inserted, 2)
+	assert.Equal(t, "admin-1", *q.inserted[0].ActorID)
+	assert.Equal(t, "req-1", *q.inserted[0].RequestID)
+	assert.Nil(t, q.inserted[1].ActorID)
+	assert.Nil(t, q.inserted[1].RequestID)
+}
+
+func TestList(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	actor := "admin-1"
+	q := &stubQuerier{rows: []db.AuditLog{
+		{ID: 2, Action: ActionDelete, ResourceType: "users", ResourceID: "42", ActorID: &actor, CreatedAt: pgtype.Timestamptz{Time: at, Valid: true}},
+		{ID: 1, Action: ActionCreate, ResourceType: "users", ResourceID: "42"},
+	}}
+
+	entries, err := List(context.Background(), q, "users", "42", 20, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Entry{
+		{ID: 2, Action: ActionDelete, ResourceType: "users", ResourceID: "42", ActorID: "admin-1", CreatedAt: at},
+		{ID: 1, Action: ActionCreate, ResourceType: "users", ResourceID: "42"},
+	}, entries)
+}
+
+func TestActor(t *testing.T) {
+	assert.Empty(t, Actor(context.Background()))
+	assert.Equal(t, "user-1", Actor(WithActor(context.Background(), "user-1")))
+}
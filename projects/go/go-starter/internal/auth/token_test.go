@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func TestValidateToken(t *testing.T) {
+	userID := uuid.New()
+	valid, err := GenerateToken(userID, "jane@example.com", "admin", testSecret, time.Hour)
+	require.NoError(t, err)
+
+	claims, err := ValidateToken(valid, testSecret)
+	require.NoError(t, err)
+	assert.Equal(t, userID.String(), claims.UserID)
+	assert.Equal(t, "admin", claims.Role)
+
+	expired, err := GenerateToken(userID, "jane@example.com", "admin", testSecret, -time.Minute)
+	require.NoError(t, err)
+
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, Claims{Role: "admin"}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		token  string
+		secret []byte
+	}{
+		{name: "wrong secret", token: valid, secret: []byte("another-secret-another-secret-xx")},
+		{name: "expired", token: expired, secret: testSecret},
+		{name: "alg none", token: unsigned, secret: testSecret},
+		{name: "garbage", token: "not.a.token", secret: testSecret},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateToken(tt.token, tt.secret)
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+}
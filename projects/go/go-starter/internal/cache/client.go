@@ -0,0 +1,90 @@
+// Package cache holds the caching layers: the optional Redis connection and
+// an in-process LRU in front of user reads. Redis is a nice-to-have: when
+// it is unreachable the server keeps running on in-memory fallbacks and
+// Client keeps probing until it comes back.
+package cache
+
+import (
+	"context"
+	"expvar"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// connected is the Redis connectivity gauge served on /debug/vars: 1 while
+// Redis answers pings, 0 otherwise
+var connected = expvar.NewInt("redis_connected")
+
+// Client tracks whether Redis is reachable
+type Client struct {
+	rdb       *redis.Client
+	logger    *slog.Logger
+	interval  time.Duration
+	timeout   time.Duration
+	available atomic.Bool
+}
+
+// NewClient wraps rdb. It starts out unavailable until the first Check.
+func NewClient(rdb *redis.Client, logger *slog.Logger) *Client {
+	return &Client{
+		rdb:      rdb,
+		logger:   logger,
+		interval: 5 * time.Second,
+		timeout:  time.Second,
+	}
+}
+
+// Redis returns the underlying client. Callers should check Available
+// first and fall back instead of waiting on a dead connection.
+func (c *Client) Redis() *redis.Client {
+	return c.rdb
+}
+
+// Available reports whether the last probe reached Redis
+func (c *Client) Available() bool {
+	return c.available.Load()
+}
+
+// Check pings Redis once, records the result and logs a change of state
+func (c *Client) Check(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	err := c.rdb.Ping(ctx).Err()
+	up := err == nil
+	was := c.available.Swap(up)
+
+	if up {
+		connected.Set(1)
+	} else {
+		connected.Set(0)
+	}
+
+	switch {
+	case up && !was:
+		c.logger.Info("redis available")
+	case !up && was:
+		c.logger.Warn("redis unreachable, using in-memory fallbacks", slog.String("error", err.Error()))
+	}
+
+	return up
+}
+
+// Run probes Redis every interval until ctx is canceled, so a lost
+// connection is noticed and a recovered one is picked up again
+func (c *Client) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.Check(ctx)
+		}
+	}
+}
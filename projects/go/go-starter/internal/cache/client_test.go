@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(t *testing.T) (*Client, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { rdb.Close() })
+	return NewClient(rdb, slog.New(slog.NewTextHandler(io.Discard, nil))), mr
+}
+
+func TestClient_Check(t *testing.T) {
+	client, mr := newTestClient(t)
+	ctx := context.Background()
+
+	assert.False(t, client.Available(), "unavailable until the first check")
+
+	assert.True(t, client.Check(ctx))
+	assert.True(t, client.Available())
+	assert.Equal(t, int64(1), connected.Value())
+
+	mr.Close()
+	assert.False(t, client.Check(ctx))
+	assert.False(t, client.Available())
+	assert.Equal(t, int64(0), connected.Value())
+
+	// Reconnects once Redis is back
+	assert.NoError(t, mr.Restart())
+	assert.True(t, client.Check(ctx))
+}
@@ -0,0 +1,180 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/yourusername/go-starter/internal/api/middleware"
+)
+
+// inFlightMarker is stored under a key while its request is running
+const inFlightMarker = "in-flight"
+
+// IdempotencyStore keeps idempotent responses in Redis so that retries are
+// recognized by any server instance
+type IdempotencyStore struct {
+	rdb     *redis.Client
+	ttl     time.Duration
+	lockTTL time.Duration
+}
+
+// NewIdempotencyStore creates a Redis-backed middleware.IdempotencyStore
+// that keeps responses for ttl. lockTTL bounds how long a request that
+// crashed holds its key; pass the server's write timeout, the longest a
+// request can take to be answered.
+func NewIdempotencyStore(rdb *redis.Client, ttl, lockTTL time.Duration) *IdempotencyStore {
+	return &IdempotencyStore{rdb: rdb, ttl: ttl, lockTTL: lockTTL}
+}
+
+// Lock implements middleware.IdempotencyStore
+func (s *IdempotencyStore) Lock(ctx context.Context, key string) (*middleware.StoredResponse, error) {
+	locked, err := s.rdb.SetNX(ctx, key, inFlightMarker, s.lockTTL).Result()
+	if err != nil {
+		return nil, fmt.Errorf("lock idempotency key: %w", err)
+	}
+	if locked {
+		return nil, nil
+	}
+
+	raw, err := s.rdb.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// Expired between SETNX and GET; treat it as still contended and let
+		// the client retry
+		return nil, middleware.ErrIdempotencyInFlight
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read idempotency key: %w", err)
+	}
+	if string(raw) == inFlightMarker {
+		return nil, middleware.ErrIdempotencyInFlight
+	}
+
+	var resp middleware.StoredResponse
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("decode idempotent response: %w", err)
+	}
+	return &resp, nil
+}
+
+// Save implements middleware.IdempotencyStore
+func (s *IdempotencyStore) Save(ctx context.Context, key string, resp *middleware.StoredResponse) error {
+	raw, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("encode idempotent response: %w", err)
+	}
+	return s.rdb.Set(ctx, key, raw, s.ttl).Err()
+}
+
+// Unlock implements middleware.IdempotencyStore
+func (s *IdempotencyStore) Unlock(ctx context.Context, key string) error {
+	return s.rdb.Del(ctx, key).Err()
+}
+
+// fallbackIdempotencyStore sends idempotency keys to Redis while it is
+// available and to process memory otherwise. Keys stored in one aren't
+// visible in the other, so a retry that straddles an outage may run twice;
+// that is the price of staying up.
+//
+// Save and Unlock go to the store that took the lock, so neither leaves an
+// in-flight entry behind in the other one.
+type fallbackIdempotencyStore struct {
+	client *Client
+	redis  middleware.IdempotencyStore
+	memory middleware.IdempotencyStore
+
+	mu sync.Mutex
+	// inRedis holds the keys locked in Redis rather than in memory, from
+	// Lock until Save or Unlock
+	inRedis map[string]bool
+}
+
+// NewFallbackIdempotencyStore creates a middleware.IdempotencyStore that
+// degrades to an in-memory store whenever client reports Redis unavailable
+// or a Redis call fails. ttl and lockTTL are as for NewIdempotencyStore.
+func NewFallbackIdempotencyStore(client *Client, ttl, lockTTL time.Duration) middleware.IdempotencyStore {
+	return &fallbackIdempotencyStore{
+		client:  client,
+		redis:   NewIdempotencyStore(client.Redis(), ttl, lockTTL),
+		memory:  middleware.NewMemoryIdempotencyStore(ttl),
+		inRedis: make(map[string]bool),
+	}
+}
+
+func (s *fallbackIdempotencyStore) Lock(ctx context.Context, key string) (*middleware.StoredResponse, error) {
+	if s.client.Available() {
+		rctx, cancel := s.redisContext(ctx)
+		resp, err := s.redis.Lock(rctx, key)
+		cancel()
+		if err == nil || errors.Is(err, middleware.ErrIdempotencyInFlight) {
+			if err == nil && resp == nil {
+				s.track(key, true)
+			}
+			return resp, err
+		}
+	}
+
+	resp, err := s.memory.Lock(ctx, key)
+	if err == nil && resp == nil {
+		s.track(key, false)
+	}
+	return resp, err
+}
+
+func (s *fallbackIdempotencyStore) Save(ctx context.Context, key string, resp *middleware.StoredResponse) error {
+	if s.release(key) && s.client.Available() {
+		rctx, cancel := s.redisContext(ctx)
+		defer cancel()
+		if err := s.redis.Save(rctx, key, resp); err == nil {
+			return nil
+		}
+		// Left in place, the in-flight marker would answer retries with
+		// 409 until it expires, so clear it on a best-effort basis
+		_ = s.redis.Unlock(rctx, key)
+	}
+	// A lock taken in Redis before it went down expires there on its own
+	return s.memory.Save(ctx, key, resp)
+}
+
+func (s *fallbackIdempotencyStore) Unlock(ctx context.Context, key string) error {
+	if !s.release(key) {
+		return s.memory.Unlock(ctx, key)
+	}
+	if !s.client.Available() {
+		return nil
+	}
+	rctx, cancel := s.redisContext(ctx)
+	defer cancel()
+	return s.redis.Unlock(rctx, key)
+}
+
+// redisContext bounds a Redis call by the client's timeout, so an outage
+// that Check hasn't noticed yet holds up a request at most that long
+func (s *fallbackIdempotencyStore) redisContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, s.client.timeout)
+}
+
+// track records which store holds key's lock
+func (s *fallbackIdempotencyStore) track(key string, inRedis bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if inRedis {
+		s.inRedis[key] = true
+	} else {
+		delete(s.inRedis, key)
+	}
+}
+
+// release forgets key's lock and reports whether it was taken in Redis
+func (s *fallbackIdempotencyStore) release(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inRedis := s.inRedis[key]
+	delete(s.inRedis, key)
+	return inRedis
+}
@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/go-starter/internal/api/middleware"
+)
+
+func TestIdempotencyStore(t *testing.T) {
+	client, mr := newTestClient(t)
+	store := NewIdempotencyStore(client.Redis(), time.Hour, 15*time.Second)
+	ctx := context.Background()
+
+	resp, err := store.Lock(ctx, "k")
+	require.NoError(t, err)
+	assert.Nil(t, resp)
+	assert.Equal(t, 15*time.Second, mr.TTL("k"), "the lock lasts as long as the write timeout")
+
+	_, err = store.Lock(ctx, "k")
+	assert.ErrorIs(t, err, middleware.ErrIdempotencyInFlight)
+
+	want := &middleware.StoredResponse{Status: http.StatusCreated, Header: http.Header{"Content-Type": {"application/json"}}, Body: []byte(`{}`), Fingerprint: "f00d"}
+	require.NoError(t, store.Save(ctx, "k", want))
+
+	got, err := store.Lock(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	require.NoError(t, store.Unlock(ctx, "k"))
+	resp, err = store.Lock(ctx, "k")
+	require.NoError(t, err)
+	assert.Nil(t, resp)
+}
+
+func TestFallbackIdempotencyStore(t *testing.T) {
+	client, mr := newTestClient(t)
+	store := NewFallbackIdempotencyStore(client, time.Hour, time.Minute)
+	ctx := context.Background()
+
+	require.True(t, client.Check(ctx))
+	_, err := store.Lock(ctx, "in-redis")
+	require.NoError(t, err)
+	assert.True(t, mr.Exists("in-redis"))
+
+	// Redis goes away: keys keep working, in memory
+	mr.Close()
+	client.Check(ctx)
+
+	_, err = store.Lock(ctx, "in-memory")
+	require.NoError(t, err)
+	_, err = store.Lock(ctx, "in-memory")
+	assert.ErrorIs(t, err, middleware.ErrIdempotencyInFlight)
+}
+
+// failingSaveStore is a Redis store whose Save fails after Lock succeeded
+type failingSaveStore struct {
+	middleware.IdempotencyStore
+}
+
+func (failingSaveStore) Save(context.Context, string, *middleware.StoredResponse) error {
+	return errors.New("connection reset")
+}
+
+func TestFallbackIdempotencyStore_ReleasesWhereLocked(t *testing.T) {
+	resp := &middleware.StoredResponse{Status: http.StatusCreated, Body: []byte(`{}`)}
+	ctx := context.Background()
+
+	t.Run("Redis save fails", func(t *testing.T) {
+		client, mr := newTestClient(t)
+		require.True(t, client.Check(ctx))
+		store := NewFallbackIdempotencyStore(client, time.Hour, time.Minute).(*fallbackIdempotencyStore)
+		store.redis = failingSaveStore{store.redis}
+
+		_, err := store.Lock(ctx, "k")
+		require.NoError(t, err)
+		require.True(t, mr.Exists("k"))
+
+		require.NoError(t, store.Save(ctx, "k", resp))
+		assert.False(t, mr.Exists("k"), "in-flight marker is cleared")
+
+		got, err := store.memory.Lock(ctx, "k")
+		require.NoError(t, err)
+		assert.Equal(t, resp, got)
+	})
+
+	t.Run("Redis down at Save is not called", func(t *testing.T) {
+		client, mr := newTestClient(t)
+		require.True(t, client.Check(ctx))
+		store := NewFallbackIdempotencyStore(client, time.Hour, time.Minute)
+
+		_, err := store.Lock(ctx, "k")
+		require.NoError(t, err)
+
+		// Reachable again, but not yet noticed by Check
+		mr.Close()
+		require.False(t, client.Check(ctx))
+		require.NoError(t, mr.Restart())
+
+		require.NoError(t, store.Save(ctx, "k", resp))
+		assert.Equal(t, inFlightMarker, mustGet(t, mr, "k"), "Redis was left alone")
+	})
+
+	t.Run("memory lock is released in memory", func(t *testing.T) {
+		client, mr := newTestClient(t)
+		mr.Close()
+		store := NewFallbackIdempotencyStore(client, time.Hour, time.Minute).(*fallbackIdempotencyStore)
+
+		for _, release := range []func(key string) error{
+			func(key string) error { return store.Save(ctx, key, resp) },
+			func(key string) error { return store.Unlock(ctx, key) },
+		} {
+			_, err := store.Lock(ctx, "k")
+			require.NoError(t, err)
+
+			// Redis comes back while the request runs
+			require.NoError(t, mr.Restart())
+			require.True(t, client.Check(ctx))
+
+			require.NoError(t, release("k"))
+			assert.False(t, mr.Exists("k"))
+			_, err = store.memory.Lock(ctx, "k")
+			assert.NotErrorIs(t, err, middleware.ErrIdempotencyInFlight, "no in-flight entry is left in memory")
+
+			require.NoError(t, store.memory.Unlock(ctx, "k"))
+			mr.Close()
+			require.False(t, client.Check(ctx))
+		}
+	})
+}
+
+func mustGet(t *testing.T, mr *miniredis.Miniredis, key string) string {
+	t.Helper()
+	v, err := mr.Get(key)
+	require.NoError(t, err)
+	return v
+}
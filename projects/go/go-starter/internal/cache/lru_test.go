@@ -0,0 +1,37 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU(t *testing.T) {
+	now := time.Now()
+	c := NewLRU[string, int](2, time.Minute)
+	c.now = func() time.Time { return now }
+
+	assert.False(t, c.Add("a", 1))
+	assert.False(t, c.Add("b", 2))
+	_, _ = c.Get("a") // a is now the most recently used
+	assert.True(t, c.Add("c", 3), "adding to a full cache evicts")
+
+	_, ok := c.Get("b")
+	assert.False(t, ok, "least recently used entry is evicted")
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	assert.Equal(t, 2, c.Len())
+
+	now = now.Add(time.Minute)
+	_, ok = c.Get("a")
+	assert.False(t, ok, "expired entries are not fresh")
+	v, ok = c.GetStale("a")
+	assert.True(t, ok, "but can still be served stale")
+	assert.Equal(t, 1, v)
+
+	c.Remove("a")
+	_, ok = c.GetStale("a")
+	assert.False(t, ok)
+}
@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/go-starter/internal/models"
+	"github.com/yourusername/go-starter/internal/repository"
+	"github.com/yourusername/go-starter/internal/service"
+)
+
+// User cache counters served on /debug/vars. Evictions are entries pushed
+// out because the cache was full; a high rate means it is too small for the
+// working set.
+var (
+	userCacheHits      = expvar.NewInt("user_cache_hits")
+	userCacheMisses    = expvar.NewInt("user_cache_misses")
+	userCacheEvictions = expvar.NewInt("user_cache_evictions")
+)
+
+// UserServiceOption configures NewCachedUserService
+type UserServiceOption func(*cachedUserService)
+
+// WithStaleOnError makes GetUser answer from an expired entry when loading
+// the user fails for infrastructure reasons (database down or overloaded),
+// trading freshness for availability. Domain errors such as "not found" are
+// always returned as is, and an entry that was invalidated is never served.
+func WithStaleOnError() UserServiceOption {
+	return func(s *cachedUserService) {
+		s.staleOnError = true
+	}
+}
+
+// cachedUserService is a read-through cache in front of a UserService.
+// Only GetUser is cached; every other method passes through to next, and
+// those that change a user invalidate its entry.
+type cachedUserService struct {
+	service.UserService
+	users        *LRU[uuid.UUID, *repository.User]
+	staleOnError bool
+	// generation is bumped by every invalidation. A load that raced with
+	// one is not cached, since it may have read the old row.
+	generation atomic.Uint64
+}
+
+// NewCachedUserService wraps next with an LRU of at most size users, each
+// served from memory for ttl. The cache is local to this process, so
+// another instance's update only shows up here once the entry expires.
+func NewCachedUserService(next service.UserService, size int, ttl time.Duration, opts ...UserServiceOption) service.UserService {
+	s := &cachedUserService{
+		UserService: next,
+		users:       NewLRU[uuid.UUID, *repository.User](size, ttl),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// GetUser serves the user from the cache, loading it on a miss
+func (s *cachedUserService) GetUser(ctx context.Context, id uuid.UUID) (*repository.User, error) {
+	if user, ok := s.users.Get(id); ok {
+		userCacheHits.Add(1)
+		return copyUser(user), nil
+	}
+	userCacheMisses.Add(1)
+
+	generation := s.generation.Load()
+	user, err := s.UserService.GetUser(ctx, id)
+	if err != nil {
+		if s.staleOnError && isInfrastructureError(err) {
+			if stale, ok := s.users.GetStale(id); ok {
+				return copyUser(stale), nil
+			}
+		}
+		return nil, err
+	}
+
+	if s.generation.Load() == generation && s.users.Add(id, copyUser(user)) {
+		userCacheEvictions.Add(1)
+	}
+	return user, nil
+}
+
+// UpdateUser updates the user and drops its entry. The entry is dropped
+// even on failure: a stale version conflict means the cached copy is old.
+func (s *cachedUserService) UpdateUser(ctx context.Context, id uuid.UUID, version int32, input service.UpdateUserInput) (*repository.User, error) {
+	defer s.invalidate(id)
+	return s.UserService.UpdateUser(ctx, id, version, input)
+}
+
+// ChangePassword changes the password and drops the user's entry
+func (s *cachedUserService) ChangePassword(ctx context.Context, id uuid.UUID, input service.ChangePasswordInput) error {
+	defer s.invalidate(id)
+	return s.UserService.ChangePassword(ctx, id, input)
+}
+
+// DeleteUser deletes the user and drops its entry
+func (s *cachedUserService) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	defer s.invalidate(id)
+	return s.UserService.DeleteUser(ctx, id)
+}
+
+func (s *cachedUserService) invalidate(id uuid.UUID) {
+	s.generation.Add(1)
+	s.users.Remove(id)
+}
+
+// isInfrastructureError reports whether err is a failure to reach the data
+// rather than an answer about it
+func isInfrastructureError(err error) bool {
+	var appErr *models.AppError
+	switch {
+	case errors.Is(err, models.ErrUnavailable):
+		// Checked first: an exhausted pool also wraps DeadlineExceeded
+		return true
+	case errors.As(err, &appErr):
+		return false
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		// The caller gave up; there is nobody to serve a stale copy to
+		return false
+	}
+	return true
+}
+
+// copyUser keeps callers from modifying the cached value
+func copyUser(user *repository.User) *repository.User {
+	clone := *user
+	return &clone
+}
@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/go-starter/internal/models"
+	"github.com/yourusername/go-starter/internal/repository"
+	"github.com/yourusername/go-starter/internal/service"
+)
+
+// countingUserService counts GetUser calls and fails them with err
+type countingUserService struct {
+	service.UserService
+	calls int
+	err   error
+}
+
+func (s *countingUserService) GetUser(_ context.Context, id uuid.UUID) (*repository.User, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &repository.User{ID: id, Name: "Jane", Version: int32(s.calls)}, nil
+}
+
+func (s *countingUserService) DeleteUser(context.Context, uuid.UUID) error {
+	return nil
+}
+
+func TestCachedUserService_GetUser(t *testing.T) {
+	next := &countingUserService{}
+	svc := NewCachedUserService(next, 10, time.Minute)
+	ctx := context.Background()
+	id := uuid.New()
+
+	hits, misses := userCacheHits.Value(), userCacheMisses.Value()
+
+	first, err := svc.GetUser(ctx, id)
+	require.NoError(t, err)
+	first.Name = "changed by caller"
+
+	second, err := svc.GetUser(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, 1, next.calls, "second read is served from the cache")
+	assert.Equal(t, "Jane", second.Name, "callers can't modify the cached copy")
+	assert.Equal(t, hits+1, userCacheHits.Value())
+	assert.Equal(t, misses+1, userCacheMisses.Value())
+
+	require.NoError(t, svc.DeleteUser(ctx, id))
+	_, err = svc.GetUser(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, 2, next.calls, "deleting invalidates the entry")
+}
+
+func TestCachedUserService_HotKeyMetrics(t *testing.T) {
+	next := &countingUserService{}
+	// Room for one user, so a second one evicts the first
+	svc := NewCachedUserService(next, 1, time.Minute)
+	ctx := context.Background()
+	hot, cold := uuid.New(), uuid.New()
+
+	hits, misses, evictions := userCacheHits.Value(), userCacheMisses.Value(), userCacheEvictions.Value()
+
+	for range 10 {
+		_, err := svc.GetUser(ctx, hot)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, hits+9, userCacheHits.Value())
+	assert.Equal(t, misses+1, userCacheMisses.Value())
+	assert.Equal(t, evictions, userCacheEvictions.Value())
+
+	_, err := svc.GetUser(ctx, cold)
+	require.NoError(t, err)
+	assert.Equal(t, evictions+1, userCacheEvictions.Value(), "the cold user pushes the hot one out")
+	assert.Equal(t, misses+2, userCacheMisses.Value())
+}
+
+func TestCachedUserService_StaleOnError(t *testing.T) {
+	notFound := models.NewAppError(http.StatusNotFound, "NOT_FOUND", "User not found", models.ErrNotFound)
+	unavailable := fmt.Errorf("get user: %w", models.ErrUnavailable)
+
+	tests := []struct {
+		name      string
+		opts      []UserServiceOption
+		err       error
+		wantStale bool
+	}{
+		{name: "disabled by default", err: unavailable},
+		{name: "database unavailable", opts: []UserServiceOption{WithStaleOnError()}, err: unavailable, wantStale: true},
+		{name: "domain errors are never hidden", opts: []UserServiceOption{WithStaleOnError()}, err: notFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &countingUserService{}
+			svc := NewCachedUserService(next, 10, time.Minute, tt.opts...).(*cachedUserService)
+			now := time.Now()
+			svc.users.now = func() time.Time { return now }
+			ctx := context.Background()
+			id := uuid.New()
+
+			_, err := svc.GetUser(ctx, id)
+			require.NoError(t, err)
+
+			now = now.Add(2 * time.Minute)
+			next.err = tt.err
+			user, err := svc.GetUser(ctx, id)
+
+			if tt.wantStale {
+				require.NoError(t, err)
+				assert.Equal(t, int32(1), user.Version)
+				return
+			}
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
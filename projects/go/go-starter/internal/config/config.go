@@ -1,9 +1,15 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"net/mail"
+	"net/netip"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -11,12 +17,62 @@ type Config struct {
 	// Server Configuration
 	ServerAddress string
 	ServerEnv     string
+	// APIVersion is reported in meta.api_version of every response
+	APIVersion string
+	// ErrorIDs gives every JSON:API error object a logged UUID id
+	ErrorIDs bool
+	// HTTP server limits. ReadHeaderTimeout and MaxHeaderBytes guard
+	// against clients that trickle or bloat request headers.
+	ServerReadTimeout       time.Duration
+	ServerReadHeaderTimeout time.Duration
+	ServerWriteTimeout      time.Duration
+	ServerIdleTimeout       time.Duration
+	ServerMaxHeaderBytes    int
+
+	// TLS certificate and key; when both are set the server speaks HTTPS
+	TLSCertFile string
+	TLSKeyFile  string
+	// HTTPRedirectAddress, with TLS enabled, is an extra plain HTTP
+	// listener that redirects everything to HTTPS (e.g. ":80")
+	HTTPRedirectAddress string
+
+	// TrustedProxies lists the CIDRs whose forwarding headers
+	// (X-Forwarded-For etc.) are believed when resolving the client IP
+	TrustedProxies []netip.Prefix
+
+	// TrailingSlash is "redirect" (308 GET/HEAD to the path without the
+	// slash, strip it for other methods) or "strip" (strip it always)
+	TrailingSlash string
+
+	// JSONAPIContentType is "relaxed" (accept the JSON:API media type with
+	// charset=utf-8 too) or "strict" (accept it only without parameters)
+	JSONAPIContentType string
+
+	// ShutdownTimeout bounds how long shutdown waits for requests to drain
+	// and background workers to stop
+	ShutdownTimeout time.Duration
+	// ShutdownDrainDelay is how long /health reports draining before the
+	// listeners close, giving load balancers time to stop sending traffic
+	ShutdownDrainDelay time.Duration
 
 	// Database Configuration
 	DatabaseURL                   string
 	DatabaseMaxConnections        int
 	DatabaseMaxIdleConnections    int
 	DatabaseConnectionMaxLifetime time.Duration
+	// DatabaseCloseTimeout bounds how long shutdown waits for queries still
+	// running when the pool is closed
+	DatabaseCloseTimeout time.Duration
+	// DBQueryTimeout bounds each query (or transaction) below the request's
+	// own deadline; 0 disables it
+	DBQueryTimeout time.Duration
+	// SlowQueryThreshold is how long a query may take before it is logged
+	// as slow; 0 disables the log
+	SlowQueryThreshold time.Duration
+	// CheckSchemaVersion makes /health report unhealthy while the schema is
+	// behind the migrations built into the binary. Turn it off when
+	// migrations are not applied with golang-migrate.
+	CheckSchemaVersion bool
 
 	// JWT Configuration
 	JWTSecret        string
@@ -26,63 +82,318 @@ type Config struct {
 	// Redis Configuration
 	RedisURL string
 
+	// SMTP server for outgoing email such as the welcome email. Without a
+	// host emails are only logged.
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+
+	// User cache: an in-process LRU in front of GetUser. Size 0 disables it.
+	UserCacheSize int
+	UserCacheTTL  time.Duration
+	// UserCacheStaleOnError serves expired entries while the database is
+	// unreachable
+	UserCacheStaleOnError bool
+
 	// Logging Configuration
 	LogLevel  string
 	LogFormat string
+	// LogRequestStart logs every request as it comes in as well as when it
+	// is done
+	LogRequestStart bool
+	// LogQuietHandlerErrors drops the handlers' own error-path logs, leaving
+	// the one "error response" line with error_code per error
+	LogQuietHandlerErrors bool
+	// LogRequestHeaders adds the request headers to the access log, with
+	// the values of LogRedactHeaders replaced by "***"
+	LogRequestHeaders bool
+	LogRedactHeaders  []string
 
 	// CORS Configuration
 	CORSAllowedOrigins []string
 	CORSAllowedMethods []string
 	CORSAllowedHeaders []string
 
-	// Rate Limiting
+	// Rate Limiting: each client IP may make RateLimitRequests API requests
+	// per RateLimitWindow, bursting up to RateLimitRequests. 0 disables it.
 	RateLimitRequests int
 	RateLimitWindow   time.Duration
+
+	// EnablePprof exposes /debug/pprof even in production
+	EnablePprof bool
+
+	// PanicDetails puts the panic message and stack into 500 responses. It
+	// must be set explicitly and is refused in production.
+	PanicDetails bool
+
+	// MaxConcurrentRequests caps API requests served at once; 0 means no cap
+	MaxConcurrentRequests int
+
+	// MaxURLLength caps the request URI in bytes; 0 means no cap
+	MaxURLLength int
+
+	// AllowedHosts lists the Host headers the API answers, e.g.
+	// "api.example.com" or "*.example.com"; empty allows any
+	AllowedHosts []string
+
+	// Warnings collects non-fatal problems found while loading, for the
+	// caller to log once a logger exists
+	Warnings []string
+}
+
+// TLSEnabled reports whether the server should serve HTTPS
+func (c *Config) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+// IsProduction reports whether SERVER_ENV is "production"
+func (c *Config) IsProduction() bool {
+	return c.ServerEnv == "production"
 }
 
+// PprofEnabled reports whether the profiling endpoints should be mounted:
+// always outside production, and in production only on explicit opt-in
+func (c *Config) PprofEnabled() bool {
+	return c.EnablePprof || !c.IsProduction()
+}
+
+// Load reads configuration from environment variables only
 func Load() (*Config, error) {
+	return load(source{})
+}
+
+// load builds a Config from src, applying defaults and validation. Every
+// entry point goes through here so validation is identical regardless of
+// where the values came from.
+func load(src source) (*Config, error) {
 	cfg := &Config{
-		ServerAddress: getEnv("SERVER_ADDRESS", ":8080"),
-		ServerEnv:     getEnv("SERVER_ENV", "development"),
+		ServerAddress: src.getEnv("SERVER_ADDRESS", ":8080"),
+		ServerEnv:     src.getEnv("SERVER_ENV", "development"),
+		APIVersion:    src.getEnv("API_VERSION", "1.0.0"),
+		ErrorIDs:      src.getEnvBool("ERROR_IDS", false),
+		TLSCertFile:   src.getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:    src.getEnv("TLS_KEY_FILE", ""),
+
+		ServerReadTimeout:       src.getEnvDuration("SERVER_READ_TIMEOUT", 15*time.Second),
+		ServerReadHeaderTimeout: src.getEnvDuration("SERVER_READ_HEADER_TIMEOUT", 5*time.Second),
+		ServerWriteTimeout:      src.getEnvDuration("SERVER_WRITE_TIMEOUT", 15*time.Second),
+		ServerIdleTimeout:       src.getEnvDuration("SERVER_IDLE_TIMEOUT", 60*time.Second),
+		ServerMaxHeaderBytes:    src.getEnvInt("SERVER_MAX_HEADER_BYTES", 1<<20),
+
+		HTTPRedirectAddress: src.getEnv("HTTP_REDIRECT_ADDRESS", ""),
+
+		TrailingSlash:      src.getEnv("TRAILING_SLASH", "redirect"),
+		JSONAPIContentType: src.getEnv("JSONAPI_CONTENT_TYPE", "relaxed"),
+		ShutdownTimeout:    src.getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+		ShutdownDrainDelay: src.getEnvDuration("SHUTDOWN_DRAIN_DELAY", 0),
+
+		DatabaseURL:                   src.getEnv("DATABASE_URL", ""),
+		DatabaseMaxConnections:        src.getEnvInt("DATABASE_MAX_CONNECTIONS", 25),
+		DatabaseMaxIdleConnections:    src.getEnvInt("DATABASE_MAX_IDLE_CONNECTIONS", 10),
+		DatabaseConnectionMaxLifetime: src.getEnvDuration("DATABASE_CONNECTION_MAX_LIFETIME", 5*time.Minute),
+		DatabaseCloseTimeout:          src.getEnvDuration("DATABASE_CLOSE_TIMEOUT", 5*time.Second),
+		DBQueryTimeout:                src.getEnvDuration("DB_QUERY_TIMEOUT", 5*time.Second),
+		SlowQueryThreshold:            src.getEnvDuration("SLOW_QUERY_THRESHOLD", 200*time.Millisecond),
+		CheckSchemaVersion:            src.getEnvBool("CHECK_SCHEMA_VERSION", true),
+
+		JWTSecret:        src.getEnv("JWT_SECRET", ""),
+		JWTExpiry:        src.getEnvDuration("JWT_EXPIRY", 24*time.Hour),
+		JWTRefreshExpiry: src.getEnvDuration("JWT_REFRESH_EXPIRY", 168*time.Hour),
+
+		RedisURL: src.getEnv("REDIS_URL", ""),
+
+		SMTPHost:     src.getEnv("SMTP_HOST", ""),
+		SMTPPort:     src.getEnvInt("SMTP_PORT", 587),
+		SMTPUsername: src.getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: src.getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:     src.getEnv("SMTP_FROM", ""),
 
-		DatabaseURL:                   getEnv("DATABASE_URL", ""),
-		DatabaseMaxConnections:        getEnvInt("DATABASE_MAX_CONNECTIONS", 25),
-		DatabaseMaxIdleConnections:    getEnvInt("DATABASE_MAX_IDLE_CONNECTIONS", 10),
-		DatabaseConnectionMaxLifetime: getEnvDuration("DATABASE_CONNECTION_MAX_LIFETIME", 5*time.Minute),
+		UserCacheSize:         src.getEnvInt("USER_CACHE_SIZE", 1000),
+		UserCacheTTL:          src.getEnvDuration("USER_CACHE_TTL", 30*time.Second),
+		UserCacheStaleOnError: src.getEnvBool("USER_CACHE_STALE_ON_ERROR", false),
 
-		JWTSecret:        getEnv("JWT_SECRET", ""),
-		JWTExpiry:        getEnvDuration("JWT_EXPIRY", 24*time.Hour),
-		JWTRefreshExpiry: getEnvDuration("JWT_REFRESH_EXPIRY", 168*time.Hour),
+		LogLevel:        src.getEnv("LOG_LEVEL", "info"),
+		LogFormat:       src.getEnv("LOG_FORMAT", "json"),
+		LogRequestStart: src.getEnvBool("LOG_REQUEST_START", false),
 
-		RedisURL: getEnv("REDIS_URL", ""),
+		LogQuietHandlerErrors: src.getEnvBool("LOG_QUIET_HANDLER_ERRORS", false),
 
-		LogLevel:  getEnv("LOG_LEVEL", "info"),
-		LogFormat: getEnv("LOG_FORMAT", "json"),
+		LogRequestHeaders: src.getEnvBool("LOG_REQUEST_HEADERS", false),
+		LogRedactHeaders:  splitList(src.getEnv("LOG_REDACT_HEADERS", defaultRedactHeaders)),
 
-		RateLimitRequests: getEnvInt("RATE_LIMIT_REQUESTS", 100),
-		RateLimitWindow:   getEnvDuration("RATE_LIMIT_WINDOW", time.Minute),
+		RateLimitRequests: src.getEnvInt("RATE_LIMIT_REQUESTS", 0),
+		RateLimitWindow:   src.getEnvDuration("RATE_LIMIT_WINDOW", time.Minute),
+
+		MaxConcurrentRequests: src.getEnvInt("MAX_CONCURRENT_REQUESTS", 0),
+		MaxURLLength:          src.getEnvInt("MAX_URL_LENGTH", 8192),
+		AllowedHosts:          splitList(src.getEnv("ALLOWED_HOSTS", "")),
+
+		EnablePprof:  src.getEnvBool("ENABLE_PPROF", false),
+		PanicDetails: src.getEnvBool("PANIC_DETAILS", false),
+	}
+
+	if dsn, ok := src.databaseURLFromParts(); ok {
+		if cfg.DatabaseURL == "" {
+			cfg.DatabaseURL = dsn
+		} else {
+			cfg.Warnings = append(cfg.Warnings, "both DATABASE_URL and DB_* variables are set; using DATABASE_URL")
+		}
+	}
+
+	trustedProxies, err := parsePrefixes(src.getEnv("TRUSTED_PROXIES", ""))
+	if err != nil {
+		return nil, fmt.Errorf("TRUSTED_PROXIES: %w", err)
 	}
+	cfg.TrustedProxies = trustedProxies
 
 	// Validate required fields
 	if cfg.DatabaseURL == "" {
-		return nil, fmt.Errorf("DATABASE_URL is required")
+		return nil, fmt.Errorf("DATABASE_URL (or DB_HOST) is required")
 	}
 	if cfg.JWTSecret == "" {
 		return nil, fmt.Errorf("JWT_SECRET is required")
 	}
+	if err := cfg.validateJWT(); err != nil {
+		return nil, err
+	}
+	if err := cfg.validateServer(); err != nil {
+		return nil, err
+	}
+	if cfg.PanicDetails && cfg.IsProduction() {
+		return nil, fmt.Errorf("PANIC_DETAILS must not be enabled in production")
+	}
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return nil, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if cfg.HTTPRedirectAddress != "" && !cfg.TLSEnabled() {
+		return nil, fmt.Errorf("HTTP_REDIRECT_ADDRESS requires TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+	if cfg.TrailingSlash != "redirect" && cfg.TrailingSlash != "strip" {
+		return nil, fmt.Errorf("TRAILING_SLASH must be redirect or strip, got %q", cfg.TrailingSlash)
+	}
+	if cfg.JSONAPIContentType != "relaxed" && cfg.JSONAPIContentType != "strict" {
+		return nil, fmt.Errorf("JSONAPI_CONTENT_TYPE must be relaxed or strict, got %q", cfg.JSONAPIContentType)
+	}
+	if cfg.ShutdownTimeout <= 0 {
+		return nil, fmt.Errorf("SHUTDOWN_TIMEOUT must be positive")
+	}
+	if cfg.DatabaseCloseTimeout <= 0 {
+		return nil, fmt.Errorf("DATABASE_CLOSE_TIMEOUT must be positive")
+	}
+	if cfg.ShutdownDrainDelay < 0 {
+		return nil, fmt.Errorf("SHUTDOWN_DRAIN_DELAY must not be negative")
+	}
+	if cfg.DBQueryTimeout < 0 {
+		return nil, fmt.Errorf("DB_QUERY_TIMEOUT must not be negative")
+	}
+	if cfg.SlowQueryThreshold < 0 {
+		return nil, fmt.Errorf("SLOW_QUERY_THRESHOLD must not be negative")
+	}
+	if cfg.MaxConcurrentRequests < 0 {
+		return nil, fmt.Errorf("MAX_CONCURRENT_REQUESTS must not be negative")
+	}
+	if cfg.RateLimitRequests < 0 {
+		return nil, fmt.Errorf("RATE_LIMIT_REQUESTS must not be negative")
+	}
+	if cfg.RateLimitRequests > 0 && cfg.RateLimitWindow <= 0 {
+		return nil, fmt.Errorf("RATE_LIMIT_WINDOW must be positive when rate limiting is enabled")
+	}
+	if cfg.MaxURLLength < 0 {
+		return nil, fmt.Errorf("MAX_URL_LENGTH must not be negative")
+	}
+	for _, host := range cfg.AllowedHosts {
+		if strings.Contains(strings.TrimPrefix(host, "*."), "*") {
+			return nil, fmt.Errorf("ALLOWED_HOSTS: %q may only use a wildcard as a leading \"*.\"", host)
+		}
+	}
+	if cfg.SMTPHost != "" {
+		if cfg.SMTPPort < 1 || cfg.SMTPPort > 65535 {
+			return nil, fmt.Errorf("SMTP_PORT must be between 1 and 65535, got %d", cfg.SMTPPort)
+		}
+		if _, err := mail.ParseAddress(cfg.SMTPFrom); err != nil {
+			return nil, fmt.Errorf("SMTP_FROM must be an email address when SMTP_HOST is set: %w", err)
+		}
+	}
+	if cfg.UserCacheSize < 0 {
+		return nil, fmt.Errorf("USER_CACHE_SIZE must not be negative")
+	}
+	if cfg.UserCacheSize > 0 && cfg.UserCacheTTL <= 0 {
+		return nil, fmt.Errorf("USER_CACHE_TTL must be positive when the user cache is enabled")
+	}
 
 	return cfg, nil
 }
 
-func getEnv(key, defaultValue string) string {
+// validateServer checks the HTTP server limits. Every problem found is
+// reported, not just the first.
+func (c *Config) validateServer() error {
+	var errs []error
+	if c.ServerReadTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("SERVER_READ_TIMEOUT must be positive, got %s", c.ServerReadTimeout))
+	}
+	if c.ServerWriteTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("SERVER_WRITE_TIMEOUT must be positive, got %s", c.ServerWriteTimeout))
+	}
+	if c.ServerReadHeaderTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("SERVER_READ_HEADER_TIMEOUT must be positive, got %s", c.ServerReadHeaderTimeout))
+	}
+	if c.ServerIdleTimeout < 0 {
+		errs = append(errs, fmt.Errorf("SERVER_IDLE_TIMEOUT must not be negative, got %s", c.ServerIdleTimeout))
+	}
+	if c.ServerMaxHeaderBytes <= 0 {
+		errs = append(errs, fmt.Errorf("SERVER_MAX_HEADER_BYTES must be positive, got %d", c.ServerMaxHeaderBytes))
+	}
+	return errors.Join(errs...)
+}
+
+// minJWTSecretLength is the shortest JWT_SECRET accepted in production:
+// HS256 needs a key at least as long as its 256-bit output
+const minJWTSecretLength = 32
+
+// validateJWT checks that the token lifetimes make sense together and that
+// the signing secret is strong enough for production. Every problem found
+// is reported, not just the first.
+func (c *Config) validateJWT() error {
+	var errs []error
+	if c.JWTExpiry <= 0 {
+		errs = append(errs, fmt.Errorf("JWT_EXPIRY must be positive, got %s", c.JWTExpiry))
+	}
+	if c.JWTRefreshExpiry <= 0 {
+		errs = append(errs, fmt.Errorf("JWT_REFRESH_EXPIRY must be positive, got %s", c.JWTRefreshExpiry))
+	}
+	if c.JWTExpiry > 0 && c.JWTRefreshExpiry > 0 && c.JWTExpiry >= c.JWTRefreshExpiry {
+		errs = append(errs, fmt.Errorf("JWT_EXPIRY (%s) must be shorter than JWT_REFRESH_EXPIRY (%s)", c.JWTExpiry, c.JWTRefreshExpiry))
+	}
+	if c.IsProduction() && len(c.JWTSecret) < minJWTSecretLength {
+		errs = append(errs, fmt.Errorf("JWT_SECRET must be at least %d bytes in production", minJWTSecretLength))
+	}
+	return errors.Join(errs...)
+}
+
+// source resolves configuration keys. Environment variables always win over
+// values read from a config file.
+type source struct {
+	file map[string]string
+}
+
+func (s source) lookup(key string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
+	return s.file[key]
+}
+
+func (s source) getEnv(key, defaultValue string) string {
+	if value := s.lookup(key); value != "" {
+		return value
+	}
 	return defaultValue
 }
 
-func getEnvInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
+func (s source) getEnvInt(key string, defaultValue int) int {
+	if value := s.lookup(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
 			return intValue
 		}
@@ -90,11 +401,94 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
-func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
+func (s source) getEnvBool(key string, defaultValue bool) bool {
+	if value := s.lookup(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+func (s source) getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := s.lookup(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
 			return duration
 		}
 	}
 	return defaultValue
 }
+
+// databaseURLFromParts assembles a DSN from DB_HOST, DB_PORT, DB_USER,
+// DB_PASSWORD, DB_NAME and DB_SSLMODE for platforms that hand out discrete
+// connection settings. It reports false when DB_HOST is not set.
+func (s source) databaseURLFromParts() (string, bool) {
+	host := s.getEnv("DB_HOST", "")
+	if host == "" {
+		return "", false
+	}
+
+	dsn := url.URL{
+		Scheme: "postgres",
+		Host:   net.JoinHostPort(host, s.getEnv("DB_PORT", "5432")),
+		Path:   "/" + s.getEnv("DB_NAME", ""),
+	}
+
+	// url.UserPassword percent-encodes, so passwords containing @, : or /
+	// don't corrupt the URL
+	if user := s.getEnv("DB_USER", ""); user != "" {
+		if password := s.getEnv("DB_PASSWORD", ""); password != "" {
+			dsn.User = url.UserPassword(user, password)
+		} else {
+			dsn.User = url.User(user)
+		}
+	}
+
+	if sslMode := s.getEnv("DB_SSLMODE", ""); sslMode != "" {
+		dsn.RawQuery = url.Values{"sslmode": {sslMode}}.Encode()
+	}
+
+	return dsn.String(), true
+}
+
+// defaultRedactHeaders are the request headers carrying credentials
+const defaultRedactHeaders = "Authorization,Proxy-Authorization,Cookie,X-Api-Key,X-Auth-Token"
+
+// splitList splits a comma-separated list, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parsePrefixes parses a comma-separated list of CIDRs. Bare addresses are
+// accepted and treated as single-host prefixes.
+func parsePrefixes(value string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		if !strings.Contains(item, "/") {
+			addr, err := netip.ParseAddr(item)
+			if err != nil {
+				return nil, fmt.Errorf("invalid address %q: %w", item, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", item, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
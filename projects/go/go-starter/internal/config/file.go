@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadFromFile reads configuration from a YAML (.yaml, .yml) or TOML (.toml)
+// file, with environment variables taking precedence over file values.
+//
+// Keys mirror the environment variable names, case-insensitively, and nested
+// tables are joined with underscores, so these are equivalent:
+//
+//	database_url: postgres://...
+//	database:
+//	  url: postgres://...
+//
+// Lists are joined with commas, matching how list env vars are written.
+// Secrets such as JWT_SECRET can stay in the environment while the rest of
+// the configuration is checked in.
+func LoadFromFile(path string) (*Config, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	var doc map[string]interface{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(raw, &doc)
+	case ".toml":
+		err = toml.Unmarshal(raw, &doc)
+	default:
+		return nil, fmt.Errorf("unsupported config file extension %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	flatten("", doc, values)
+
+	return load(source{file: values})
+}
+
+// flatten converts a decoded document into ENV_STYLE keys
+func flatten(prefix string, node map[string]interface{}, out map[string]string) {
+	for key, value := range node {
+		name := strings.ToUpper(key)
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flatten(name, v, out)
+		case []interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			out[name] = strings.Join(items, ",")
+		case nil:
+			// An explicit null leaves the default in place
+		default:
+			out[name] = fmt.Sprint(v)
+		}
+	}
+}
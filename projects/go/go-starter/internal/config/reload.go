@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// LiveSettings are the settings a running server applies on reload.
+// Everything else only takes effect on restart.
+var LiveSettings = []string{"LOG_LEVEL"}
+
+// setting names a Config field by its environment variable
+type setting struct {
+	name  string
+	value func(*Config) string
+}
+
+// settings lists every Config field that Diff compares
+var settings = []setting{
+	{"SERVER_ADDRESS", func(c *Config) string { return c.ServerAddress }},
+	{"SERVER_ENV", func(c *Config) string { return c.ServerEnv }},
+	{"API_VERSION", func(c *Config) string { return c.APIVersion }},
+	{"ERROR_IDS", func(c *Config) string { return fmt.Sprint(c.ErrorIDs) }},
+	{"SERVER_READ_TIMEOUT", func(c *Config) string { return c.ServerReadTimeout.String() }},
+	{"SERVER_READ_HEADER_TIMEOUT", func(c *Config) string { return c.ServerReadHeaderTimeout.String() }},
+	{"SERVER_WRITE_TIMEOUT", func(c *Config) string { return c.ServerWriteTimeout.String() }},
+	{"SERVER_IDLE_TIMEOUT", func(c *Config) string { return c.ServerIdleTimeout.String() }},
+	{"SERVER_MAX_HEADER_BYTES", func(c *Config) string { return fmt.Sprint(c.ServerMaxHeaderBytes) }},
+	{"TLS_CERT_FILE", func(c *Config) string { return c.TLSCertFile }},
+	{"TLS_KEY_FILE", func(c *Config) string { return c.TLSKeyFile }},
+	{"HTTP_REDIRECT_ADDRESS", func(c *Config) string { return c.HTTPRedirectAddress }},
+	{"TRAILING_SLASH", func(c *Config) string { return c.TrailingSlash }},
+	{"JSONAPI_CONTENT_TYPE", func(c *Config) string { return c.JSONAPIContentType }},
+	{"SHUTDOWN_TIMEOUT", func(c *Config) string { return c.ShutdownTimeout.String() }},
+	{"SHUTDOWN_DRAIN_DELAY", func(c *Config) string { return c.ShutdownDrainDelay.String() }},
+	{"TRUSTED_PROXIES", func(c *Config) string { return fmt.Sprint(c.TrustedProxies) }},
+	{"DATABASE_URL", func(c *Config) string { return c.DatabaseURL }},
+	{"DATABASE_MAX_CONNECTIONS", func(c *Config) string { return fmt.Sprint(c.DatabaseMaxConnections) }},
+	{"DATABASE_MAX_IDLE_CONNECTIONS", func(c *Config) string { return fmt.Sprint(c.DatabaseMaxIdleConnections) }},
+	{"DATABASE_CONNECTION_MAX_LIFETIME", func(c *Config) string { return c.DatabaseConnectionMaxLifetime.String() }},
+	{"DATABASE_CLOSE_TIMEOUT", func(c *Config) string { return c.DatabaseCloseTimeout.String() }},
+	{"DB_QUERY_TIMEOUT", func(c *Config) string { return c.DBQueryTimeout.String() }},
+	{"SLOW_QUERY_THRESHOLD", func(c *Config) string { return c.SlowQueryThreshold.String() }},
+	{"CHECK_SCHEMA_VERSION", func(c *Config) string { return fmt.Sprint(c.CheckSchemaVersion) }},
+	{"JWT_SECRET", func(c *Config) string { return c.JWTSecret }},
+	{"JWT_EXPIRY", func(c *Config) string { return c.JWTExpiry.String() }},
+	{"JWT_REFRESH_EXPIRY", func(c *Config) string { return c.JWTRefreshExpiry.String() }},
+	{"REDIS_URL", func(c *Config) string { return c.RedisURL }},
+	{"SMTP_HOST", func(c *Config) string { return c.SMTPHost }},
+	{"SMTP_PORT", func(c *Config) string { return fmt.Sprint(c.SMTPPort) }},
+	{"SMTP_USERNAME", func(c *Config) string { return c.SMTPUsername }},
+	{"SMTP_PASSWORD", func(c *Config) string { return c.SMTPPassword }},
+	{"SMTP_FROM", func(c *Config) string { return c.SMTPFrom }},
+	{"USER_CACHE_SIZE", func(c *Config) string { return fmt.Sprint(c.UserCacheSize) }},
+	{"USER_CACHE_TTL", func(c *Config) string { return c.UserCacheTTL.String() }},
+	{"USER_CACHE_STALE_ON_ERROR", func(c *Config) string { return fmt.Sprint(c.UserCacheStaleOnError) }},
+	{"LOG_LEVEL", func(c *Config) string { return c.LogLevel }},
+	{"LOG_FORMAT", func(c *Config) string { return c.LogFormat }},
+	{"LOG_REQUEST_START", func(c *Config) string { return fmt.Sprint(c.LogRequestStart) }},
+	{"LOG_QUIET_HANDLER_ERRORS", func(c *Config) string { return fmt.Sprint(c.LogQuietHandlerErrors) }},
+	{"LOG_REQUEST_HEADERS", func(c *Config) string { return fmt.Sprint(c.LogRequestHeaders) }},
+	{"LOG_REDACT_HEADERS", func(c *Config) string { return strings.Join(c.LogRedactHeaders, ",") }},
+	{"CORS_ALLOWED_ORIGINS", func(c *Config) string { return strings.Join(c.CORSAllowedOrigins, ",") }},
+	{"CORS_ALLOWED_METHODS", func(c *Config) string { return strings.Join(c.CORSAllowedMethods, ",") }},
+	{"CORS_ALLOWED_HEADERS", func(c *Config) string { return strings.Join(c.CORSAllowedHeaders, ",") }},
+	{"RATE_LIMIT_REQUESTS", func(c *Config) string { return fmt.Sprint(c.RateLimitRequests) }},
+	{"RATE_LIMIT_WINDOW", func(c *Config) string { return c.RateLimitWindow.String() }},
+	{"ENABLE_PPROF", func(c *Config) string { return fmt.Sprint(c.EnablePprof) }},
+	{"PANIC_DETAILS", func(c *Config) string { return fmt.Sprint(c.PanicDetails) }},
+	{"MAX_CONCURRENT_REQUESTS", func(c *Config) string { return fmt.Sprint(c.MaxConcurrentRequests) }},
+	{"MAX_URL_LENGTH", func(c *Config) string { return fmt.Sprint(c.MaxURLLength) }},
+	{"ALLOWED_HOSTS", func(c *Config) string { return strings.Join(c.AllowedHosts, ",") }},
+}
+
+// Diff returns the names of the settings whose values differ between c and
+// other. Only names are returned so that secrets never end up in logs.
+func (c *Config) Diff(other *Config) []string {
+	var changed []string
+	for _, s := range settings {
+		if s.value(c) != s.value(other) {
+			changed = append(changed, s.name)
+		}
+	}
+	return changed
+}
+
+// SlogLevel converts LogLevel to a slog.Level, defaulting to info
+func (c *Config) SlogLevel() slog.Level {
+	switch strings.ToLower(c.LogLevel) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
@@ -0,0 +1,20 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// uniqueViolationCode is the Postgres SQLSTATE for unique_violation
+const uniqueViolationCode = "23505"
+
+// UsersEmailKey is the unique constraint Postgres names for users.email
+const UsersEmailKey = "users_email_key"
+
+// IsUniqueViolation reports whether err is a unique_violation of the named
+// constraint, so callers can map each constraint to its own domain error
+func IsUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode && pgErr.ConstraintName == constraint
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsUniqueViolation(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "matching constraint",
+			err:  &pgconn.PgError{Code: "23505", ConstraintName: UsersEmailKey},
+			want: true,
+		},
+		{
+			name: "wrapped",
+			err:  fmt.Errorf("create user: %w", &pgconn.PgError{Code: "23505", ConstraintName: UsersEmailKey}),
+			want: true,
+		},
+		{
+			name: "other constraint",
+			err:  &pgconn.PgError{Code: "23505", ConstraintName: "users_pkey"},
+		},
+		{
+			name: "other code",
+			err:  &pgconn.PgError{Code: "23503", ConstraintName: UsersEmailKey},
+		},
+		{
+			name: "not a postgres error",
+			err:  fmt.Errorf("boom"),
+		},
+		{
+			name: "nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsUniqueViolation(tt.err, UsersEmailKey))
+		})
+	}
+}
@@ -8,11 +8,45 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-type User struct {
-	ID           pgtype.UUID        `json:"id"`
-	Email        string             `json:"email"`
-	Name         string             `json:"name"`
-	PasswordHash string             `json:"password_hash"`
+type AuditLog struct {
+	ID           int64              `json:"id"`
+	Action       string             `json:"action"`
+	ResourceType string             `json:"resource_type"`
+	ResourceID   string             `json:"resource_id"`
+	ActorID      *string            `json:"actor_id"`
+	RequestID    *string            `json:"request_id"`
 	CreatedAt    pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
+}
+
+type Outbox struct {
+	ID          int64              `json:"id"`
+	EventType   string             `json:"event_type"`
+	AggregateID pgtype.UUID        `json:"aggregate_id"`
+	Payload     []byte             `json:"payload"`
+	Attempts    int32              `json:"attempts"`
+	LastError   *string            `json:"last_error"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	LockedUntil pgtype.Timestamptz `json:"locked_until"`
+	PublishedAt pgtype.Timestamptz `json:"published_at"`
+}
+
+type Profile struct {
+	ID        pgtype.UUID        `json:"id"`
+	UserID    pgtype.UUID        `json:"user_id"`
+	Bio       string             `json:"bio"`
+	AvatarUrl *string            `json:"avatar_url"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type User struct {
+	ID                pgtype.UUID        `json:"id"`
+	Email             string             `json:"email"`
+	Name              string             `json:"name"`
+	PasswordHash      string             `json:"password_hash"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
+	Version           int32              `json:"version"`
+	Role              string             `json:"role"`
+	PasswordChangedAt pgtype.Timestamptz `json:"password_changed_at"`
 }
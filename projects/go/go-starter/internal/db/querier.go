@@ -11,13 +11,29 @@ import (
 )
 
 type Querier interface {
-	CountUsers(ctx context.Context) (int64, error)
+	// Leases a batch of undelivered events so that concurrent pollers don't
+	// pick up the same rows; a lease that runs out makes the row claimable
+	// again. Rows that failed max_attempts times are left for an operator.
+	ClaimOutboxEvents(ctx context.Context, arg ClaimOutboxEventsParams) ([]Outbox, error)
+	CountUsers(ctx context.Context, arg CountUsersParams) (int64, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
-	DeleteUser(ctx context.Context, id pgtype.UUID) error
+	DeleteUser(ctx context.Context, id pgtype.UUID) (int64, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserByID(ctx context.Context, id pgtype.UUID) (User, error)
-	ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error)
+	// The profile columns are all NULL when the user has no profile
+	GetUserWithProfile(ctx context.Context, id pgtype.UUID) (GetUserWithProfileRow, error)
+	// Missing ids are simply absent from the result; order is unspecified
+	GetUsersByIDs(ctx context.Context, ids []pgtype.UUID) ([]User, error)
+	InsertAuditEntry(ctx context.Context, arg InsertAuditEntryParams) error
+	InsertOutboxEvent(ctx context.Context, arg InsertOutboxEventParams) error
+	// Newest first; id breaks ties between entries of the same transaction
+	ListAuditEntries(ctx context.Context, arg ListAuditEntriesParams) ([]AuditLog, error)
+	MarkOutboxEventPublished(ctx context.Context, id int64) error
+	RecordOutboxEventFailure(ctx context.Context, arg RecordOutboxEventFailureParams) error
+	// The expression matches idx_users_search; keep them in sync
+	SearchUsers(ctx context.Context, arg SearchUsersParams) ([]SearchUsersRow, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// RetryPolicy controls how Retry backs off between attempts
+type RetryPolicy struct {
+	// MaxAttempts is the total number of calls, including the first
+	MaxAttempts int
+	// BaseDelay is the wait before the second attempt; it doubles after
+	// every further failure up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// DefaultRetryPolicy rides out a primary failover without holding a request
+// for more than a few hundred milliseconds
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   50 * time.Millisecond,
+	MaxDelay:    time.Second,
+}
+
+// transientCodes are the SQLSTATEs worth retrying: the server is going away
+// or refused the statement for reasons unrelated to its content
+var transientCodes = map[string]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+	"57P01": true, // admin_shutdown
+	"57P02": true, // crash_shutdown
+	"57P03": true, // cannot_connect_now
+}
+
+// IsTransient reports whether err is a failure that may succeed when the
+// same query is run again. Constraint violations, bad SQL and context
+// errors are never transient.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// Class 08 is connection_exception
+		return transientCodes[pgErr.Code] || strings.HasPrefix(pgErr.Code, "08")
+	}
+
+	var connectErr *pgconn.ConnectError
+	switch {
+	case errors.As(err, &connectErr):
+		return true
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, io.ErrUnexpectedEOF):
+		return true
+	}
+
+	// pgx knows when a failure happened before anything reached the server
+	return pgconn.SafeToRetry(err)
+}
+
+// Retry calls fn until it succeeds, returns a non-transient error, or the
+// policy runs out of attempts. It never sleeps past ctx's deadline: when
+// the next wait would overrun it, the last error is returned right away.
+func Retry[T any](ctx context.Context, policy RetryPolicy, fn func(context.Context) (T, error)) (T, error) {
+	delay := policy.BaseDelay
+
+	for attempt := 1; ; attempt++ {
+		result, err := fn(ctx)
+		if err == nil || attempt >= policy.MaxAttempts || !IsTransient(err) {
+			return result, err
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return result, err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, err
+		case <-timer.C:
+		}
+
+		delay = min(delay*2, policy.MaxDelay)
+	}
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+var testPolicy = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "admin shutdown during failover", err: &pgconn.PgError{Code: "57P01"}, want: true},
+		{name: "connection failure", err: &pgconn.PgError{Code: "08006"}, want: true},
+		{name: "serialization failure", err: fmt.Errorf("get user: %w", &pgconn.PgError{Code: "40001"}), want: true},
+		{name: "unique violation", err: &pgconn.PgError{Code: "23505"}},
+		{name: "syntax error", err: &pgconn.PgError{Code: "42601"}},
+		{name: "context canceled", err: context.Canceled},
+		{name: "plain error", err: errors.New("boom")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsTransient(tt.err))
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	transient := &pgconn.PgError{Code: "57P01"}
+
+	tests := []struct {
+		name      string
+		failures  []error
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "recovers after transient failures", failures: []error{transient, transient}, wantCalls: 3},
+		{name: "gives up after max attempts", failures: []error{transient, transient, transient}, wantCalls: 3, wantErr: transient},
+		{name: "constraint violation is not retried", failures: []error{&pgconn.PgError{Code: "23505"}}, wantCalls: 1, wantErr: &pgconn.PgError{Code: "23505"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			got, err := Retry(context.Background(), testPolicy, func(context.Context) (int, error) {
+				calls++
+				if calls <= len(tt.failures) {
+					return 0, tt.failures[calls-1]
+				}
+				return 42, nil
+			})
+
+			assert.Equal(t, tt.wantCalls, calls)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 42, got)
+		})
+	}
+}
+
+func TestRetry_RespectsDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: time.Second}
+	calls := 0
+	start := time.Now()
+	_, err := Retry(ctx, policy, func(context.Context) (int, error) {
+		calls++
+		return 0, &pgconn.PgError{Code: "57P01"}
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+	assert.Less(t, time.Since(start), time.Second)
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// undefinedTableCode is the Postgres SQLSTATE for undefined_table
+const undefinedTableCode = "42P01"
+
+// ErrSchemaOutdated means the database schema is behind the code, or a
+// migration failed halfway
+var ErrSchemaOutdated = errors.New("database schema is not up to date")
+
+// SchemaVersion reads the migration version golang-migrate recorded in
+// schema_migrations, and whether that migration failed halfway (dirty). A
+// database that was never migrated is at version 0.
+func SchemaVersion(ctx context.Context, conn DBTX) (version int64, dirty bool, err error) {
+	err = conn.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return 0, false, nil
+	case errors.As(err, &pgErr) && pgErr.Code == undefinedTableCode:
+		return 0, false, nil
+	case err != nil:
+		return 0, false, fmt.Errorf("read schema version: %w", err)
+	}
+	return version, dirty, nil
+}
+
+// CheckSchemaVersion returns ErrSchemaOutdated unless the schema is at
+// version want or newer and clean. A newer schema is accepted, since during
+// a rolling deploy the previous binary keeps serving after the migration.
+func CheckSchemaVersion(ctx context.Context, conn DBTX, want int64) error {
+	version, dirty, err := SchemaVersion(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("%w: migration %d failed and is marked dirty", ErrSchemaOutdated, version)
+	}
+	if version < want {
+		return fmt.Errorf("%w: at version %d, want %d", ErrSchemaOutdated, version, want)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// schemaRow answers the schema_migrations query
+type schemaRow struct {
+	version int64
+	dirty   bool
+	err     error
+}
+
+func (r schemaRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	*dest[0].(*int64) = r.version
+	*dest[1].(*bool) = r.dirty
+	return nil
+}
+
+// schemaDB is a DBTX whose QueryRow always returns row
+type schemaDB struct {
+	DBTX
+	row schemaRow
+}
+
+func (d schemaDB) QueryRow(context.Context, string, ...interface{}) pgx.Row {
+	return d.row
+}
+
+func TestCheckSchemaVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		row     schemaRow
+		wantErr error
+	}{
+		{name: "current", row: schemaRow{version: 8}},
+		{name: "newer", row: schemaRow{version: 9}},
+		{name: "pending", row: schemaRow{version: 7}, wantErr: ErrSchemaOutdated},
+		{name: "dirty", row: schemaRow{version: 8, dirty: true}, wantErr: ErrSchemaOutdated},
+		{name: "no rows", row: schemaRow{err: pgx.ErrNoRows}, wantErr: ErrSchemaOutdated},
+		{name: "never migrated", row: schemaRow{err: &pgconn.PgError{Code: "42P01"}}, wantErr: ErrSchemaOutdated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckSchemaVersion(context.Background(), schemaDB{row: tt.row}, 8)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+
+	t.Run("query error", func(t *testing.T) {
+		boom := errors.New("connection refused")
+		err := CheckSchemaVersion(context.Background(), schemaDB{row: schemaRow{err: boom}}, 8)
+		require.ErrorIs(t, err, boom)
+		assert.NotErrorIs(t, err, ErrSchemaOutdated)
+	})
+}
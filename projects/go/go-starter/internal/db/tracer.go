@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SlowQueryTracer is a pgx.QueryTracer that logs a warning for every query
+// taking threshold or longer. The warning is logged with the query's
+// context, so a request-aware logger correlates it with the request.
+type SlowQueryTracer struct {
+	threshold time.Duration
+	logger    *slog.Logger
+	now       func() time.Time
+}
+
+// NewSlowQueryTracer creates a SlowQueryTracer. Install it as the Tracer of
+// the pool's ConnConfig.
+func NewSlowQueryTracer(threshold time.Duration, logger *slog.Logger) *SlowQueryTracer {
+	return &SlowQueryTracer{
+		threshold: threshold,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+type queryStartKey struct{}
+
+// queryStart is what TraceQueryStart hands to TraceQueryEnd
+type queryStart struct {
+	sql string
+	at  time.Time
+}
+
+// TraceQueryStart records when the query was sent
+func (t *SlowQueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryStartKey{}, queryStart{sql: data.SQL, at: t.now()})
+}
+
+// TraceQueryEnd logs the query if it was slow
+func (t *SlowQueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+
+	elapsed := t.now().Sub(start.at)
+	if elapsed < t.threshold {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("query", QueryName(start.sql)),
+		slog.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+		slog.Duration("threshold", t.threshold),
+	}
+	if data.Err != nil {
+		attrs = append(attrs, slog.String("error", data.Err.Error()))
+	}
+	t.logger.LogAttrs(ctx, slog.LevelWarn, "slow query", attrs...)
+}
+
+// QueryName returns the name from a "-- name: GetUserByID :one" header as
+// sqlc writes it, or "unnamed" for SQL without one. Only the name is
+// logged, never the statement or its arguments.
+func QueryName(sql string) string {
+	header, _, _ := strings.Cut(strings.TrimSpace(sql), "\n")
+	rest, ok := strings.CutPrefix(header, "-- name:")
+	if !ok {
+		return "unnamed"
+	}
+	if fields := strings.Fields(rest); len(fields) > 0 {
+		return fields[0]
+	}
+	return "unnamed"
+}
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock advances only when told to
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestSlowQueryTracer(t *testing.T) {
+	tests := []struct {
+		name     string
+		took     time.Duration
+		err      error
+		wantLogs []string
+	}{
+		{
+			name: "fast query is not logged",
+			took: 199 * time.Millisecond,
+		},
+		{
+			name:     "query at the threshold is logged",
+			took:     200 * time.Millisecond,
+			wantLogs: []string{`"msg":"slow query"`, `"query":"GetUserByID"`, `"duration_ms":200`},
+		},
+		{
+			name:     "failed slow query keeps its error",
+			took:     time.Second,
+			err:      errors.New("canceling statement due to statement timeout"),
+			wantLogs: []string{`"query":"GetUserByID"`, `"error":"canceling statement due to statement timeout"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			clock := &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+			tracer := NewSlowQueryTracer(200*time.Millisecond, slog.New(slog.NewJSONHandler(&buf, nil)))
+			tracer.now = clock.Now
+
+			ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: getUserByID})
+			clock.now = clock.now.Add(tt.took)
+			tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: tt.err})
+
+			if len(tt.wantLogs) == 0 {
+				assert.Empty(t, buf.String())
+				return
+			}
+			assert.Contains(t, buf.String(), `"level":"WARN"`)
+			for _, want := range tt.wantLogs {
+				assert.Contains(t, buf.String(), want)
+			}
+			assert.NotContains(t, buf.String(), "SELECT", "the statement itself is not logged")
+		})
+	}
+}
+
+func TestQueryName(t *testing.T) {
+	assert.Equal(t, "GetUserByID", QueryName(getUserByID))
+	assert.Equal(t, "ListUsers", QueryName("-- name: ListUsers :many\nSELECT 1"))
+	assert.Equal(t, "unnamed", QueryName("SELECT 1"))
+	assert.Equal(t, "unnamed", QueryName("-- name:"))
+}
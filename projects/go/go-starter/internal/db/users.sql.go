@@ -13,10 +13,17 @@ import (
 
 const countUsers = `-- name: CountUsers :one
 SELECT COUNT(*) FROM users
+WHERE ($1::text IS NULL OR email ILIKE $1)
+  AND ($2::text IS NULL OR name ILIKE $2)
 `
 
-func (q *Queries) CountUsers(ctx context.Context) (int64, error) {
-	row := q.db.QueryRow(ctx, countUsers)
+type CountUsersParams struct {
+	EmailPattern *string `json:"email_pattern"`
+	NamePattern  *string `json:"name_pattern"`
+}
+
+func (q *Queries) CountUsers(ctx context.Context, arg CountUsersParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countUsers, arg.EmailPattern, arg.NamePattern)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -30,7 +37,7 @@ INSERT INTO users (
 ) VALUES (
     $1, $2, $3
 )
-RETURNING id, email, name, password_hash, created_at, updated_at
+RETURNING id, email, name, password_hash, created_at, updated_at, version, role, password_changed_at
 `
 
 type CreateUserParams struct {
@@ -49,22 +56,28 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.PasswordHash,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.Role,
+		&i.PasswordChangedAt,
 	)
 	return i, err
 }
 
-const deleteUser = `-- name: DeleteUser :exec
+const deleteUser = `-- name: DeleteUser :execrows
 DELETE FROM users
 WHERE id = $1
 `
 
-func (q *Queries) DeleteUser(ctx context.Context, id pgtype.UUID) error {
-	_, err := q.db.Exec(ctx, deleteUser, id)
-	return err
+func (q *Queries) DeleteUser(ctx context.Context, id pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, name, password_hash, created_at, updated_at FROM users
+SELECT id, email, name, password_hash, created_at, updated_at, version, role, password_changed_at FROM users
 WHERE email = $1 LIMIT 1
 `
 
@@ -78,12 +91,15 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.PasswordHash,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.Role,
+		&i.PasswordChangedAt,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, email, name, password_hash, created_at, updated_at FROM users
+SELECT id, email, name, password_hash, created_at, updated_at, version, role, password_changed_at FROM users
 WHERE id = $1 LIMIT 1
 `
 
@@ -97,23 +113,65 @@ func (q *Queries) GetUserByID(ctx context.Context, id pgtype.UUID) (User, error)
 		&i.PasswordHash,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.Role,
+		&i.PasswordChangedAt,
 	)
 	return i, err
 }
 
-const listUsers = `-- name: ListUsers :many
-SELECT id, email, name, password_hash, created_at, updated_at FROM users
-ORDER BY created_at DESC
-LIMIT $1 OFFSET $2
+const getUserWithProfile = `-- name: GetUserWithProfile :one
+SELECT users.id, users.email, users.name, users.password_hash, users.created_at, users.updated_at, users.version, users.role, users.password_changed_at,
+    profiles.id AS profile_id,
+    profiles.bio AS profile_bio,
+    profiles.avatar_url AS profile_avatar_url,
+    profiles.created_at AS profile_created_at,
+    profiles.updated_at AS profile_updated_at
+FROM users
+LEFT JOIN profiles ON profiles.user_id = users.id
+WHERE users.id = $1 LIMIT 1
 `
 
-type ListUsersParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+type GetUserWithProfileRow struct {
+	User             User               `json:"user"`
+	ProfileID        pgtype.UUID        `json:"profile_id"`
+	ProfileBio       *string            `json:"profile_bio"`
+	ProfileAvatarUrl *string            `json:"profile_avatar_url"`
+	ProfileCreatedAt pgtype.Timestamptz `json:"profile_created_at"`
+	ProfileUpdatedAt pgtype.Timestamptz `json:"profile_updated_at"`
 }
 
-func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
-	rows, err := q.db.Query(ctx, listUsers, arg.Limit, arg.Offset)
+// The profile columns are all NULL when the user has no profile
+func (q *Queries) GetUserWithProfile(ctx context.Context, id pgtype.UUID) (GetUserWithProfileRow, error) {
+	row := q.db.QueryRow(ctx, getUserWithProfile, id)
+	var i GetUserWithProfileRow
+	err := row.Scan(
+		&i.User.ID,
+		&i.User.Email,
+		&i.User.Name,
+		&i.User.PasswordHash,
+		&i.User.CreatedAt,
+		&i.User.UpdatedAt,
+		&i.User.Version,
+		&i.User.Role,
+		&i.User.PasswordChangedAt,
+		&i.ProfileID,
+		&i.ProfileBio,
+		&i.ProfileAvatarUrl,
+		&i.ProfileCreatedAt,
+		&i.ProfileUpdatedAt,
+	)
+	return i, err
+}
+
+const getUsersByIDs = `-- name: GetUsersByIDs :many
+SELECT id, email, name, password_hash, created_at, updated_at, version, role, password_changed_at FROM users
+WHERE id = ANY($1::uuid[])
+`
+
+// Missing ids are simply absent from the result; order is unspecified
+func (q *Queries) GetUsersByIDs(ctx context.Context, ids []pgtype.UUID) ([]User, error) {
+	rows, err := q.db.Query(ctx, getUsersByIDs, ids)
 	if err != nil {
 		return nil, err
 	}
@@ -128,6 +186,67 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 			&i.PasswordHash,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Version,
+			&i.Role,
+			&i.PasswordChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchUsers = `-- name: SearchUsers :many
+SELECT id, email, name, password_hash, created_at, updated_at, version, role,
+    ts_rank(to_tsvector('simple', name || ' ' || email), plainto_tsquery('simple', $1))::real AS score
+FROM users
+WHERE to_tsvector('simple', name || ' ' || email) @@ plainto_tsquery('simple', $1)
+ORDER BY score DESC, id
+LIMIT $2 OFFSET $3
+`
+
+type SearchUsersParams struct {
+	Query  string `json:"query"`
+	Limit  int32  `json:"limit"`
+	Offset int32  `json:"offset"`
+}
+
+type SearchUsersRow struct {
+	ID           pgtype.UUID        `json:"id"`
+	Email        string             `json:"email"`
+	Name         string             `json:"name"`
+	PasswordHash string             `json:"password_hash"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
+	Version      int32              `json:"version"`
+	Role         string             `json:"role"`
+	Score        float32            `json:"score"`
+}
+
+// The expression matches idx_users_search; keep them in sync
+func (q *Queries) SearchUsers(ctx context.Context, arg SearchUsersParams) ([]SearchUsersRow, error) {
+	rows, err := q.db.Query(ctx, searchUsers, arg.Query, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchUsersRow{}
+	for rows.Next() {
+		var i SearchUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.Name,
+			&i.PasswordHash,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.Role,
+			&i.Score,
 		); err != nil {
 			return nil, err
 		}
@@ -144,9 +263,10 @@ UPDATE users
 SET 
     email = COALESCE($1, email),
     name = COALESCE($2, name),
-    password_hash = COALESCE($3, password_hash)
-WHERE id = $4
-RETURNING id, email, name, password_hash, created_at, updated_at
+    password_hash = COALESCE($3, password_hash),
+    version = version + 1
+WHERE id = $4 AND version = $5
+RETURNING id, email, name, password_hash, created_at, updated_at, version, role, password_changed_at
 `
 
 type UpdateUserParams struct {
@@ -154,6 +274,7 @@ type UpdateUserParams struct {
 	Name         *string     `json:"name"`
 	PasswordHash *string     `json:"password_hash"`
 	ID           pgtype.UUID `json:"id"`
+	Version      int32       `json:"version"`
 }
 
 func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
@@ -162,6 +283,7 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		arg.Name,
 		arg.PasswordHash,
 		arg.ID,
+		arg.Version,
 	)
 	var i User
 	err := row.Scan(
@@ -171,6 +293,31 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.PasswordHash,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.Role,
+		&i.PasswordChangedAt,
 	)
 	return i, err
 }
+
+const updateUserPassword = `-- name: UpdateUserPassword :execrows
+UPDATE users
+SET
+    password_hash = $1,
+    password_changed_at = NOW(),
+    version = version + 1
+WHERE id = $2
+`
+
+type UpdateUserPasswordParams struct {
+	PasswordHash string      `json:"password_hash"`
+	ID           pgtype.UUID `json:"id"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateUserPassword, arg.PasswordHash, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
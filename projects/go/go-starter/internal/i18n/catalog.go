@@ -0,0 +1,50 @@
+package i18n
+
+// Message is the translated text of an error. An empty field keeps the
+// English text the handler wrote, e.g. a Detail naming the invalid field.
+type Message struct {
+	Title  string
+	Detail string
+}
+
+// catalogs maps language and error code to a translation. English has no
+// catalog: handlers already write English.
+var catalogs = map[string]map[string]Message{
+	German: {
+		"INTERNAL_ERROR":         {Title: "Interner Serverfehler", Detail: "Ein unerwarteter Fehler ist aufgetreten"},
+		"SERVICE_UNAVAILABLE":    {Title: "Dienst nicht verfügbar", Detail: "Der Dienst ist vorübergehend nicht verfügbar, bitte später erneut versuchen"},
+		"TOO_MANY_IN_FLIGHT":     {Title: "Dienst nicht verfügbar", Detail: "Zu viele gleichzeitige Anfragen, bitte später erneut versuchen"},
+		"RATE_LIMIT_EXCEEDED":    {Title: "Zu viele Anfragen", Detail: "Anfragelimit überschritten, bitte nach der in Retry-After angegebenen Zeit erneut versuchen"},
+		"TIMEOUT":                {Title: "Zeitüberschreitung", Detail: "Die Anfrage hat zu lange gedauert"},
+		"UNAUTHORIZED":           {Title: "Nicht autorisiert", Detail: "Ein gültiges Zugriffstoken ist erforderlich"},
+		"FORBIDDEN":              {Title: "Verboten", Detail: "Keine ausreichenden Berechtigungen"},
+		"NOT_FOUND":              {Title: "Nicht gefunden", Detail: "Die angeforderte Ressource wurde nicht gefunden"},
+		"METHOD_NOT_ALLOWED":     {Title: "Methode nicht erlaubt", Detail: "Diese Methode wird für diese Ressource nicht unterstützt"},
+		"INVALID_ID":             {Title: "Ungültige Anfrage", Detail: "Die ID fehlt oder ist ungültig"},
+		"INVALID_BODY":           {Title: "Ungültige Anfrage", Detail: "Der Anfragekörper ist ungültig"},
+		"INVALID_PARAMETER":      {Title: "Ungültige Anfrage"},
+		"INVALID_PAGINATION":     {Title: "Ungültige Anfrage"},
+		"INVALID_FILTER":         {Title: "Ungültige Anfrage"},
+		"INVALID_QUERY":          {Title: "Ungültige Anfrage"},
+		"INVALID_SORT":           {Title: "Ungültige Anfrage"},
+		"INVALID_INCLUDE":        {Title: "Ungültige Anfrage"},
+		"INVALID_VERSION":        {Title: "Ungültige Anfrage", Detail: "Die Version muss eine positive Ganzzahl sein"},
+		"BATCH_TOO_LARGE":        {Title: "Ungültige Anfrage"},
+		"URI_TOO_LONG":           {Title: "URI zu lang"},
+		"INVALID_HOST":           {Title: "Ungültige Anfrage", Detail: "Der Host-Header ist nicht zulässig"},
+		"UNSUPPORTED_MEDIA_TYPE": {Title: "Nicht unterstützter Medientyp", Detail: "Der Anfragekörper muss als application/vnd.api+json gesendet werden"},
+		"VALIDATION_ERROR":       {Title: "Validierungsfehler"},
+		"VERSION_REQUIRED":       {Title: "Vorbedingung erforderlich", Detail: "Die Version muss per If-Match oder data.meta.version angegeben werden"},
+		"TYPE_MISMATCH":          {Title: "Konflikt", Detail: "Der Ressourcentyp passt nicht zu diesem Endpunkt"},
+		"STALE_VERSION":          {Title: "Konflikt", Detail: "Der Datensatz wurde zwischenzeitlich geändert; bitte neu laden und erneut versuchen"},
+		"EMAIL_ALREADY_EXISTS":   {Title: "Konflikt", Detail: "Diese E-Mail-Adresse wird bereits verwendet"},
+		"IDEMPOTENCY_KEY_IN_USE": {Title: "Konflikt", Detail: "Eine Anfrage mit diesem Idempotency-Key wird noch verarbeitet"},
+		"IDEMPOTENCY_KEY_REUSED": {Title: "Nicht verarbeitbare Anfrage", Detail: "Dieser Idempotency-Key wurde bereits für eine andere Anfrage verwendet"},
+	},
+}
+
+// Lookup returns the translation of code in lang
+func Lookup(lang, code string) (Message, bool) {
+	msg, ok := catalogs[lang][code]
+	return msg, ok
+}
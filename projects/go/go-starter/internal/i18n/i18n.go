@@ -0,0 +1,111 @@
+// Package i18n picks a response language from Accept-Language and holds the
+// translated error messages, keyed by JSON:API error code
+package i18n
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// Supported languages. English is the language messages are written in and
+// the fallback for anything else.
+const (
+	English = "en"
+	German  = "de"
+)
+
+// maxRanges bounds how much of an Accept-Language header is looked at
+const maxRanges = 16
+
+// maxTagLength is the longest language tag accepted (RFC 5646 allows 35)
+const maxTagLength = 35
+
+type contextKey struct{}
+
+// WithLanguage returns a copy of ctx carrying lang
+func WithLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, contextKey{}, lang)
+}
+
+// Language returns the language stored in ctx, or English
+func Language(ctx context.Context) string {
+	if lang, ok := ctx.Value(contextKey{}).(string); ok {
+		return lang
+	}
+	return English
+}
+
+// Match returns the supported language the client prefers most according
+// to an Accept-Language header. Ranges are compared by q-value, then by
+// position; a regional tag such as de-AT matches its base language.
+// Malformed ranges are skipped, so a garbage header yields English.
+func Match(header string) string {
+	best, bestQ := English, 0.0
+	for i, part := range strings.Split(header, ",") {
+		if i == maxRanges {
+			break
+		}
+		tag, q, ok := parseRange(part)
+		if !ok || q <= bestQ {
+			continue
+		}
+		if lang, ok := supported(tag); ok {
+			best, bestQ = lang, q
+		}
+	}
+	return best
+}
+
+// parseRange parses one "tag;q=0.8" element of Accept-Language
+func parseRange(part string) (tag string, q float64, ok bool) {
+	tag, params, _ := strings.Cut(part, ";")
+	tag = strings.TrimSpace(tag)
+	if !validTag(tag) {
+		return "", 0, false
+	}
+
+	q = 1
+	if params = strings.TrimSpace(params); params != "" {
+		value, found := strings.CutPrefix(params, "q=")
+		if !found {
+			return "", 0, false
+		}
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			return "", 0, false
+		}
+		q = parsed
+	}
+
+	return tag, q, true
+}
+
+// validTag accepts "*" and tags made of letters, digits and hyphens
+func validTag(tag string) bool {
+	if tag == "*" {
+		return true
+	}
+	if tag == "" || len(tag) > maxTagLength {
+		return false
+	}
+	for _, c := range tag {
+		if c != '-' && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// supported maps a language tag to a supported language
+func supported(tag string) (string, bool) {
+	if tag == "*" {
+		return English, true
+	}
+	primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+	switch primary {
+	case English, German:
+		return primary, true
+	}
+	return "", false
+}
@@ -0,0 +1,41 @@
+package i18n
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{header: "", want: English},
+		{header: "de", want: German},
+		{header: "de-AT", want: German},
+		{header: "DE-de", want: German},
+		{header: "fr-FR, de;q=0.8, en;q=0.5", want: German},
+		{header: "en;q=0.9, de", want: German},
+		{header: "de;q=0.5, en;q=0.5", want: German},
+		{header: "fr, ja", want: English},
+		{header: "de;q=0", want: English},
+		{header: "*", want: English},
+		{header: "de;q=abc", want: English},
+		{header: "de;q=2", want: English},
+		{header: "d<e>, ;;;, ,,,", want: English},
+		{header: strings.Repeat("x", 100) + ", de", want: German},
+		{header: strings.Repeat("fr,", maxRanges) + "de", want: English},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Match(tt.header), "header %q", tt.header)
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	assert.Equal(t, English, Language(context.Background()))
+	assert.Equal(t, German, Language(WithLanguage(context.Background(), German)))
+}
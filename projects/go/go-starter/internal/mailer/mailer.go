@@ -0,0 +1,58 @@
+// Package mailer renders and sends templated HTML emails. Templates live in
+// templates/ and are rendered with html/template, so data is escaped.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+)
+
+// Template names
+const (
+	Welcome = "welcome"
+)
+
+// subjects holds the subject line of each template. They are fixed rather
+// than rendered so that user data never ends up in a header.
+var subjects = map[string]string{
+	Welcome: "Welcome to go-starter",
+}
+
+// ErrUnknownTemplate is returned for a template name without a template
+var ErrUnknownTemplate = errors.New("unknown email template")
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var templates = template.Must(template.ParseFS(templateFS, "templates/*.html"))
+
+// Mailer sends the email template rendered with data to one recipient
+type Mailer interface {
+	Send(ctx context.Context, to, template string, data any) error
+}
+
+// Message is a rendered email
+type Message struct {
+	Subject string
+	HTML    string
+}
+
+// Render renders the named template with data
+func Render(name string, data any) (Message, error) {
+	subject, ok := subjects[name]
+	tmpl := templates.Lookup(name + ".html")
+	if !ok || tmpl == nil {
+		return Message{}, fmt.Errorf("%w: %q", ErrUnknownTemplate, name)
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return Message{}, fmt.Errorf("render %s: %w", name, err)
+	}
+
+	return Message{Subject: subject, HTML: body.String()}, nil
+}
@@ -0,0 +1,114 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	msg, err := Render(Welcome, map[string]string{"Name": "<b>Ada</b>", "Email": "ada@example.com"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Welcome to go-starter", msg.Subject)
+	assert.Contains(t, msg.HTML, "Hi &lt;b&gt;Ada&lt;/b&gt;,")
+	assert.Contains(t, msg.HTML, "ada@example.com")
+}
+
+func TestRender_UnknownTemplate(t *testing.T) {
+	_, err := Render("goodbye", nil)
+	assert.ErrorIs(t, err, ErrUnknownTemplate)
+}
+
+// fakeSMTPServer accepts one SMTP conversation without STARTTLS or auth and
+// returns the envelope and message it received
+func fakeSMTPServer(t *testing.T) (addr string, received <-chan []string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	lines := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var got []string
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+		reply("220 localhost ready")
+		inData := false
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				lines <- got
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			if inData {
+				if line == "." {
+					inData = false
+					reply("250 queued")
+					continue
+				}
+				got = append(got, line)
+				continue
+			}
+			got = append(got, line)
+			switch {
+			case strings.HasPrefix(line, "EHLO"):
+				reply("250 localhost")
+			case line == "DATA":
+				inData = true
+				reply("354 go ahead")
+			case line == "QUIT":
+				reply("221 bye")
+				lines <- got
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	return ln.Addr().String(), lines
+}
+
+func TestSMTP_Send(t *testing.T) {
+	addr, received := fakeSMTPServer(t)
+	host, port, err := net.SplitHostPort(addr)
+	require.NoError(t, err)
+	portNum, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	m := NewSMTP(SMTPConfig{Host: host, Port: portNum, From: "go-starter <noreply@example.com>"})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = m.Send(ctx, "ada@example.com", Welcome, map[string]string{"Name": "Ada", "Email": "ada@example.com"})
+	require.NoError(t, err)
+
+	got := strings.Join(<-received, "\n")
+	assert.Contains(t, got, "MAIL FROM:<noreply@example.com>")
+	assert.Contains(t, got, "RCPT TO:<ada@example.com>")
+	assert.Contains(t, got, "Subject: Welcome to go-starter")
+	assert.Contains(t, got, "Content-Type: text/html; charset=UTF-8")
+	assert.Contains(t, got, "Hi Ada,")
+}
+
+func TestSMTP_Send_RejectsHeaderInjection(t *testing.T) {
+	m := NewSMTP(SMTPConfig{Host: "127.0.0.1", Port: 25, From: "noreply@example.com"})
+
+	err := m.Send(context.Background(), "ada@example.com\r\nBcc: eve@example.com", Welcome, nil)
+	assert.ErrorContains(t, err, "invalid recipient")
+}
@@ -0,0 +1,33 @@
+package mailer
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Noop renders emails but only logs them, for development and tests where
+// no SMTP server is configured
+type Noop struct {
+	logger *slog.Logger
+}
+
+// NewNoop creates a Noop mailer
+func NewNoop(logger *slog.Logger) *Noop {
+	return &Noop{logger: logger}
+}
+
+// Send renders the template, so broken templates still fail, and logs the
+// email instead of sending it
+func (m *Noop) Send(ctx context.Context, to, template string, data any) error {
+	msg, err := Render(template, data)
+	if err != nil {
+		return err
+	}
+
+	m.logger.DebugContext(ctx, "email not sent, no SMTP server configured",
+		slog.String("to", to),
+		slog.String("template", template),
+		slog.String("subject", msg.Subject),
+	)
+	return nil
+}
@@ -1,6 +1,10 @@
 package models
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
 var (
 	ErrNotFound           = errors.New("resource not found")
@@ -9,4 +13,80 @@ var (
 	ErrValidation         = errors.New("validation failed")
 	ErrConflict           = errors.New("resource conflict")
 	ErrEmailAlreadyExists = errors.New("email already exists")
+	ErrUnavailable        = errors.New("service temporarily unavailable")
+	// ErrStaleVersion means an update was based on an outdated version of
+	// the resource
+	ErrStaleVersion = errors.New("resource version is stale")
 )
+
+// AppError is an error that knows how it should be presented to a client:
+// the HTTP status, a stable machine-readable code, and a detail that is safe
+// to show. It wraps the underlying error so errors.Is still matches the
+// sentinels above.
+type AppError struct {
+	Status int
+	Code   string
+	Detail string
+	Err    error
+}
+
+// NewAppError creates an AppError wrapping err
+func NewAppError(status int, code, detail string, err error) *AppError {
+	return &AppError{Status: status, Code: code, Detail: detail, Err: err}
+}
+
+func (e *AppError) Error() string {
+	if e.Err == nil {
+		return e.Code + ": " + e.Detail
+	}
+	return e.Code + ": " + e.Err.Error()
+}
+
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError describes a single invalid field. Field is empty when the
+// problem concerns the input as a whole.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("validation failed: %s", e.Message)
+	}
+	return fmt.Sprintf("validation failed for %s: %s", e.Field, e.Message)
+}
+
+// ValidationErrors collects every invalid field of an input so clients can
+// fix them all at once. It matches ErrValidation with errors.Is.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fieldErr := range e {
+		msgs[i] = fieldErr.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Is reports whether target is ErrValidation
+func (e ValidationErrors) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// BatchItemError reports which item of a batch operation failed
+type BatchItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("item %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchItemError) Unwrap() error {
+	return e.Err
+}
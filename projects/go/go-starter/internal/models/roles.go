@@ -0,0 +1,7 @@
+package models
+
+// Roles a user can hold; stored in users.role and carried in the JWT claims
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
@@ -0,0 +1,42 @@
+// Package outbox delivers events that were stored in the outbox table in
+// the same transaction as the change they describe. Delivery is
+// at-least-once: a crash between publishing and marking a row published
+// sends the event again, so consumers must be idempotent on Event.ID.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types
+const (
+	UserCreated = "user.created"
+)
+
+// Event is one outbox row handed to a Publisher
+type Event struct {
+	ID          int64           `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID uuid.UUID       `json:"aggregate_id"`
+	Payload     json.RawMessage `json:"payload"`
+	CreatedAt   time.Time       `json:"created_at"`
+	// Attempts is how many deliveries failed before this one
+	Attempts int32 `json:"attempts"`
+}
+
+// Publisher sends events to a broker. Publish returning nil means the
+// broker accepted the event; any error schedules a retry.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// UserCreatedPayload is the payload of a user.created event
+type UserCreatedPayload struct {
+	ID    uuid.UUID `json:"id"`
+	Email string    `json:"email"`
+	Name  string    `json:"name"`
+}
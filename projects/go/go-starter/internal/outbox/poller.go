@@ -0,0 +1,152 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/go-starter/internal/db"
+)
+
+// Store is the part of db.Querier the poller needs
+type Store interface {
+	ClaimOutboxEvents(ctx context.Context, arg db.ClaimOutboxEventsParams) ([]db.Outbox, error)
+	MarkOutboxEventPublished(ctx context.Context, id int64) error
+	RecordOutboxEventFailure(ctx context.Context, arg db.RecordOutboxEventFailureParams) error
+}
+
+// Poller periodically claims undelivered events and publishes them
+type Poller struct {
+	store       Store
+	publisher   Publisher
+	logger      *slog.Logger
+	interval    time.Duration
+	batchSize   int32
+	maxAttempts int32
+	lease       time.Duration
+}
+
+// PollerOption configures a Poller
+type PollerOption func(*Poller)
+
+// WithInterval sets how long the poller sleeps when there is nothing to do
+func WithInterval(d time.Duration) PollerOption {
+	return func(p *Poller) {
+		p.interval = d
+	}
+}
+
+// WithMaxAttempts sets how many failed deliveries make an event poison.
+// Poison events stay in the table with their last_error but are no longer
+// claimed.
+func WithMaxAttempts(n int32) PollerOption {
+	return func(p *Poller) {
+		p.maxAttempts = n
+	}
+}
+
+// NewPoller creates a Poller
+func NewPoller(store Store, publisher Publisher, logger *slog.Logger, opts ...PollerOption) *Poller {
+	p := &Poller{
+		store:       store,
+		publisher:   publisher,
+		logger:      logger,
+		interval:    time.Second,
+		batchSize:   100,
+		maxAttempts: 10,
+		// A claimed row is invisible to other pollers for this long, which
+		// must comfortably exceed the time to publish one batch
+		lease: 30 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Run polls until ctx is canceled. A full batch is followed immediately by
+// another poll so that a backlog drains without waiting for the ticker.
+func (p *Poller) Run(ctx context.Context) {
+	for {
+		n, err := p.PollOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			p.logger.Error("outbox poll failed", slog.String("error", err.Error()))
+		}
+
+		if err == nil && n == int(p.batchSize) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.interval):
+		}
+	}
+}
+
+// PollOnce claims and publishes one batch, returning how many events were
+// claimed. Failures to publish are recorded on the row, not returned.
+func (p *Poller) PollOnce(ctx context.Context) (int, error) {
+	rows, err := p.store.ClaimOutboxEvents(ctx, db.ClaimOutboxEventsParams{
+		LeaseSeconds: int32(p.lease / time.Second),
+		MaxAttempts:  p.maxAttempts,
+		BatchSize:    p.batchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, row := range rows {
+		p.deliver(ctx, row)
+	}
+
+	return len(rows), nil
+}
+
+// deliver publishes one row and records the outcome. If recording fails
+// the lease runs out and the event is published again.
+func (p *Poller) deliver(ctx context.Context, row db.Outbox) {
+	logger := p.logger.With(slog.Int64("event_id", row.ID), slog.String("event_type", row.EventType))
+
+	if err := p.publisher.Publish(ctx, toEvent(row)); err != nil {
+		attempts := row.Attempts + 1
+		if attempts >= p.maxAttempts {
+			logger.Error("outbox event is poison, giving up",
+				slog.Int("attempts", int(attempts)),
+				slog.String("error", err.Error()),
+			)
+		} else {
+			logger.Warn("outbox publish failed",
+				slog.Int("attempts", int(attempts)),
+				slog.String("error", err.Error()),
+			)
+		}
+
+		if err := p.store.RecordOutboxEventFailure(ctx, db.RecordOutboxEventFailureParams{
+			LastError: err.Error(),
+			ID:        row.ID,
+		}); err != nil {
+			logger.Error("failed to record outbox failure", slog.String("error", err.Error()))
+		}
+		return
+	}
+
+	if err := p.store.MarkOutboxEventPublished(ctx, row.ID); err != nil {
+		logger.Error("failed to mark outbox event published", slog.String("error", err.Error()))
+	}
+}
+
+// toEvent converts an outbox row to the Event handed to publishers
+func toEvent(row db.Outbox) Event {
+	return Event{
+		ID:          row.ID,
+		Type:        row.EventType,
+		AggregateID: uuid.UUID(row.AggregateID.Bytes),
+		Payload:     row.Payload,
+		CreatedAt:   row.CreatedAt.Time,
+		Attempts:    row.Attempts,
+	}
+}
@@ -0,0 +1,101 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/go-starter/internal/db"
+)
+
+// fakeStore hands out rows once and records what the poller did with them
+type fakeStore struct {
+	rows      []db.Outbox
+	claimArgs db.ClaimOutboxEventsParams
+	published []int64
+	failures  []db.RecordOutboxEventFailureParams
+}
+
+func (s *fakeStore) ClaimOutboxEvents(_ context.Context, arg db.ClaimOutboxEventsParams) ([]db.Outbox, error) {
+	s.claimArgs = arg
+	rows := s.rows
+	s.rows = nil
+	return rows, nil
+}
+
+func (s *fakeStore) MarkOutboxEventPublished(_ context.Context, id int64) error {
+	s.published = append(s.published, id)
+	return nil
+}
+
+func (s *fakeStore) RecordOutboxEventFailure(_ context.Context, arg db.RecordOutboxEventFailureParams) error {
+	s.failures = append(s.failures, arg)
+	return nil
+}
+
+// failingPublisher rejects events whose ID is in fail
+type failingPublisher struct {
+	fail      map[int64]bool
+	published []Event
+}
+
+func (p *failingPublisher) Publish(_ context.Context, event Event) error {
+	if p.fail[event.ID] {
+		return errors.New("broker unavailable")
+	}
+	p.published = append(p.published, event)
+	return nil
+}
+
+func TestPoller_PollOnce(t *testing.T) {
+	aggregate := uuid.New()
+	store := &fakeStore{rows: []db.Outbox{
+		{ID: 1, EventType: UserCreated, AggregateID: pgtype.UUID{Bytes: aggregate, Valid: true}, Payload: []byte(`{}`)},
+		{ID: 2, EventType: UserCreated, Attempts: 1},
+	}}
+	publisher := &failingPublisher{fail: map[int64]bool{2: true}}
+	poller := NewPoller(store, publisher, slog.New(slog.NewTextHandler(io.Discard, nil)), WithMaxAttempts(5))
+
+	n, err := poller.PollOnce(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, n)
+	assert.Equal(t, int32(5), store.claimArgs.MaxAttempts)
+	require.Len(t, publisher.published, 1)
+	assert.Equal(t, aggregate, publisher.published[0].AggregateID)
+	assert.Equal(t, []int64{1}, store.published)
+	assert.Equal(t, []db.RecordOutboxEventFailureParams{{LastError: "broker unavailable", ID: 2}}, store.failures)
+}
+
+func TestPoller_PoisonEvent(t *testing.T) {
+	var logs bytes.Buffer
+	store := &fakeStore{rows: []db.Outbox{{ID: 7, EventType: UserCreated, Attempts: 2}}}
+	publisher := &failingPublisher{fail: map[int64]bool{7: true}}
+	poller := NewPoller(store, publisher, slog.New(slog.NewTextHandler(&logs, nil)), WithMaxAttempts(3))
+
+	_, err := poller.PollOnce(context.Background())
+	require.NoError(t, err)
+
+	// The failure is still recorded, which takes attempts to the cap so the
+	// row is never claimed again
+	assert.Len(t, store.failures, 1)
+	assert.Contains(t, logs.String(), "outbox event is poison")
+}
+
+func TestWriterPublisher(t *testing.T) {
+	var buf bytes.Buffer
+	publisher := NewWriterPublisher(&buf)
+
+	require.NoError(t, publisher.Publish(context.Background(), Event{ID: 1, Type: UserCreated, Payload: []byte(`{"name":"Jane"}`)}))
+
+	assert.Contains(t, buf.String(), `"type":"user.created"`)
+	assert.Contains(t, buf.String(), `"payload":{"name":"Jane"}`)
+}
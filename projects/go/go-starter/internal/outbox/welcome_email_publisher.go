@@ -0,0 +1,54 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/yourusername/go-starter/internal/mailer"
+)
+
+// welcomeEmailTimeout bounds one send, so a hanging SMTP server cannot hold
+// the poller's lease on the rest of the batch
+const welcomeEmailTimeout = 10 * time.Second
+
+// WelcomeEmailPublisher sends a welcome email for every user.created event
+// once next has accepted it. A failed email is logged and does not fail the
+// event: retrying it would publish the event to next again. An event
+// delivered twice can still send the email twice.
+type WelcomeEmailPublisher struct {
+	next   Publisher
+	mailer mailer.Mailer
+	logger *slog.Logger
+}
+
+// NewWelcomeEmailPublisher creates a WelcomeEmailPublisher in front of next
+func NewWelcomeEmailPublisher(next Publisher, m mailer.Mailer, logger *slog.Logger) *WelcomeEmailPublisher {
+	return &WelcomeEmailPublisher{next: next, mailer: m, logger: logger}
+}
+
+// Publish hands event to next and then sends the email
+func (p *WelcomeEmailPublisher) Publish(ctx context.Context, event Event) error {
+	if err := p.next.Publish(ctx, event); err != nil {
+		return err
+	}
+	if event.Type != UserCreated {
+		return nil
+	}
+
+	logger := p.logger.With(slog.Int64("event_id", event.ID), slog.String("user_id", event.AggregateID.String()))
+
+	var payload UserCreatedPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		logger.Error("welcome email not sent, invalid payload", slog.String("error", err.Error()))
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, welcomeEmailTimeout)
+	defer cancel()
+	if err := p.mailer.Send(ctx, payload.Email, mailer.Welcome, payload); err != nil {
+		logger.Error("welcome email failed", slog.String("error", err.Error()))
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingMailer records sends and fails them with err
+type recordingMailer struct {
+	err  error
+	sent []string
+}
+
+func (m *recordingMailer) Send(_ context.Context, to, template string, _ any) error {
+	m.sent = append(m.sent, template+" "+to)
+	return m.err
+}
+
+func userCreatedEvent(t *testing.T) Event {
+	t.Helper()
+	id := uuid.New()
+	payload, err := json.Marshal(UserCreatedPayload{ID: id, Email: "ada@example.com", Name: "Ada"})
+	require.NoError(t, err)
+	return Event{ID: 1, Type: UserCreated, AggregateID: id, Payload: payload}
+}
+
+func TestWelcomeEmailPublisher(t *testing.T) {
+	next := &failingPublisher{}
+	mail := &recordingMailer{}
+	p := NewWelcomeEmailPublisher(next, mail, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	require.NoError(t, p.Publish(context.Background(), userCreatedEvent(t)))
+	require.NoError(t, p.Publish(context.Background(), Event{ID: 2, Type: "user.deleted"}))
+
+	assert.Len(t, next.published, 2)
+	assert.Equal(t, []string{"welcome ada@example.com"}, mail.sent)
+}
+
+func TestWelcomeEmailPublisher_MailFailureIsLogged(t *testing.T) {
+	var logs bytes.Buffer
+	mail := &recordingMailer{err: errors.New("connection refused")}
+	p := NewWelcomeEmailPublisher(&failingPublisher{}, mail, slog.New(slog.NewTextHandler(&logs, nil)))
+
+	require.NoError(t, p.Publish(context.Background(), userCreatedEvent(t)))
+	assert.Contains(t, logs.String(), "welcome email failed")
+	assert.Contains(t, logs.String(), "connection refused")
+}
+
+func TestWelcomeEmailPublisher_NoEmailWhenPublishFails(t *testing.T) {
+	event := userCreatedEvent(t)
+	mail := &recordingMailer{}
+	p := NewWelcomeEmailPublisher(&failingPublisher{fail: map[int64]bool{event.ID: true}}, mail, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	assert.Error(t, p.Publish(context.Background(), event))
+	assert.Empty(t, mail.sent)
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/puddle/v2"
+
+	"github.com/yourusername/go-starter/internal/db"
+	"github.com/yourusername/go-starter/internal/models"
+)
+
+// queryError translates a driver error into the repository error contract:
+// missing rows become models.ErrNotFound, capacity problems wrap
+// models.ErrUnavailable, and everything else is wrapped with op.
+func queryError(op string, err error) error {
+	// pgx v5 returns its own pgx.ErrNoRows, which is not sql.ErrNoRows
+	// before v5.6
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.ErrNotFound
+	}
+	// Already classified, e.g. by withQueryTimeout
+	if errors.Is(err, models.ErrUnavailable) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if isUnavailable(err) {
+		return fmt.Errorf("%s: %w: %w", op, models.ErrUnavailable, err)
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
+
+// writeError maps insert and update failures. Unique violations are matched
+// by constraint name; one that isn't listed here is a bug, not a conflict
+// the client caused, so it falls through to queryError.
+func writeError(op string, err error) error {
+	if db.IsUniqueViolation(err, db.UsersEmailKey) {
+		return models.ErrEmailAlreadyExists
+	}
+	return queryError(op, err)
+}
+
+// isUnavailable reports whether err means the database could not take the
+// request right now (pool exhausted, pool closed, or no connection could be
+// established) as opposed to the query itself failing. pgxpool surfaces an
+// exhausted pool as the context deadline expiring while waiting in Acquire.
+func isUnavailable(err error) bool {
+	var connectErr *pgconn.ConnectError
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return true
+	case errors.Is(err, puddle.ErrClosedPool), errors.Is(err, puddle.ErrNotAvailable):
+		return true
+	case errors.As(err, &connectErr):
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,321 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/yourusername/go-starter/internal/models"
+)
+
+// memoryUserRepository implements UserRepository on top of a map so that
+// services can be tested without a database. It writes no outbox events or
+// audit entries.
+type memoryUserRepository struct {
+	mu      sync.RWMutex
+	users   map[uuid.UUID]User
+	byEmail map[string]uuid.UUID
+}
+
+// NewMemoryUserRepository creates an empty in-memory UserRepository
+func NewMemoryUserRepository() UserRepository {
+	return &memoryUserRepository{
+		users:   make(map[uuid.UUID]User),
+		byEmail: make(map[string]uuid.UUID),
+	}
+}
+
+// NewSeededMemoryUserRepository creates an in-memory UserRepository pre-loaded
+// with fixtures. Seeds without an ID get a random one; a duplicate email
+// returns models.ErrEmailAlreadyExists just like an insert would.
+func NewSeededMemoryUserRepository(users ...User) (UserRepository, error) {
+	repo := &memoryUserRepository{
+		users:   make(map[uuid.UUID]User, len(users)),
+		byEmail: make(map[string]uuid.UUID, len(users)),
+	}
+
+	for _, user := range users {
+		if err := repo.insert(user); err != nil {
+			return nil, fmt.Errorf("seed user %q: %w", user.Email, err)
+		}
+	}
+
+	return repo, nil
+}
+
+// GetByID retrieves a user by their ID
+func (r *memoryUserRepository) GetByID(_ context.Context, id uuid.UUID) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+
+	return &user, nil
+}
+
+// GetByIDs retrieves the users that exist among ids
+func (r *memoryUserRepository) GetByIDs(_ context.Context, ids []uuid.UUID) ([]*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	byID := make(map[uuid.UUID]*User, len(ids))
+	for _, id := range ids {
+		if user, ok := r.users[id]; ok {
+			byID[id] = &user
+		}
+	}
+
+	return inIDOrder(ids, byID), nil
+}
+
+// GetByEmail retrieves a user by their email address
+func (r *memoryUserRepository) GetByEmail(_ context.Context, email string) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.byEmail[NormalizeEmail(email)]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+
+	user := r.users[id]
+	return &user, nil
+}
+
+// List retrieves a page of matching users in the given order
+func (r *memoryUserRepository) List(_ context.Context, filter UserFilter, sort []SortField, limit, offset int32) ([]*User, error) {
+	if _, err := orderByClause(sort); err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+	if len(sort) == 0 {
+		sort = DefaultUserSort
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	all := make([]*User, 0, len(r.users))
+	for _, user := range r.users {
+		user := user
+		if filter.matches(&user) {
+			all = append(all, &user)
+		}
+	}
+	slices.SortFunc(all, func(a, b *User) int {
+		for _, field := range sort {
+			c := compareColumn(a, b, field.Column)
+			if field.Desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return strings.Compare(a.ID.String(), b.ID.String())
+	})
+
+	if int(offset) >= len(all) {
+		return []*User{}, nil
+	}
+	end := int(offset) + int(limit)
+	if end > len(all) {
+		end = len(all)
+	}
+
+	return all[offset:end], nil
+}
+
+// Count returns the number of matching users
+func (r *memoryUserRepository) Count(_ context.Context, filter UserFilter) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var count int64
+	for _, user := range r.users {
+		user := user
+		if filter.matches(&user) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// compareColumn compares two users on one sortable column
+func compareColumn(a, b *User, column SortColumn) int {
+	switch column {
+	case SortByName:
+		return strings.Compare(a.Name, b.Name)
+	case SortByEmail:
+		return strings.Compare(a.Email, b.Email)
+	case SortByUpdatedAt:
+		return a.UpdatedAt.Time.Compare(b.UpdatedAt.Time)
+	default:
+		return a.CreatedAt.Time.Compare(b.CreatedAt.Time)
+	}
+}
+
+// matches mirrors the ILIKE substring semantics of the SQL queries
+func (f UserFilter) matches(user *User) bool {
+	return containsFold(user.Email, f.Email) && containsFold(user.Name, f.Name)
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// CreateBatch inserts users with the same all-or-nothing semantics as the
+// Postgres implementation when atomic is true
+func (r *memoryUserRepository) CreateBatch(_ context.Context, newUsers []NewUser, atomic bool) ([]BatchResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	results := make([]BatchResult, len(newUsers))
+	var inserted []User
+	for i, newUser := range newUsers {
+		user := fromNewUser(newUser)
+		if err := r.insertLocked(user); err != nil {
+			if atomic {
+				for _, u := range inserted {
+					delete(r.users, u.ID)
+					delete(r.byEmail, u.Email)
+				}
+				return nil, &models.BatchItemError{Index: i, Err: err}
+			}
+			results[i].Err = err
+			continue
+		}
+		inserted = append(inserted, user)
+		results[i].User = &user
+	}
+
+	return results, nil
+}
+
+// Update applies a partial update guarded by the version
+func (r *memoryUserRepository) Update(_ context.Context, id uuid.UUID, version int32, upd UserUpdate) (*User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+	if user.Version != version {
+		return nil, models.ErrStaleVersion
+	}
+
+	if upd.Email != nil {
+		email := NormalizeEmail(*upd.Email)
+		if owner, exists := r.byEmail[email]; exists && owner != id {
+			return nil, models.ErrEmailAlreadyExists
+		}
+		delete(r.byEmail, user.Email)
+		user.Email = email
+		r.byEmail[email] = id
+	}
+	if upd.Name != nil {
+		user.Name = *upd.Name
+	}
+	user.Version++
+	user.UpdatedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	r.users[id] = user
+
+	return &user, nil
+}
+
+// UpdatePassword stores a new password hash
+func (r *memoryUserRepository) UpdatePassword(_ context.Context, id uuid.UUID, passwordHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return models.ErrNotFound
+	}
+
+	now := pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	user.PasswordHash = passwordHash
+	user.PasswordChangedAt = now
+	user.UpdatedAt = now
+	user.Version++
+	r.users[id] = user
+
+	return nil
+}
+
+// Delete removes a user by ID
+func (r *memoryUserRepository) Delete(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return models.ErrNotFound
+	}
+	delete(r.users, id)
+	delete(r.byEmail, user.Email)
+
+	return nil
+}
+
+// fromNewUser builds the stored form of newUser, stamping it like the
+// table defaults would
+func fromNewUser(newUser NewUser) User {
+	now := pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	return User{
+		ID:           uuid.New(),
+		Email:        NormalizeEmail(newUser.Email),
+		Name:         newUser.Name,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		Version:      1,
+		Role:         models.RoleUser,
+		PasswordHash: newUser.PasswordHash,
+	}
+}
+
+// insert stores a copy of user, enforcing the same unique email constraint
+// as the users table
+func (r *memoryUserRepository) insert(user User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.insertLocked(user)
+}
+
+// insertLocked is insert for callers that already hold r.mu
+func (r *memoryUserRepository) insertLocked(user User) error {
+	if user.ID == uuid.Nil {
+		user.ID = uuid.New()
+	}
+	if user.Version == 0 {
+		// Matches the column default
+		user.Version = 1
+	}
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+	user.Email = NormalizeEmail(user.Email)
+
+	if _, exists := r.byEmail[user.Email]; exists {
+		return models.ErrEmailAlreadyExists
+	}
+	if _, exists := r.users[user.ID]; exists {
+		return models.ErrConflict
+	}
+
+	r.users[user.ID] = user
+	r.byEmail[user.Email] = user.ID
+
+	return nil
+}
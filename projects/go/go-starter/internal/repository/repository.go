@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/yourusername/go-starter/internal/models"
+)
+
+// Repository is the CRUD contract shared by entity repositories, so that
+// new entities get the same method names and errors as users:
+//   - Get, Update and Delete return models.ErrNotFound when there is no
+//     entity with the id
+//   - Create returns an error wrapping models.ErrConflict when the entity
+//     clashes with an existing one (same id or unique field)
+//   - Update returns models.ErrStaleVersion when the entity is versioned
+//     and its version is not the stored one
+//   - infrastructure failures wrap models.ErrUnavailable where they are
+//     transient
+//
+// Entity-specific queries (filters, search, batches) stay on the entity's
+// own interface, like UserRepository.
+type Repository[T any, ID comparable] interface {
+	Get(ctx context.Context, id ID) (*T, error)
+	// List returns a page of entities in a stable order
+	List(ctx context.Context, limit, offset int32) ([]*T, error)
+	// Create stores entity and returns it as stored, e.g. with its
+	// generated id and timestamps
+	Create(ctx context.Context, entity *T) (*T, error)
+	// Update replaces the mutable fields of the stored entity with the id
+	// of entity and returns the result
+	Update(ctx context.Context, entity *T) (*T, error)
+	Delete(ctx context.Context, id ID) error
+}
+
+// MemoryRepository is an in-memory Repository for tests and prototypes of
+// new entities. Entities are identified by the id function, listed in
+// insertion order, and not versioned.
+type MemoryRepository[T any, ID comparable] struct {
+	mu    sync.RWMutex
+	id    func(*T) ID
+	items map[ID]T
+	order []ID
+}
+
+// NewMemoryRepository creates an empty MemoryRepository that identifies
+// entities by id
+func NewMemoryRepository[T any, ID comparable](id func(*T) ID) *MemoryRepository[T, ID] {
+	return &MemoryRepository[T, ID]{id: id, items: make(map[ID]T)}
+}
+
+// Get returns a copy of the entity with id
+func (r *MemoryRepository[T, ID]) Get(_ context.Context, id ID) (*T, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	item, ok := r.items[id]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+	return &item, nil
+}
+
+// List returns copies of up to limit entities after offset
+func (r *MemoryRepository[T, ID]) List(_ context.Context, limit, offset int32) ([]*T, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	page := make([]*T, 0, limit)
+	for i := int(offset); i < len(r.order) && len(page) < int(limit); i++ {
+		item := r.items[r.order[i]]
+		page = append(page, &item)
+	}
+	return page, nil
+}
+
+// Create stores a copy of entity
+func (r *MemoryRepository[T, ID]) Create(_ context.Context, entity *T) (*T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.id(entity)
+	if _, ok := r.items[id]; ok {
+		return nil, models.ErrConflict
+	}
+	r.items[id] = *entity
+	r.order = append(r.order, id)
+
+	stored := *entity
+	return &stored, nil
+}
+
+// Update replaces the stored entity with a copy of entity
+func (r *MemoryRepository[T, ID]) Update(_ context.Context, entity *T) (*T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.id(entity)
+	if _, ok := r.items[id]; !ok {
+		return nil, models.ErrNotFound
+	}
+	r.items[id] = *entity
+
+	stored := *entity
+	return &stored, nil
+}
+
+// Delete removes the entity with id
+func (r *MemoryRepository[T, ID]) Delete(_ context.Context, id ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.items[id]; !ok {
+		return models.ErrNotFound
+	}
+	delete(r.items, id)
+	for i, other := range r.order {
+		if other == id {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
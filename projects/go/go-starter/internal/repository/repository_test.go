@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/go-starter/internal/models"
+)
+
+// contract describes an entity type to testRepositoryContract
+type contract[T any, ID comparable] struct {
+	// newEntity returns a fresh, valid entity that clashes with no other
+	newEntity func(i int) *T
+	id        func(*T) ID
+	// rename changes a mutable field, name reads it back
+	rename func(*T, string)
+	name   func(*T) string
+}
+
+// testRepositoryContract checks the behaviour every Repository promises
+func testRepositoryContract[T any, ID comparable](t *testing.T, repo Repository[T, ID], c contract[T, ID], missing ID) {
+	ctx := context.Background()
+
+	var created []*T
+	for i := range 3 {
+		entity, err := repo.Create(ctx, c.newEntity(i))
+		require.NoError(t, err)
+		created = append(created, entity)
+	}
+
+	got, err := repo.Get(ctx, c.id(created[1]))
+	require.NoError(t, err)
+	assert.Equal(t, c.id(created[1]), c.id(got))
+
+	_, err = repo.Create(ctx, created[0])
+	assert.ErrorIs(t, err, models.ErrConflict)
+
+	all, err := repo.List(ctx, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	page, err := repo.List(ctx, 2, 1)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, c.id(all[1]), c.id(page[0]), "pages follow one stable order")
+	assert.Equal(t, c.id(all[2]), c.id(page[1]))
+
+	c.rename(got, "Renamed")
+	updated, err := repo.Update(ctx, got)
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", c.name(updated))
+	got, err = repo.Get(ctx, c.id(got))
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", c.name(got))
+
+	require.NoError(t, repo.Delete(ctx, c.id(created[0])))
+	_, err = repo.Get(ctx, c.id(created[0]))
+	assert.ErrorIs(t, err, models.ErrNotFound)
+
+	_, err = repo.Get(ctx, missing)
+	assert.ErrorIs(t, err, models.ErrNotFound)
+	assert.ErrorIs(t, repo.Delete(ctx, missing), models.ErrNotFound)
+	ghost := c.newEntity(99)
+	_, err = repo.Update(ctx, ghost)
+	assert.ErrorIs(t, err, models.ErrNotFound)
+}
+
+// note is a stand-in for a future entity
+type note struct {
+	ID    int64
+	Title string
+}
+
+func TestMemoryRepository_Contract(t *testing.T) {
+	repo := NewMemoryRepository(func(n *note) int64 { return n.ID })
+	testRepositoryContract[note, int64](t, repo, contract[note, int64]{
+		newEntity: func(i int) *note { return &note{ID: int64(i + 1), Title: fmt.Sprint("note ", i)} },
+		id:        func(n *note) int64 { return n.ID },
+		rename:    func(n *note, title string) { n.Title = title },
+		name:      func(n *note) string { return n.Title },
+	}, 1000)
+}
+
+func TestUserStore_Contract(t *testing.T) {
+	store := NewUserStore(NewMemoryUserRepository())
+	testRepositoryContract[User, uuid.UUID](t, store, contract[User, uuid.UUID]{
+		newEntity: func(i int) *User {
+			return &User{
+				ID:      uuid.New(),
+				Email:   fmt.Sprintf("user%d@example.com", i),
+				Name:    fmt.Sprint("User ", i),
+				Version: 1,
+			}
+		},
+		id:     func(u *User) uuid.UUID { return u.ID },
+		rename: func(u *User, name string) { u.Name = name },
+		name:   func(u *User) string { return u.Name },
+	}, uuid.New())
+}
+
+func TestUserStore_Update_StaleVersion(t *testing.T) {
+	ctx := context.Background()
+	store := NewUserStore(NewMemoryUserRepository())
+
+	user, err := store.Create(ctx, &User{Email: "jane@example.com", Name: "Jane"})
+	require.NoError(t, err)
+
+	user.Name = "Jane Roe"
+	_, err = store.Update(ctx, user)
+	require.NoError(t, err)
+
+	// user still holds the version before the update
+	_, err = store.Update(ctx, user)
+	assert.ErrorIs(t, err, models.ErrStaleVersion)
+}
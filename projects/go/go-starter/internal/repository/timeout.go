@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/go-starter/internal/db"
+	"github.com/yourusername/go-starter/internal/models"
+)
+
+// DefaultQueryTimeout bounds a single query when no WithQueryTimeout option
+// is given
+const DefaultQueryTimeout = 5 * time.Second
+
+// UserRepositoryOption configures NewUserRepository
+type UserRepositoryOption func(*userRepository)
+
+// WithQueryTimeout bounds every query, or transaction, by d on top of the
+// caller's deadline, so one pathological statement can't use up the whole
+// request. d <= 0 leaves only the caller's deadline.
+func WithQueryTimeout(d time.Duration) UserRepositoryOption {
+	return func(r *userRepository) {
+		r.queryTimeout = d
+	}
+}
+
+// withQueryTimeout calls fn with ctx limited to timeout. A query cut off by
+// that limit, rather than by the caller's own deadline or cancellation,
+// fails with models.ErrUnavailable.
+func withQueryTimeout[T any](ctx context.Context, timeout time.Duration, fn func(ctx context.Context) (T, error)) (T, error) {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result, err := fn(queryCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(queryCtx.Err(), context.DeadlineExceeded) && !errors.Is(err, models.ErrUnavailable) {
+		err = fmt.Errorf("query timed out after %s: %w: %w", timeout, models.ErrUnavailable, err)
+	}
+	return result, err
+}
+
+// read runs a single-statement read. Every attempt gets the full query
+// timeout, and transient failures are retried.
+func read[T any](ctx context.Context, r *userRepository, fn func(ctx context.Context) (T, error)) (T, error) {
+	return db.Retry(ctx, r.retry, func(ctx context.Context) (T, error) {
+		return withQueryTimeout(ctx, r.queryTimeout, fn)
+	})
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yourusername/go-starter/internal/db"
+	"github.com/yourusername/go-starter/internal/models"
+)
+
+// blockUntilDone stands in for a query that runs until it is canceled, the
+// way pgx gives up on a statement once its context is done
+func blockUntilDone(ctx context.Context) (struct{}, error) {
+	<-ctx.Done()
+	return struct{}{}, ctx.Err()
+}
+
+func TestWithQueryTimeout(t *testing.T) {
+	t.Run("query timeout is unavailable", func(t *testing.T) {
+		_, err := withQueryTimeout(context.Background(), 10*time.Millisecond, blockUntilDone)
+		assert.ErrorIs(t, err, models.ErrUnavailable)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.ErrorContains(t, err, "query timed out after 10ms")
+	})
+
+	t.Run("caller cancellation is passed through", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		_, err := withQueryTimeout(ctx, time.Minute, blockUntilDone)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.NotErrorIs(t, err, models.ErrUnavailable)
+	})
+
+	t.Run("caller deadline is not a query timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := withQueryTimeout(ctx, time.Minute, blockUntilDone)
+		assert.NotContains(t, err.Error(), "query timed out")
+	})
+
+	t.Run("no timeout", func(t *testing.T) {
+		_, err := withQueryTimeout(context.Background(), 0, func(ctx context.Context) (struct{}, error) {
+			_, ok := ctx.Deadline()
+			assert.False(t, ok)
+			return struct{}{}, nil
+		})
+		assert.NoError(t, err)
+	})
+}
+
+func TestUserRepository_GetByID_QueryTimeout(t *testing.T) {
+	q := &stubQuerier{
+		getUserByID: func(ctx context.Context, _ pgtype.UUID) (db.User, error) {
+			<-ctx.Done()
+			return db.User{}, ctx.Err()
+		},
+	}
+	repo := NewUserRepository(q, nil, WithQueryTimeout(10*time.Millisecond))
+
+	start := time.Now()
+	_, err := repo.GetByID(context.Background(), uuid.New())
+
+	assert.ErrorIs(t, err, models.ErrUnavailable)
+	assert.Less(t, time.Since(start), time.Second, "a timed-out query is not retried")
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/yourusername/go-starter/internal/db"
+	"github.com/yourusername/go-starter/internal/models"
+)
+
+// Profile is the optional public profile of a user
+type Profile struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+	Bio    string
+	// AvatarURL is nil when the user hasn't set one
+	AvatarURL *string
+	CreatedAt pgtype.Timestamptz
+	UpdatedAt pgtype.Timestamptz
+}
+
+// UserWithProfile is a user together with their profile. Profile is nil
+// when the user has none.
+type UserWithProfile struct {
+	User    *User
+	Profile *Profile
+}
+
+// GetWithProfile retrieves a user and their profile in a single query
+func (r *userRepository) GetWithProfile(ctx context.Context, id uuid.UUID) (*UserWithProfile, error) {
+	pgID := pgtype.UUID{Bytes: id, Valid: true}
+	row, err := read(ctx, r, func(ctx context.Context) (db.GetUserWithProfileRow, error) {
+		return r.queries.GetUserWithProfile(ctx, pgID)
+	})
+	if err != nil {
+		return nil, queryError("get user with profile", err)
+	}
+
+	result := &UserWithProfile{User: toDomainUser(row.User)}
+	// The LEFT JOIN leaves every profile column NULL when there is no
+	// profile; the primary key is the one that can't be NULL otherwise
+	if row.ProfileID.Valid {
+		result.Profile = &Profile{
+			ID:        uuid.UUID(row.ProfileID.Bytes),
+			UserID:    id,
+			Bio:       *row.ProfileBio,
+			AvatarURL: row.ProfileAvatarUrl,
+			CreatedAt: row.ProfileCreatedAt,
+			UpdatedAt: row.ProfileUpdatedAt,
+		}
+	}
+
+	return result, nil
+}
+
+// GetWithProfile retrieves a user. The in-memory repository stores no
+// profiles, so Profile is always nil.
+func (r *memoryUserRepository) GetWithProfile(_ context.Context, id uuid.UUID) (*UserWithProfile, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+	return &UserWithProfile{User: &user}, nil
+}
@@ -2,15 +2,20 @@ package repository
 
 import (
 	"context"
-	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 
+	"github.com/yourusername/go-starter/internal/audit"
 	"github.com/yourusername/go-starter/internal/db"
 	"github.com/yourusername/go-starter/internal/models"
+	"github.com/yourusername/go-starter/internal/outbox"
 )
 
 // User represents the domain model for a user
@@ -20,23 +25,113 @@ type User struct {
 	Name      string
 	CreatedAt pgtype.Timestamptz
 	UpdatedAt pgtype.Timestamptz
+	// Version is bumped on every update and used for optimistic concurrency
+	Version int32
+	// Role is one of the models.Role* constants
+	Role string
+	// PasswordHash is the bcrypt hash; it must never leave the service layer
+	PasswordHash string
+	// PasswordChangedAt is NULL until the password is first changed
+	PasswordChangedAt pgtype.Timestamptz
+}
+
+// UserFilter narrows List and Count. Each non-empty field is a
+// case-insensitive substring match; empty fields match everything.
+type UserFilter struct {
+	Email string
+	Name  string
+}
+
+// UserUpdate holds the fields of a partial update; nil fields are left as is
+type UserUpdate struct {
+	Email *string
+	Name  *string
+}
+
+// NewUser holds the fields needed to insert a user
+type NewUser struct {
+	Email        string
+	Name         string
+	PasswordHash string
+}
+
+// BatchResult is the outcome of one item in a batch insert. Exactly one of
+// User and Err is set.
+type BatchResult struct {
+	User *User
+	Err  error
+}
+
+// DB is the database handle repositories run on. *pgxpool.Pool satisfies
+// it; Begin is needed for operations that span several statements.
+type DB interface {
+	db.DBTX
+	Begin(ctx context.Context) (pgx.Tx, error)
 }
 
 // UserRepository defines the interface for user data access
 type UserRepository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*User, error)
+	// GetByIDs returns the users that exist among ids, in the order of ids.
+	// Missing ids are skipped rather than reported as errors.
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*User, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
+	// GetWithProfile returns a user with their profile, which is nil if
+	// the user has none
+	GetWithProfile(ctx context.Context, id uuid.UUID) (*UserWithProfile, error)
+	List(ctx context.Context, filter UserFilter, sort []SortField, limit, offset int32) ([]*User, error)
+	Count(ctx context.Context, filter UserFilter) (int64, error)
+	// Search ranks users by full-text relevance of query against name and
+	// email. query must not be blank.
+	Search(ctx context.Context, query string, limit, offset int32) ([]SearchResult, error)
+	// CreateBatch inserts users in a single transaction. When atomic is
+	// true the first failure rolls back everything and is returned as the
+	// error; otherwise each failure is reported in its BatchResult and the
+	// rest are committed.
+	CreateBatch(ctx context.Context, users []NewUser, atomic bool) ([]BatchResult, error)
+	// Update applies upd only if the stored version still equals version,
+	// returning models.ErrStaleVersion otherwise
+	Update(ctx context.Context, id uuid.UUID, version int32, upd UserUpdate) (*User, error)
+	// UpdatePassword replaces the password hash and stamps
+	// PasswordChangedAt, returning models.ErrNotFound if there is no user
+	UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error
+	// Delete removes a user, returning models.ErrNotFound if there is none
+	Delete(ctx context.Context, id uuid.UUID) error
 }
 
+// usersResource is the resource type of users in the audit log
+const usersResource = "users"
+
 // userRepository implements UserRepository
 type userRepository struct {
-	queries *db.Queries
+	db      DB
+	queries db.Querier
+	// txQueries binds the queries to a transaction
+	txQueries func(tx pgx.Tx) db.Querier
+	// retry applies to single-statement reads, which are safe to repeat
+	retry db.RetryPolicy
+	// queryTimeout bounds each read attempt and each transaction
+	queryTimeout time.Duration
 }
 
-// NewUserRepository creates a new UserRepository
-func NewUserRepository(queries *db.Queries) UserRepository {
-	return &userRepository{
+// NewUserRepository creates a new UserRepository. Single statements go
+// through queries, so tests can pass a fake db.Querier; conn is only used
+// for transactions (every audited write) and hand-written SQL (List) and
+// may be nil when those aren't exercised. Production code passes db.New(pool), pool.
+func NewUserRepository(queries db.Querier, conn DB, opts ...UserRepositoryOption) UserRepository {
+	r := &userRepository{
+		db:      conn,
 		queries: queries,
+		txQueries: func(tx pgx.Tx) db.Querier {
+			return db.New(tx)
+		},
+		retry:        db.DefaultRetryPolicy,
+		queryTimeout: DefaultQueryTimeout,
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 // GetByID retrieves a user by their ID
@@ -48,22 +143,308 @@ func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*User, erro
 	}
 
 	// Query the database using sqlc-generated code
-	dbUser, err := r.queries.GetUserByID(ctx, pgID)
+	dbUser, err := read(ctx, r, func(ctx context.Context) (db.User, error) {
+		return r.queries.GetUserByID(ctx, pgID)
+	})
+	if err != nil {
+		return nil, queryError("get user by id", err)
+	}
+
+	return toDomainUser(dbUser), nil
+}
+
+// GetByIDs retrieves several users in one query
+func (r *userRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*User, error) {
+	pgIDs := make([]pgtype.UUID, len(ids))
+	for i, id := range ids {
+		pgIDs[i] = pgtype.UUID{Bytes: id, Valid: true}
+	}
+
+	dbUsers, err := read(ctx, r, func(ctx context.Context) ([]db.User, error) {
+		return r.queries.GetUsersByIDs(ctx, pgIDs)
+	})
+	if err != nil {
+		return nil, queryError("get users by ids", err)
+	}
+
+	byID := make(map[uuid.UUID]*User, len(dbUsers))
+	for _, dbUser := range dbUsers {
+		user := toDomainUser(dbUser)
+		byID[user.ID] = user
+	}
+
+	return inIDOrder(ids, byID), nil
+}
+
+// inIDOrder lists the users of byID in the order of ids, skipping ids
+// without a user. A repeated id yields the user again.
+func inIDOrder(ids []uuid.UUID, byID map[uuid.UUID]*User) []*User {
+	users := make([]*User, 0, len(byID))
+	for _, id := range ids {
+		if user, ok := byID[id]; ok {
+			users = append(users, user)
+		}
+	}
+	return users
+}
+
+// GetByEmail retrieves a user by their email address
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*User, error) {
+	email = NormalizeEmail(email)
+	dbUser, err := read(ctx, r, func(ctx context.Context) (db.User, error) {
+		return r.queries.GetUserByEmail(ctx, email)
+	})
+	if err != nil {
+		return nil, queryError("get user by email", err)
+	}
+
+	return toDomainUser(dbUser), nil
+}
+
+// Count returns the number of matching users
+func (r *userRepository) Count(ctx context.Context, filter UserFilter) (int64, error) {
+	params := db.CountUsersParams{
+		EmailPattern: containsPattern(filter.Email),
+		NamePattern:  containsPattern(filter.Name),
+	}
+	count, err := read(ctx, r, func(ctx context.Context) (int64, error) {
+		return r.queries.CountUsers(ctx, params)
+	})
+	if err != nil {
+		return 0, queryError("count users", err)
+	}
+
+	return count, nil
+}
+
+// CreateBatch inserts users in a single transaction
+func (r *userRepository) CreateBatch(ctx context.Context, users []NewUser, atomic bool) ([]BatchResult, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, queryError("begin create batch", err)
+	}
+	// Rollback after a successful Commit is a no-op
+	defer tx.Rollback(ctx)
+
+	results := make([]BatchResult, len(users))
+	for i, user := range users {
+		if atomic {
+			dbUser, err := createUser(ctx, db.New(tx), user)
+			if err != nil {
+				return nil, &models.BatchItemError{Index: i, Err: writeError("create user", err)}
+			}
+			results[i].User = toDomainUser(dbUser)
+			continue
+		}
+
+		// A failed statement aborts the whole Postgres transaction, so each
+		// item runs in its own savepoint to let the others survive
+		result, err := r.createInSavepoint(ctx, tx, user)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, queryError("commit create batch", err)
+	}
+
+	return results, nil
+}
+
+// Update applies a partial update guarded by the row version
+func (r *userRepository) Update(ctx context.Context, id uuid.UUID, version int32, upd UserUpdate) (*User, error) {
+	params := db.UpdateUserParams{
+		Name:    upd.Name,
+		ID:      pgtype.UUID{Bytes: id, Valid: true},
+		Version: version,
+	}
+	if upd.Email != nil {
+		email := NormalizeEmail(*upd.Email)
+		params.Email = &email
+	}
+
+	// The version check lives in the WHERE clause so that two concurrent
+	// updates can't both pass it
+	var dbUser db.User
+	err := r.inTx(ctx, "update user", func(ctx context.Context, q db.Querier) error {
+		var err error
+		dbUser, err = q.UpdateUser(ctx, params)
+		if err != nil {
+			return writeError("update user", err)
+		}
+		return audit.Audit(ctx, q, audit.ActionUpdate, usersResource, id.String(), audit.Actor(ctx))
+	})
+	if err == nil {
+		return toDomainUser(dbUser), nil
+	}
+	if !errors.Is(err, models.ErrNotFound) {
+		return nil, err
+	}
+
+	// No row matched: either the user is gone or the version moved on
+	if _, getErr := r.GetByID(ctx, id); getErr != nil {
+		return nil, getErr
+	}
+	return nil, models.ErrStaleVersion
+}
+
+// UpdatePassword stores a new password hash. It is a separate statement
+// from Update so that the hash can't ride along with a profile edit.
+func (r *userRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	return r.inTx(ctx, "update user password", func(ctx context.Context, q db.Querier) error {
+		updated, err := q.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+			PasswordHash: passwordHash,
+			ID:           pgtype.UUID{Bytes: id, Valid: true},
+		})
+		if err != nil {
+			return queryError("update user password", err)
+		}
+		if updated == 0 {
+			return models.ErrNotFound
+		}
+		return audit.Audit(ctx, q, audit.ActionChangePassword, usersResource, id.String(), audit.Actor(ctx))
+	})
+}
+
+// Delete removes a user by ID
+func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.inTx(ctx, "delete user", func(ctx context.Context, q db.Querier) error {
+		deleted, err := q.DeleteUser(ctx, pgtype.UUID{Bytes: id, Valid: true})
+		if err != nil {
+			return queryError("delete user", err)
+		}
+		if deleted == 0 {
+			return models.ErrNotFound
+		}
+		return audit.Audit(ctx, q, audit.ActionDelete, usersResource, id.String(), audit.Actor(ctx))
+	})
+}
+
+// inTx runs fn in a transaction that commits if fn returns nil. fn must use
+// the ctx it is given. Errors from fn are returned unchanged unless the
+// query timeout cut the transaction short.
+func (r *userRepository) inTx(ctx context.Context, op string, fn func(ctx context.Context, q db.Querier) error) error {
+	// The whole transaction shares one query timeout
+	_, err := withQueryTimeout(ctx, r.queryTimeout, func(ctx context.Context) (struct{}, error) {
+		tx, err := r.db.Begin(ctx)
+		if err != nil {
+			return struct{}{}, queryError("begin "+op, err)
+		}
+		// Rollback after a successful Commit is a no-op
+		defer tx.Rollback(ctx)
+
+		if err := fn(ctx, r.txQueries(tx)); err != nil {
+			return struct{}{}, err
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			return struct{}{}, queryError("commit "+op, err)
+		}
+
+		return struct{}{}, nil
+	})
+	return err
+}
+
+// createInSavepoint inserts user inside a savepoint of tx. Insert failures
+// are reported in the result; only savepoint failures are returned as err.
+func (r *userRepository) createInSavepoint(ctx context.Context, tx pgx.Tx, user NewUser) (BatchResult, error) {
+	savepoint, err := tx.Begin(ctx)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, models.ErrNotFound
+		return BatchResult{}, queryError("create savepoint", err)
+	}
+
+	dbUser, err := createUser(ctx, db.New(savepoint), user)
+	if err != nil {
+		if rbErr := savepoint.Rollback(ctx); rbErr != nil {
+			return BatchResult{}, queryError("rollback savepoint", rbErr)
 		}
-		return nil, fmt.Errorf("get user by id: %w", err)
+		return BatchResult{Err: writeError("create user", err)}, nil
+	}
+
+	if err := savepoint.Commit(ctx); err != nil {
+		return BatchResult{}, queryError("release savepoint", err)
+	}
+
+	return BatchResult{User: toDomainUser(dbUser)}, nil
+}
+
+// createUser inserts user together with its user.created outbox event and
+// audit entry. q must be bound to a transaction so that all rows commit or
+// none does.
+func createUser(ctx context.Context, q *db.Queries, user NewUser) (db.User, error) {
+	dbUser, err := q.CreateUser(ctx, toCreateParams(user))
+	if err != nil {
+		return db.User{}, err
+	}
+
+	payload, err := json.Marshal(outbox.UserCreatedPayload{
+		ID:    uuid.UUID(dbUser.ID.Bytes),
+		Email: dbUser.Email,
+		Name:  dbUser.Name,
+	})
+	if err != nil {
+		return db.User{}, fmt.Errorf("encode user.created: %w", err)
+	}
+
+	if err := q.InsertOutboxEvent(ctx, db.InsertOutboxEventParams{
+		EventType:   outbox.UserCreated,
+		AggregateID: dbUser.ID,
+		Payload:     payload,
+	}); err != nil {
+		return db.User{}, fmt.Errorf("insert outbox event: %w", err)
 	}
 
-	// Convert database model to domain model
-	user := &User{
-		ID:        uuid.UUID(dbUser.ID.Bytes),
-		Email:     dbUser.Email,
-		Name:      dbUser.Name,
-		CreatedAt: dbUser.CreatedAt,
-		UpdatedAt: dbUser.UpdatedAt,
+	if err := audit.Audit(ctx, q, audit.ActionCreate, usersResource, uuid.UUID(dbUser.ID.Bytes).String(), audit.Actor(ctx)); err != nil {
+		return db.User{}, err
 	}
 
-	return user, nil
+	return dbUser, nil
+}
+
+// NormalizeEmail trims surrounding whitespace and lowercases the address so
+// lookups match regardless of how the client typed it
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// likeEscaper escapes LIKE wildcards so filter values match literally;
+// backslash is the default ESCAPE character in Postgres
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// containsPattern turns a filter value into an ILIKE substring pattern, or
+// nil when the filter is unset. The value is always sent as a query
+// parameter, never spliced into the SQL.
+func containsPattern(value string) *string {
+	if value == "" {
+		return nil
+	}
+	pattern := "%" + likeEscaper.Replace(value) + "%"
+	return &pattern
+}
+
+// toCreateParams converts a NewUser to sqlc insert parameters
+func toCreateParams(user NewUser) db.CreateUserParams {
+	return db.CreateUserParams{
+		Email:        NormalizeEmail(user.Email),
+		Name:         user.Name,
+		PasswordHash: user.PasswordHash,
+	}
+}
+
+// toDomainUser converts a database model to a domain model
+func toDomainUser(dbUser db.User) *User {
+	return &User{
+		ID:                uuid.UUID(dbUser.ID.Bytes),
+		Email:             dbUser.Email,
+		Name:              dbUser.Name,
+		CreatedAt:         dbUser.CreatedAt,
+		UpdatedAt:         dbUser.UpdatedAt,
+		Version:           dbUser.Version,
+		Role:              dbUser.Role,
+		PasswordHash:      dbUser.PasswordHash,
+		PasswordChangedAt: dbUser.PasswordChangedAt,
+	}
 }
@@ -0,0 +1,47 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/go-starter/internal/db"
+	"github.com/yourusername/go-starter/internal/db/testutil"
+	"github.com/yourusername/go-starter/internal/models"
+)
+
+func TestUserRepository_GetByID_Integration(t *testing.T) {
+	pool := testutil.NewPool(t)
+	repo := NewUserRepository(db.New(pool), pool)
+	ctx := context.Background()
+
+	t.Run("existing user", func(t *testing.T) {
+		testutil.Truncate(t, pool)
+		created, err := db.New(pool).CreateUser(ctx, db.CreateUserParams{
+			Email:        "jane@example.com",
+			Name:         "Jane Doe",
+			PasswordHash: "hash",
+		})
+		require.NoError(t, err)
+
+		user, err := repo.GetByID(ctx, uuid.UUID(created.ID.Bytes))
+		require.NoError(t, err)
+		assert.Equal(t, "jane@example.com", user.Email)
+		assert.Equal(t, "Jane Doe", user.Name)
+		assert.Equal(t, models.RoleUser, user.Role)
+		assert.Equal(t, int32(1), user.Version)
+		assert.True(t, user.CreatedAt.Valid)
+	})
+
+	t.Run("missing user", func(t *testing.T) {
+		testutil.Truncate(t, pool)
+
+		_, err := repo.GetByID(ctx, uuid.New())
+		assert.ErrorIs(t, err, models.ErrNotFound)
+	})
+}
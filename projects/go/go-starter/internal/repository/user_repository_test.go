@@ -0,0 +1,421 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/puddle/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/go-starter/internal/audit"
+	"github.com/yourusername/go-starter/internal/db"
+	"github.com/yourusername/go-starter/internal/models"
+)
+
+// failingDBTX simulates a pool that never hands out a connection: every
+// call fails with err before any SQL is sent
+type failingDBTX struct {
+	err error
+}
+
+func (f failingDBTX) Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, f.err
+}
+
+func (f failingDBTX) Query(context.Context, string, ...interface{}) (pgx.Rows, error) {
+	return nil, f.err
+}
+
+func (f failingDBTX) Begin(context.Context) (pgx.Tx, error) {
+	return nil, f.err
+}
+
+func (f failingDBTX) QueryRow(context.Context, string, ...interface{}) pgx.Row {
+	return errRow{err: f.err}
+}
+
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(...any) error {
+	return r.err
+}
+
+func TestUserRepository_GetByID_Unavailable(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr error
+	}{
+		{
+			name:    "acquire timed out on exhausted pool",
+			err:     context.DeadlineExceeded,
+			wantErr: models.ErrUnavailable,
+		},
+		{
+			name:    "pool closed",
+			err:     puddle.ErrClosedPool,
+			wantErr: models.ErrUnavailable,
+		},
+		{
+			name: "query error is not unavailable",
+			err:  errors.New("syntax error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewUserRepository(db.New(failingDBTX{err: tt.err}), nil)
+
+			_, err := repo.GetByID(context.Background(), uuid.New())
+
+			assert.ErrorIs(t, err, tt.err)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NotErrorIs(t, err, models.ErrUnavailable)
+			}
+		})
+	}
+}
+
+// ctxAwareDBTX behaves like pgx for a context that is already done: the
+// query is never sent and the context error is returned
+type ctxAwareDBTX struct {
+	failingDBTX
+}
+
+func (ctxAwareDBTX) QueryRow(ctx context.Context, _ string, _ ...interface{}) pgx.Row {
+	return errRow{err: ctx.Err()}
+}
+
+func TestUserRepository_GetByID_ContextCanceled(t *testing.T) {
+	repo := NewUserRepository(db.New(ctxAwareDBTX{}), nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := repo.GetByID(ctx, uuid.New())
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotErrorIs(t, err, models.ErrUnavailable)
+}
+
+// stubQuerier stands in for the sqlc queries. Embedding db.Querier means
+// only the methods a test needs have to be written; anything else panics.
+type stubQuerier struct {
+	db.Querier
+	getUserByID      func(ctx context.Context, id pgtype.UUID) (db.User, error)
+	getUserByEmail   func(ctx context.Context, email string) (db.User, error)
+	getUsersByIDs    func(ctx context.Context, ids []pgtype.UUID) ([]db.User, error)
+	getWithProfile   func(ctx context.Context, id pgtype.UUID) (db.GetUserWithProfileRow, error)
+	updateUser       func(ctx context.Context, arg db.UpdateUserParams) (db.User, error)
+	insertAuditEntry func(ctx context.Context, arg db.InsertAuditEntryParams) error
+}
+
+func (s *stubQuerier) GetUserByID(ctx context.Context, id pgtype.UUID) (db.User, error) {
+	return s.getUserByID(ctx, id)
+}
+
+func (s *stubQuerier) GetUserByEmail(ctx context.Context, email string) (db.User, error) {
+	return s.getUserByEmail(ctx, email)
+}
+
+func (s *stubQuerier) GetUsersByIDs(ctx context.Context, ids []pgtype.UUID) ([]db.User, error) {
+	return s.getUsersByIDs(ctx, ids)
+}
+
+func (s *stubQuerier) GetUserWithProfile(ctx context.Context, id pgtype.UUID) (db.GetUserWithProfileRow, error) {
+	return s.getWithProfile(ctx, id)
+}
+
+func (s *stubQuerier) UpdateUser(ctx context.Context, arg db.UpdateUserParams) (db.User, error) {
+	return s.updateUser(ctx, arg)
+}
+
+func (s *stubQuerier) InsertAuditEntry(ctx context.Context, arg db.InsertAuditEntryParams) error {
+	if s.insertAuditEntry == nil {
+		return nil
+	}
+	return s.insertAuditEntry(ctx, arg)
+}
+
+// stubTx is a transaction that records whether it was committed
+type stubTx struct {
+	pgx.Tx
+	committed *bool
+}
+
+func (t stubTx) Commit(context.Context) error {
+	*t.committed = true
+	return nil
+}
+
+func (t stubTx) Rollback(context.Context) error {
+	return nil
+}
+
+// stubTxDB hands out stubTx transactions
+type stubTxDB struct {
+	DB
+	committed bool
+}
+
+func (d *stubTxDB) Begin(context.Context) (pgx.Tx, error) {
+	return stubTx{committed: &d.committed}, nil
+}
+
+// newStubRepository runs statements, including those in transactions,
+// against q
+func newStubRepository(q *stubQuerier) (*userRepository, *stubTxDB) {
+	conn := &stubTxDB{}
+	repo := NewUserRepository(q, conn).(*userRepository)
+	repo.txQueries = func(pgx.Tx) db.Querier { return q }
+	return repo, conn
+}
+
+func TestUserRepository_GetByID(t *testing.T) {
+	id := uuid.New()
+	q := &stubQuerier{
+		getUserByID: func(_ context.Context, got pgtype.UUID) (db.User, error) {
+			if got.Bytes != id {
+				return db.User{}, pgx.ErrNoRows
+			}
+			return db.User{ID: got, Email: "jane@example.com", Name: "Jane", Version: 3}, nil
+		},
+	}
+	repo := NewUserRepository(q, nil)
+
+	user, err := repo.GetByID(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, id, user.ID)
+	assert.Equal(t, "jane@example.com", user.Email)
+	assert.Equal(t, int32(3), user.Version)
+
+	_, err = repo.GetByID(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, models.ErrNotFound)
+}
+
+func TestUserRepository_GetByEmail(t *testing.T) {
+	q := &stubQuerier{
+		getUserByEmail: func(_ context.Context, email string) (db.User, error) {
+			if email != "jane@example.com" {
+				return db.User{}, pgx.ErrNoRows
+			}
+			return db.User{ID: pgtype.UUID{Bytes: uuid.New(), Valid: true}, Email: email}, nil
+		},
+	}
+	repo := NewUserRepository(q, nil)
+
+	user, err := repo.GetByEmail(context.Background(), "Jane@Example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "jane@example.com", user.Email)
+
+	_, err = repo.GetByEmail(context.Background(), "john@example.com")
+	assert.ErrorIs(t, err, models.ErrNotFound)
+}
+
+func TestUserRepository_GetWithProfile(t *testing.T) {
+	withProfile, withoutProfile, profileID := uuid.New(), uuid.New(), uuid.New()
+	bio, avatar := "Gopher", "https://example.com/jane.png"
+	q := &stubQuerier{
+		getWithProfile: func(_ context.Context, id pgtype.UUID) (db.GetUserWithProfileRow, error) {
+			switch id.Bytes {
+			case withProfile:
+				return db.GetUserWithProfileRow{
+					User:             db.User{ID: id, Name: "Jane"},
+					ProfileID:        pgtype.UUID{Bytes: profileID, Valid: true},
+					ProfileBio:       &bio,
+					ProfileAvatarUrl: &avatar,
+				}, nil
+			case withoutProfile:
+				// What the LEFT JOIN yields for a user without a profile
+				return db.GetUserWithProfileRow{User: db.User{ID: id, Name: "John"}}, nil
+			default:
+				return db.GetUserWithProfileRow{}, pgx.ErrNoRows
+			}
+		},
+	}
+	repo := NewUserRepository(q, nil)
+
+	got, err := repo.GetWithProfile(context.Background(), withProfile)
+	require.NoError(t, err)
+	assert.Equal(t, "Jane", got.User.Name)
+	require.NotNil(t, got.Profile)
+	assert.Equal(t, &Profile{ID: profileID, UserID: withProfile, Bio: bio, AvatarURL: &avatar}, got.Profile)
+
+	got, err = repo.GetWithProfile(context.Background(), withoutProfile)
+	require.NoError(t, err)
+	assert.Equal(t, "John", got.User.Name)
+	assert.Nil(t, got.Profile)
+
+	_, err = repo.GetWithProfile(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, models.ErrNotFound)
+}
+
+func TestUserRepository_GetByIDs(t *testing.T) {
+	a, b, missing := uuid.New(), uuid.New(), uuid.New()
+	q := &stubQuerier{
+		// Postgres returns ANY() matches in no particular order
+		getUsersByIDs: func(_ context.Context, ids []pgtype.UUID) ([]db.User, error) {
+			assert.Len(t, ids, 3)
+			return []db.User{
+				{ID: pgtype.UUID{Bytes: b, Valid: true}, Name: "B"},
+				{ID: pgtype.UUID{Bytes: a, Valid: true}, Name: "A"},
+			}, nil
+		},
+	}
+	repo := NewUserRepository(q, nil)
+
+	users, err := repo.GetByIDs(context.Background(), []uuid.UUID{a, missing, b})
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, a, users[0].ID)
+	assert.Equal(t, b, users[1].ID)
+}
+
+func TestUserRepository_GetByID_RetriesTransientErrors(t *testing.T) {
+	calls := 0
+	q := &stubQuerier{
+		// The first two attempts hit a primary that is shutting down
+		getUserByID: func(_ context.Context, id pgtype.UUID) (db.User, error) {
+			calls++
+			if calls <= 2 {
+				return db.User{}, &pgconn.PgError{Code: "57P01"}
+			}
+			return db.User{ID: id}, nil
+		},
+	}
+	repo := &userRepository{
+		queries: q,
+		retry:   db.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+	}
+
+	_, err := repo.GetByID(context.Background(), uuid.New())
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestUserRepository_Update_StaleVersion(t *testing.T) {
+	id := uuid.New()
+	q := &stubQuerier{
+		// The row exists but its version moved on, so the guarded UPDATE
+		// matches nothing
+		updateUser: func(context.Context, db.UpdateUserParams) (db.User, error) {
+			return db.User{}, pgx.ErrNoRows
+		},
+		getUserByID: func(_ context.Context, got pgtype.UUID) (db.User, error) {
+			return db.User{ID: got, Version: 2}, nil
+		},
+	}
+	repo, conn := newStubRepository(q)
+
+	name := "Jane"
+	_, err := repo.Update(context.Background(), id, 1, UserUpdate{Name: &name})
+	assert.ErrorIs(t, err, models.ErrStaleVersion)
+	assert.False(t, conn.committed)
+}
+
+func TestUserRepository_Update_Audits(t *testing.T) {
+	id := uuid.New()
+	var entries []db.InsertAuditEntryParams
+	q := &stubQuerier{
+		updateUser: func(_ context.Context, arg db.UpdateUserParams) (db.User, error) {
+			return db.User{ID: arg.ID, Name: *arg.Name, Version: arg.Version + 1}, nil
+		},
+		insertAuditEntry: func(_ context.Context, arg db.InsertAuditEntryParams) error {
+			entries = append(entries, arg)
+			return nil
+		},
+	}
+	repo, conn := newStubRepository(q)
+
+	ctx := audit.WithActor(audit.WithRequestID(context.Background(), "req-1"), "admin-1")
+	name := "Jane"
+	_, err := repo.Update(ctx, id, 1, UserUpdate{Name: &name})
+	require.NoError(t, err)
+	assert.True(t, conn.committed)
+
+	require.Len(t, entries, 1)
+	assert.Equal(t, audit.ActionUpdate, entries[0].Action)
+	assert.Equal(t, "users", entries[0].ResourceType)
+	assert.Equal(t, id.String(), entries[0].ResourceID)
+	assert.Equal(t, "admin-1", *entries[0].ActorID)
+	assert.Equal(t, "req-1", *entries[0].RequestID)
+
+	// Without an audit entry the update must not commit either
+	conn.committed = false
+	q.insertAuditEntry = func(context.Context, db.InsertAuditEntryParams) error {
+		return errors.New("audit_log is full")
+	}
+	_, err = repo.Update(context.Background(), id, 1, UserUpdate{Name: &name})
+	assert.ErrorContains(t, err, "audit_log is full")
+	assert.False(t, conn.committed)
+}
+
+func TestUserRepository_Update_UniqueViolation(t *testing.T) {
+	tests := []struct {
+		name       string
+		constraint string
+		wantErr    error
+	}{
+		{
+			name:       "email constraint is a conflict",
+			constraint: db.UsersEmailKey,
+			wantErr:    models.ErrEmailAlreadyExists,
+		},
+		{
+			name:       "unknown constraint is not",
+			constraint: "users_some_other_key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pgErr := &pgconn.PgError{Code: "23505", ConstraintName: tt.constraint}
+			q := &stubQuerier{
+				updateUser: func(context.Context, db.UpdateUserParams) (db.User, error) {
+					return db.User{}, pgErr
+				},
+			}
+			repo, _ := newStubRepository(q)
+
+			email := "jane@example.com"
+			_, err := repo.Update(context.Background(), uuid.New(), 1, UserUpdate{Email: &email})
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NotErrorIs(t, err, models.ErrEmailAlreadyExists)
+			assert.ErrorIs(t, err, pgErr)
+		})
+	}
+}
+
+func TestContainsPattern(t *testing.T) {
+	assert.Nil(t, containsPattern(""))
+	assert.Equal(t, "%jane%", *containsPattern("jane"))
+	assert.Equal(t, `%50\%\_off\\%`, *containsPattern(`50%_off\`))
+}
+
+func TestOrderByClause(t *testing.T) {
+	clause, err := orderByClause(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "created_at DESC, id", clause)
+
+	clause, err = orderByClause([]SortField{{Column: SortByName}, {Column: SortByUpdatedAt, Desc: true}})
+	require.NoError(t, err)
+	assert.Equal(t, "name, updated_at DESC, id", clause)
+
+	_, err = orderByClause([]SortField{{Column: "name; DROP TABLE users"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/yourusername/go-starter/internal/db"
+)
+
+// SearchResult is one user matched by Search with its relevance; higher
+// scores are better matches
+type SearchResult struct {
+	User  *User
+	Score float32
+}
+
+// Search finds users whose name or email contains the words of query, best
+// matches first
+func (r *userRepository) Search(ctx context.Context, query string, limit, offset int32) ([]SearchResult, error) {
+	params := db.SearchUsersParams{Query: query, Limit: limit, Offset: offset}
+	rows, err := read(ctx, r, func(ctx context.Context) ([]db.SearchUsersRow, error) {
+		return r.queries.SearchUsers(ctx, params)
+	})
+	if err != nil {
+		return nil, queryError("search users", err)
+	}
+
+	results := make([]SearchResult, len(rows))
+	for i, row := range rows {
+		results[i] = SearchResult{
+			User: toDomainUser(db.User{
+				ID:        row.ID,
+				Email:     row.Email,
+				Name:      row.Name,
+				CreatedAt: row.CreatedAt,
+				UpdatedAt: row.UpdatedAt,
+				Version:   row.Version,
+				Role:      row.Role,
+			}),
+			Score: row.Score,
+		}
+	}
+
+	return results, nil
+}
+
+// Search approximates the Postgres full-text search: every word of query
+// must appear in the name or email, and the score is the share of the
+// user's words that matched
+func (r *memoryUserRepository) Search(_ context.Context, query string, limit, offset int32) ([]SearchResult, error) {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return []SearchResult{}, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var all []SearchResult
+	for _, user := range r.users {
+		user := user
+		words := strings.Fields(strings.ToLower(user.Name + " " + user.Email))
+		matched := 0
+		for _, term := range terms {
+			if slices.Contains(words, term) {
+				matched++
+			}
+		}
+		if matched == len(terms) {
+			all = append(all, SearchResult{User: &user, Score: float32(matched) / float32(len(words))})
+		}
+	}
+	slices.SortFunc(all, func(a, b SearchResult) int {
+		if a.Score != b.Score {
+			if a.Score > b.Score {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.User.ID.String(), b.User.ID.String())
+	})
+
+	if int(offset) >= len(all) {
+		return []SearchResult{}, nil
+	}
+	end := min(int(offset)+int(limit), len(all))
+
+	return all[offset:end], nil
+}
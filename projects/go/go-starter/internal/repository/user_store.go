@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/go-starter/internal/models"
+)
+
+// UserStore is the generic Repository contract specialized to users. It is
+// a view of a UserRepository, which remains the interface services use for
+// the user-specific queries.
+type UserStore = Repository[User, uuid.UUID]
+
+// userStore adapts a UserRepository, Postgres or in-memory, to UserStore
+type userStore struct {
+	users UserRepository
+}
+
+// NewUserStore returns users as a UserStore
+func NewUserStore(users UserRepository) UserStore {
+	return userStore{users: users}
+}
+
+func (s userStore) Get(ctx context.Context, id uuid.UUID) (*User, error) {
+	return s.users.GetByID(ctx, id)
+}
+
+// List returns users ordered by creation time, unfiltered
+func (s userStore) List(ctx context.Context, limit, offset int32) ([]*User, error) {
+	return s.users.List(ctx, UserFilter{}, nil, limit, offset)
+}
+
+// Create inserts a user from its email, name and password hash; the id,
+// timestamps, version and role are assigned by the repository. A taken
+// email wraps both models.ErrConflict and models.ErrEmailAlreadyExists.
+func (s userStore) Create(ctx context.Context, user *User) (*User, error) {
+	results, err := s.users.CreateBatch(ctx, []NewUser{{
+		Email:        user.Email,
+		Name:         user.Name,
+		PasswordHash: user.PasswordHash,
+	}}, true)
+	if errors.Is(err, models.ErrEmailAlreadyExists) {
+		return nil, fmt.Errorf("%w: %w", models.ErrConflict, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return results[0].User, nil
+}
+
+// Update sets the user's email and name, using user.Version as the
+// expected version
+func (s userStore) Update(ctx context.Context, user *User) (*User, error) {
+	email, name := user.Email, user.Name
+	updated, err := s.users.Update(ctx, user.ID, user.Version, UserUpdate{Email: &email, Name: &name})
+	if errors.Is(err, models.ErrEmailAlreadyExists) {
+		return nil, fmt.Errorf("%w: %w", models.ErrConflict, err)
+	}
+	return updated, err
+}
+
+func (s userStore) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.users.Delete(ctx, id)
+}
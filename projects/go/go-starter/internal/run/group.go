@@ -0,0 +1,129 @@
+// Package run coordinates the long-running components of the server, such
+// as listeners and background workers, so that they stop together.
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Group runs components until one of them fails or Stop is called. The
+// first failure cancels the context of every other component.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	logger *slog.Logger
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	err     error
+	running map[string]int
+}
+
+// NewGroup creates a Group whose components run under a context derived
+// from ctx
+func NewGroup(ctx context.Context, logger *slog.Logger) *Group {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Group{
+		ctx:     ctx,
+		cancel:  cancel,
+		logger:  logger,
+		running: make(map[string]int),
+	}
+}
+
+// Go starts fn as component name. fn must return soon after its context is
+// canceled. Returning a non-nil error, or panicking, fails the group;
+// returning nil just means the component is done.
+func (g *Group) Go(name string, fn func(ctx context.Context) error) {
+	g.mu.Lock()
+	g.running[name]++
+	g.mu.Unlock()
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer func() {
+			g.mu.Lock()
+			if g.running[name]--; g.running[name] == 0 {
+				delete(g.running, name)
+			}
+			g.mu.Unlock()
+		}()
+
+		err := g.call(fn)
+		// Stopping is not failing
+		if err == nil || (g.ctx.Err() != nil && errors.Is(err, context.Canceled)) {
+			return
+		}
+		g.fail(fmt.Errorf("%s: %w", name, err))
+	}()
+}
+
+// call runs fn, turning a panic into an error so that one component can't
+// take the process down without the others being stopped
+func (g *Group) call(fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(g.ctx)
+}
+
+func (g *Group) fail(err error) {
+	g.mu.Lock()
+	first := g.err == nil
+	if first {
+		g.err = err
+	}
+	g.mu.Unlock()
+
+	if first {
+		g.logger.Error("component failed, shutting down", slog.String("error", err.Error()))
+		g.cancel()
+	}
+}
+
+// Done is closed once a component has failed or Stop was called
+func (g *Group) Done() <-chan struct{} {
+	return g.ctx.Done()
+}
+
+// Err returns the first component failure, or nil
+func (g *Group) Err() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.err
+}
+
+// Stop cancels every component and waits for them to return, giving up when
+// ctx is done. The error names the components that did not stop in time.
+func (g *Group) Stop(ctx context.Context) error {
+	g.cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		g.mu.Lock()
+		names := make([]string, 0, len(g.running))
+		for name := range g.running {
+			names = append(names, name)
+		}
+		g.mu.Unlock()
+		sort.Strings(names)
+		return fmt.Errorf("still running: %s: %w", strings.Join(names, ", "), ctx.Err())
+	}
+}
@@ -0,0 +1,95 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGroup() *Group {
+	return NewGroup(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// worker blocks until its context is canceled
+func worker(stopped *bool) func(context.Context) error {
+	return func(ctx context.Context) error {
+		<-ctx.Done()
+		*stopped = true
+		return ctx.Err()
+	}
+}
+
+func TestGroup_Stop(t *testing.T) {
+	g := newTestGroup()
+	var a, b bool
+	g.Go("a", worker(&a))
+	g.Go("b", worker(&b))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, g.Stop(ctx))
+
+	assert.True(t, a)
+	assert.True(t, b)
+	assert.NoError(t, g.Err(), "being stopped is not a failure")
+}
+
+func TestGroup_FailureStopsOthers(t *testing.T) {
+	tests := []struct {
+		name    string
+		fn      func(context.Context) error
+		wantErr string
+	}{
+		{
+			name:    "error",
+			fn:      func(context.Context) error { return errors.New("listen: address in use") },
+			wantErr: "broken: listen: address in use",
+		},
+		{
+			name:    "panic",
+			fn:      func(context.Context) error { panic("boom") },
+			wantErr: "broken: panic: boom",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGroup()
+			var stopped bool
+			g.Go("worker", worker(&stopped))
+			g.Go("broken", tt.fn)
+
+			select {
+			case <-g.Done():
+			case <-time.After(time.Second):
+				t.Fatal("a failing component did not stop the group")
+			}
+			require.NoError(t, g.Stop(context.Background()))
+			assert.True(t, stopped)
+			assert.EqualError(t, g.Err(), tt.wantErr)
+		})
+	}
+}
+
+func TestGroup_StopTimeout(t *testing.T) {
+	g := newTestGroup()
+	release := make(chan struct{})
+	defer close(release)
+	g.Go("stuck", func(context.Context) error {
+		<-release
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := g.Stop(ctx)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "still running: stuck")
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/yourusername/go-starter/internal/models"
+)
+
+// userError turns the repository and validation errors a client can act on
+// into AppErrors. Infrastructure errors (unavailable database, cancellation)
+// are returned unchanged for the handler to classify.
+func userError(err error) error {
+	var validationErrs models.ValidationErrors
+	switch {
+	case errors.As(err, &validationErrs):
+		return models.NewAppError(http.StatusUnprocessableEntity, "VALIDATION_ERROR", "The request contains invalid fields", err)
+	case errors.Is(err, models.ErrNotFound):
+		return models.NewAppError(http.StatusNotFound, "NOT_FOUND", "User not found", err)
+	case errors.Is(err, models.ErrEmailAlreadyExists):
+		return models.NewAppError(http.StatusConflict, "EMAIL_ALREADY_EXISTS", "A user with this email already exists", err)
+	case errors.Is(err, models.ErrStaleVersion):
+		return models.NewAppError(http.StatusConflict, "STALE_VERSION", "The user was modified by someone else; fetch it again and retry", err)
+	}
+	return err
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/yourusername/go-starter/internal/models"
+	"github.com/yourusername/go-starter/internal/repository"
+)
+
+// Password length bounds. bcrypt ignores everything past 72 bytes, so
+// longer passwords are rejected rather than silently truncated.
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 72
+	maxNameLength     = 255
+)
+
+// CreateUserInput is the data needed to create a user
+type CreateUserInput struct {
+	Name     string
+	Email    string
+	Password string
+}
+
+// Validate checks every field and returns all problems at once as
+// models.ValidationErrors
+func (in CreateUserInput) Validate() error {
+	var errs models.ValidationErrors
+	errs = appendIfInvalid(errs, validateName(in.Name))
+	errs = appendIfInvalid(errs, validateEmail(in.Email))
+
+	errs = appendIfInvalid(errs, validatePassword("password", in.Password))
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ChangePasswordInput is the data needed to change a user's password
+type ChangePasswordInput struct {
+	CurrentPassword string
+	NewPassword     string
+}
+
+// Validate checks the new password's strength. Whether CurrentPassword is
+// right is checked against the stored hash by ChangePassword.
+func (in ChangePasswordInput) Validate() error {
+	var errs models.ValidationErrors
+	if in.CurrentPassword == "" {
+		errs = append(errs, models.ValidationError{Field: "current_password", Message: "is required"})
+	}
+	if fieldErr := validatePassword("new_password", in.NewPassword); fieldErr != nil {
+		errs = append(errs, *fieldErr)
+	} else if in.NewPassword == in.CurrentPassword {
+		errs = append(errs, models.ValidationError{Field: "new_password", Message: "must differ from the current password"})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// UpdateUserInput is a partial update of a user; nil fields are unchanged
+type UpdateUserInput struct {
+	Name  *string
+	Email *string
+}
+
+// Validate checks the fields that are present
+func (in UpdateUserInput) Validate() error {
+	var errs models.ValidationErrors
+	if in.Name == nil && in.Email == nil {
+		errs = append(errs, models.ValidationError{Message: "must contain at least one of name, email"})
+	}
+	if in.Name != nil {
+		errs = appendIfInvalid(errs, validateName(*in.Name))
+	}
+	if in.Email != nil {
+		errs = appendIfInvalid(errs, validateEmail(*in.Email))
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// toUserUpdate validates the input and trims the values
+func (in UpdateUserInput) toUserUpdate() (repository.UserUpdate, error) {
+	if err := in.Validate(); err != nil {
+		return repository.UserUpdate{}, err
+	}
+
+	var upd repository.UserUpdate
+	if in.Name != nil {
+		name := strings.TrimSpace(*in.Name)
+		upd.Name = &name
+	}
+	if in.Email != nil {
+		email := strings.TrimSpace(*in.Email)
+		upd.Email = &email
+	}
+	return upd, nil
+}
+
+func validateName(name string) *models.ValidationError {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return &models.ValidationError{Field: "name", Message: "is required"}
+	case len(name) > maxNameLength:
+		return &models.ValidationError{Field: "name", Message: fmt.Sprintf("must be at most %d characters", maxNameLength)}
+	}
+	return nil
+}
+
+func validatePassword(field, password string) *models.ValidationError {
+	switch {
+	case len(password) < minPasswordLength:
+		return &models.ValidationError{Field: field, Message: fmt.Sprintf("must be at least %d characters", minPasswordLength)}
+	case len(password) > maxPasswordLength:
+		return &models.ValidationError{Field: field, Message: fmt.Sprintf("must be at most %d bytes", maxPasswordLength)}
+	}
+	return nil
+}
+
+func validateEmail(email string) *models.ValidationError {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return &models.ValidationError{Field: "email", Message: "is required"}
+	}
+	// ParseAddress also accepts "Name <addr>"; only a bare address is valid
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		return &models.ValidationError{Field: "email", Message: "must be a valid email address"}
+	}
+	return nil
+}
+
+func appendIfInvalid(errs models.ValidationErrors, fieldErr *models.ValidationError) models.ValidationErrors {
+	if fieldErr != nil {
+		errs = append(errs, *fieldErr)
+	}
+	return errs
+}
+
+// toNewUser validates the input and hashes the password
+func (in CreateUserInput) toNewUser() (repository.NewUser, error) {
+	if err := in.Validate(); err != nil {
+		return repository.NewUser{}, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(in.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return repository.NewUser{}, fmt.Errorf("hash password: %w", err)
+	}
+
+	return repository.NewUser{
+		Email:        strings.TrimSpace(in.Email),
+		Name:         strings.TrimSpace(in.Name),
+		PasswordHash: string(hash),
+	}, nil
+}
@@ -2,21 +2,68 @@ package service
 
 import (
 	"context"
+	"errors"
+	"expvar"
 	"fmt"
 
 	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/sync/singleflight"
 
+	"github.com/yourusername/go-starter/internal/models"
 	"github.com/yourusername/go-starter/internal/repository"
 )
 
 // UserService defines the interface for user business logic
 type UserService interface {
 	GetUser(ctx context.Context, id uuid.UUID) (*repository.User, error)
+	// GetUsers returns the users that exist among ids, in the order of ids;
+	// missing ids are left out
+	GetUsers(ctx context.Context, ids []uuid.UUID) ([]*repository.User, error)
+	// GetUserByEmail is for internal callers such as auth; it is deliberately
+	// not exposed as a route to avoid account enumeration
+	GetUserByEmail(ctx context.Context, email string) (*repository.User, error)
+	// GetUserWithProfile returns a user and their profile in one
+	// repository call. A user without a profile is not an error; Profile
+	// is nil.
+	GetUserWithProfile(ctx context.Context, id uuid.UUID) (*repository.UserWithProfile, error)
+	ListUsers(ctx context.Context, filter repository.UserFilter, sort []repository.SortField, limit, offset int) ([]*repository.User, error)
+	CountUsers(ctx context.Context, filter repository.UserFilter) (int64, error)
+	// SearchUsers returns users matching query, best matches first. Callers
+	// must reject a blank query.
+	SearchUsers(ctx context.Context, query string, limit, offset int) ([]repository.SearchResult, error)
+	// CreateUsers creates several users at once. With atomic set, any
+	// invalid or conflicting item fails the whole batch; otherwise each
+	// item's outcome is reported in its CreateResult.
+	CreateUsers(ctx context.Context, inputs []CreateUserInput, atomic bool) ([]CreateResult, error)
+	// UpdateUser applies input if the user is still at version, returning
+	// models.ErrStaleVersion when someone else updated it first
+	UpdateUser(ctx context.Context, id uuid.UUID, version int32, input UpdateUserInput) (*repository.User, error)
+	// ChangePassword replaces the password after checking the current one;
+	// a wrong current password is a validation error on current_password
+	ChangePassword(ctx context.Context, id uuid.UUID, input ChangePasswordInput) error
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+}
+
+// userGetShared counts GetUser calls answered by a query that other
+// concurrent calls shared, served on /debug/vars. Compared with the number
+// of lookups it shows how much the deduplication saves on hot users.
+var userGetShared = expvar.NewInt("user_get_shared")
+
+// CreateResult is the outcome of one item in CreateUsers. Exactly one of
+// User and Err is set.
+type CreateResult struct {
+	User *repository.User
+	Err  error
 }
 
 // userService implements UserService
 type userService struct {
 	userRepo repository.UserRepository
+	// getGroup collapses concurrent GetUser calls for the same ID into one
+	// repository query. It only shares in-flight calls; nothing, including
+	// errors, is remembered once the query returns.
+	getGroup singleflight.Group
 }
 
 // NewUserService creates a new UserService
@@ -26,12 +73,192 @@ func NewUserService(userRepo repository.UserRepository) UserService {
 	}
 }
 
-// GetUser retrieves a user by their ID
+// GetUser retrieves a user by their ID. Concurrent calls for the same ID
+// share a single repository query.
 func (s *userService) GetUser(ctx context.Context, id uuid.UUID) (*repository.User, error) {
-	user, err := s.userRepo.GetByID(ctx, id)
+	// The shared query must not fail just because the caller that started
+	// it went away, so it runs detached from that caller's cancellation;
+	// each caller still stops waiting when its own ctx is done
+	shared := context.WithoutCancel(ctx)
+	ch := s.getGroup.DoChan(id.String(), func() (interface{}, error) {
+		return s.userRepo.GetByID(shared, id)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("get user: %w", ctx.Err())
+	case res := <-ch:
+		if res.Shared {
+			userGetShared.Add(1)
+		}
+		if res.Err != nil {
+			return nil, fmt.Errorf("get user: %w", userError(res.Err))
+		}
+		// Every caller gets its own copy so that none can modify the
+		// others' result
+		user := *res.Val.(*repository.User)
+		return &user, nil
+	}
+}
+
+// GetUsers retrieves several users in one repository call
+func (s *userService) GetUsers(ctx context.Context, ids []uuid.UUID) ([]*repository.User, error) {
+	users, err := s.userRepo.GetByIDs(ctx, ids)
 	if err != nil {
-		return nil, fmt.Errorf("get user: %w", err)
+		return nil, fmt.Errorf("get users: %w", userError(err))
+	}
+
+	return users, nil
+}
+
+// GetUserByEmail retrieves a user by their email address
+func (s *userService) GetUserByEmail(ctx context.Context, email string) (*repository.User, error) {
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("get user by email: %w", userError(err))
 	}
 
 	return user, nil
 }
+
+// GetUserWithProfile retrieves a user together with their profile
+func (s *userService) GetUserWithProfile(ctx context.Context, id uuid.UUID) (*repository.UserWithProfile, error) {
+	result, err := s.userRepo.GetWithProfile(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get user with profile: %w", userError(err))
+	}
+
+	return result, nil
+}
+
+// ListUsers retrieves a page of matching users in the given order
+func (s *userService) ListUsers(ctx context.Context, filter repository.UserFilter, sort []repository.SortField, limit, offset int) ([]*repository.User, error) {
+	users, err := s.userRepo.List(ctx, filter, sort, int32(limit), int32(offset))
+	if err != nil {
+		return nil, fmt.Errorf("list users: %w", userError(err))
+	}
+
+	return users, nil
+}
+
+// CountUsers returns the number of matching users
+func (s *userService) CountUsers(ctx context.Context, filter repository.UserFilter) (int64, error) {
+	count, err := s.userRepo.Count(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("count users: %w", userError(err))
+	}
+
+	return count, nil
+}
+
+// SearchUsers runs a full-text search over name and email
+func (s *userService) SearchUsers(ctx context.Context, query string, limit, offset int) ([]repository.SearchResult, error) {
+	results, err := s.userRepo.Search(ctx, query, int32(limit), int32(offset))
+	if err != nil {
+		return nil, fmt.Errorf("search users: %w", userError(err))
+	}
+
+	return results, nil
+}
+
+// CreateUsers validates and creates several users in one transaction
+func (s *userService) CreateUsers(ctx context.Context, inputs []CreateUserInput, atomic bool) ([]CreateResult, error) {
+	results := make([]CreateResult, len(inputs))
+	newUsers := make([]repository.NewUser, 0, len(inputs))
+	// positions maps an index in newUsers back to its index in inputs
+	positions := make([]int, 0, len(inputs))
+
+	for i, input := range inputs {
+		newUser, err := input.toNewUser()
+		if err != nil {
+			if atomic {
+				return nil, fmt.Errorf("create users: %w", &models.BatchItemError{Index: i, Err: userError(err)})
+			}
+			results[i].Err = userError(err)
+			continue
+		}
+		newUsers = append(newUsers, newUser)
+		positions = append(positions, i)
+	}
+
+	if len(newUsers) == 0 {
+		return results, nil
+	}
+
+	batch, err := s.userRepo.CreateBatch(ctx, newUsers, atomic)
+	if err != nil {
+		// In atomic mode every input passed validation, so the index in a
+		// repository BatchItemError is also the input index
+		var itemErr *models.BatchItemError
+		if errors.As(err, &itemErr) {
+			err = &models.BatchItemError{Index: itemErr.Index, Err: userError(itemErr.Err)}
+		}
+		return nil, fmt.Errorf("create users: %w", err)
+	}
+
+	for j, result := range batch {
+		result := CreateResult{User: result.User, Err: result.Err}
+		if result.Err != nil {
+			result.Err = userError(result.Err)
+		}
+		results[positions[j]] = result
+	}
+
+	return results, nil
+}
+
+// UpdateUser validates and applies a partial update
+func (s *userService) UpdateUser(ctx context.Context, id uuid.UUID, version int32, input UpdateUserInput) (*repository.User, error) {
+	upd, err := input.toUserUpdate()
+	if err != nil {
+		return nil, fmt.Errorf("update user: %w", userError(err))
+	}
+
+	user, err := s.userRepo.Update(ctx, id, version, upd)
+	if err != nil {
+		return nil, fmt.Errorf("update user: %w", userError(err))
+	}
+
+	return user, nil
+}
+
+// ChangePassword verifies the current password and stores a hash of the
+// new one
+func (s *userService) ChangePassword(ctx context.Context, id uuid.UUID, input ChangePasswordInput) error {
+	if err := input.Validate(); err != nil {
+		return fmt.Errorf("change password: %w", userError(err))
+	}
+
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("change password: %w", userError(err))
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.CurrentPassword)); err != nil {
+		if !errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return fmt.Errorf("change password: compare hash: %w", err)
+		}
+		wrong := models.ValidationErrors{{Field: "current_password", Message: "is incorrect"}}
+		return fmt.Errorf("change password: %w", userError(wrong))
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("change password: hash password: %w", err)
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, id, string(hash)); err != nil {
+		return fmt.Errorf("change password: %w", userError(err))
+	}
+
+	return nil
+}
+
+// DeleteUser removes a user
+func (s *userService) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	if err := s.userRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("delete user: %w", userError(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/go-starter/internal/models"
+	"github.com/yourusername/go-starter/internal/repository"
+)
+
+func TestUserService_GetUser(t *testing.T) {
+	existing := repository.User{
+		ID:    uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"),
+		Email: "john@example.com",
+		Name:  "John Doe",
+	}
+
+	repo, err := repository.NewSeededMemoryUserRepository(existing)
+	require.NoError(t, err)
+
+	svc := NewUserService(repo)
+
+	tests := []struct {
+		name    string
+		id      uuid.UUID
+		wantErr error
+	}{
+		{
+			name: "success - existing user",
+			id:   existing.ID,
+		},
+		{
+			name:    "error - user not found",
+			id:      uuid.New(),
+			wantErr: models.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.GetUser(context.Background(), tt.id)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, existing.ID, got.ID)
+			assert.Equal(t, existing.Name, got.Name)
+		})
+	}
+}
+
+func TestUserService_GetUserByEmail(t *testing.T) {
+	repo, err := repository.NewSeededMemoryUserRepository(repository.User{
+		Email: "john@example.com",
+		Name:  "John Doe",
+	})
+	require.NoError(t, err)
+
+	svc := NewUserService(repo)
+
+	got, err := svc.GetUserByEmail(context.Background(), "  John@Example.com ")
+	require.NoError(t, err)
+	assert.Equal(t, "john@example.com", got.Email)
+
+	_, err = svc.GetUserByEmail(context.Background(), "missing@example.com")
+	assert.ErrorIs(t, err, models.ErrNotFound)
+}
+
+func TestNewSeededMemoryUserRepository_DuplicateEmail(t *testing.T) {
+	_, err := repository.NewSeededMemoryUserRepository(
+		repository.User{Email: "john@example.com"},
+		repository.User{Email: "JOHN@example.com"},
+	)
+	assert.ErrorIs(t, err, models.ErrEmailAlreadyExists)
+}
+
+func TestUserService_CreateUsers(t *testing.T) {
+	existing := repository.User{Email: "taken@example.com", Name: "Taken"}
+
+	valid := CreateUserInput{Name: "Jane", Email: "jane@example.com", Password: "correct-horse"}
+	invalid := CreateUserInput{Name: "", Email: "not-an-email", Password: "short"}
+	duplicate := CreateUserInput{Name: "Dup", Email: "Taken@example.com", Password: "correct-horse"}
+
+	tests := []struct {
+		name      string
+		inputs    []CreateUserInput
+		atomic    bool
+		wantErr   error
+		wantIndex int
+		wantOK    []bool
+		wantCount int64
+	}{
+		{
+			name:      "partial - invalid and duplicate items are reported, valid one is created",
+			inputs:    []CreateUserInput{invalid, valid, duplicate},
+			wantOK:    []bool{false, true, false},
+			wantCount: 2,
+		},
+		{
+			name:      "atomic - validation error creates nothing",
+			inputs:    []CreateUserInput{valid, invalid},
+			atomic:    true,
+			wantErr:   models.ErrValidation,
+			wantIndex: 1,
+			wantCount: 1,
+		},
+		{
+			name:      "atomic - duplicate email rolls back earlier items",
+			inputs:    []CreateUserInput{valid, duplicate},
+			atomic:    true,
+			wantErr:   models.ErrEmailAlreadyExists,
+			wantIndex: 1,
+			wantCount: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, err := repository.NewSeededMemoryUserRepository(existing)
+			require.NoError(t, err)
+			svc := NewUserService(repo)
+
+			results, err := svc.CreateUsers(context.Background(), tt.inputs, tt.atomic)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				var itemErr *models.BatchItemError
+				require.ErrorAs(t, err, &itemErr)
+				assert.Equal(t, tt.wantIndex, itemErr.Index)
+			} else {
+				require.NoError(t, err)
+				require.Len(t, results, len(tt.wantOK))
+				for i, ok := range tt.wantOK {
+					assert.Equal(t, ok, results[i].Err == nil, "item %d", i)
+				}
+			}
+
+			count, err := repo.Count(context.Background(), repository.UserFilter{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCount, count)
+		})
+	}
+}
+
+func TestCreateUserInput_Validate(t *testing.T) {
+	err := CreateUserInput{Name: " ", Email: "Jane <jane@example.com>", Password: "short"}.Validate()
+
+	var errs models.ValidationErrors
+	require.ErrorAs(t, err, &errs)
+	assert.ErrorIs(t, err, models.ErrValidation)
+
+	fields := make([]string, len(errs))
+	for i, e := range errs {
+		fields[i] = e.Field
+	}
+	assert.Equal(t, []string{"name", "email", "password"}, fields)
+}
+
+func TestUserService_ReturnsAppErrors(t *testing.T) {
+	svc := NewUserService(repository.NewMemoryUserRepository())
+
+	_, err := svc.GetUser(context.Background(), uuid.New())
+
+	var appErr *models.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, 404, appErr.Status)
+	assert.Equal(t, "NOT_FOUND", appErr.Code)
+	// The sentinel is still reachable through the AppError
+	assert.ErrorIs(t, err, models.ErrNotFound)
+}
+
+func TestUserService_DeleteUser(t *testing.T) {
+	existing := repository.User{ID: uuid.New(), Email: "john@example.com", Name: "John Doe"}
+	repo, err := repository.NewSeededMemoryUserRepository(existing)
+	require.NoError(t, err)
+
+	svc := NewUserService(repo)
+
+	require.NoError(t, svc.DeleteUser(context.Background(), existing.ID))
+
+	_, err = svc.GetUser(context.Background(), existing.ID)
+	assert.ErrorIs(t, err, models.ErrNotFound)
+
+	err = svc.DeleteUser(context.Background(), existing.ID)
+	assert.ErrorIs(t, err, models.ErrNotFound)
+}
+
+func TestChangePasswordInput_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      ChangePasswordInput
+		wantFields []string
+	}{
+		{
+			name:  "valid",
+			input: ChangePasswordInput{CurrentPassword: "old-password", NewPassword: "new-password"},
+		},
+		{
+			name:       "missing current and short new",
+			input:      ChangePasswordInput{NewPassword: "short"},
+			wantFields: []string{"current_password", "new_password"},
+		},
+		{
+			name:       "unchanged",
+			input:      ChangePasswordInput{CurrentPassword: "old-password", NewPassword: "old-password"},
+			wantFields: []string{"new_password"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.input.Validate()
+			if tt.wantFields == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var errs models.ValidationErrors
+			require.ErrorAs(t, err, &errs)
+			fields := make([]string, len(errs))
+			for i, e := range errs {
+				fields[i] = e.Field
+			}
+			assert.Equal(t, tt.wantFields, fields)
+		})
+	}
+}
+
+// blockingUserRepository counts GetByID calls and holds each one until
+// release is closed
+type blockingUserRepository struct {
+	repository.UserRepository
+	calls   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+	err     error
+}
+
+func (r *blockingUserRepository) GetByID(_ context.Context, id uuid.UUID) (*repository.User, error) {
+	if r.calls.Add(1) == 1 {
+		close(r.started)
+	}
+	<-r.release
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &repository.User{ID: id, Name: "Jane"}, nil
+}
+
+func TestUserService_GetUser_SharesConcurrentLookups(t *testing.T) {
+	const callers = 20
+	repo := &blockingUserRepository{started: make(chan struct{}), release: make(chan struct{})}
+	svc := NewUserService(repo)
+	id := uuid.New()
+
+	var wg sync.WaitGroup
+	users := make([]*repository.User, callers)
+	errs := make([]error, callers)
+	get := func(ctx context.Context, i int) {
+		defer wg.Done()
+		users[i], errs[i] = svc.GetUser(ctx, id)
+	}
+
+	// The first caller gives up while the query is in flight; the others
+	// must still get the result
+	shared := userGetShared.Value()
+	ctx, cancel := context.WithCancel(context.Background())
+	wg.Add(1)
+	go get(ctx, 0)
+	<-repo.started
+	for i := 1; i < callers; i++ {
+		wg.Add(1)
+		go get(context.Background(), i)
+	}
+	cancel()
+	// Give the callers time to join the in-flight query
+	time.Sleep(50 * time.Millisecond)
+	close(repo.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), repo.calls.Load())
+	assert.Equal(t, shared+callers-1, userGetShared.Value(), "every caller still waiting got the shared result")
+	assert.ErrorIs(t, errs[0], context.Canceled)
+	for i := 1; i < callers; i++ {
+		require.NoError(t, errs[i])
+		assert.Equal(t, id, users[i].ID)
+	}
+	users[1].Name = "changed"
+	assert.Equal(t, "Jane", users[2].Name, "callers must not share one *User")
+}
+
+func TestUserService_GetUser_DoesNotRememberErrors(t *testing.T) {
+	repo := &blockingUserRepository{started: make(chan struct{}), release: make(chan struct{}), err: errors.New("boom")}
+	close(repo.release)
+	svc := NewUserService(repo)
+	id := uuid.New()
+
+	_, err := svc.GetUser(context.Background(), id)
+	assert.Error(t, err)
+
+	repo.err = nil
+	user, err := svc.GetUser(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, id, user.ID)
+	assert.Equal(t, int32(2), repo.calls.Load())
+}
+
+func TestUserService_GetUserWithProfile(t *testing.T) {
+	existing := repository.User{
+		ID:    uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"),
+		Email: "john@example.com",
+		Name:  "John Doe",
+	}
+	repo, err := repository.NewSeededMemoryUserRepository(existing)
+	require.NoError(t, err)
+
+	svc := NewUserService(repo)
+
+	// No profile is a normal result, not an error
+	got, err := svc.GetUserWithProfile(context.Background(), existing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, existing.Name, got.User.Name)
+	assert.Nil(t, got.Profile)
+
+	_, err = svc.GetUserWithProfile(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, models.ErrNotFound)
+}
@@ -0,0 +1,2 @@
+-- Drop row version
+ALTER TABLE users DROP COLUMN IF EXISTS version;
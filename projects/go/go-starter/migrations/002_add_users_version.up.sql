@@ -0,0 +1,2 @@
+-- Add a row version for optimistic concurrency control; every UPDATE bumps it
+ALTER TABLE users ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+-- Role used for authorization; carried in the JWT claims
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(32) NOT NULL DEFAULT 'user'
+    CHECK (role IN ('user', 'admin'));
@@ -0,0 +1,5 @@
+-- Full-text index for GET /api/v1/users/search. The 'simple' configuration
+-- lowercases without stemming, which suits names. SearchUsers in
+-- queries/users.sql must use the exact same expression to hit the index.
+CREATE INDEX IF NOT EXISTS idx_users_search ON users
+    USING GIN (to_tsvector('simple', name || ' ' || email));
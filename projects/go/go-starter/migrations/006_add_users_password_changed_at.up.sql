@@ -0,0 +1,2 @@
+-- NULL until the user changes their password for the first time
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_changed_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,34 @@
+// Package migrations embeds the SQL migrations so that the binary knows the
+// schema version it was built for. They are applied with golang-migrate
+// (make migrate-up), which records its progress in schema_migrations.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// FS holds the migration files
+//
+//go:embed *.sql
+var FS embed.FS
+
+// Latest returns the highest migration version, i.e. the schema version the
+// code expects. Files are named <version>_<name>.up.sql/.down.sql.
+func Latest() int64 {
+	entries, _ := fs.ReadDir(FS, ".")
+
+	var latest int64
+	for _, entry := range entries {
+		prefix, _, ok := strings.Cut(entry.Name(), "_")
+		if !ok {
+			continue
+		}
+		if version, err := strconv.ParseInt(prefix, 10, 64); err == nil && version > latest {
+			latest = version
+		}
+	}
+	return latest
+}
@@ -0,0 +1,12 @@
+package migrations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatest(t *testing.T) {
+	// Bump this with every new migration
+	assert.Equal(t, int64(8), Latest())
+}
@@ -0,0 +1,17 @@
+-- name: InsertAuditEntry :exec
+INSERT INTO audit_log (
+    action,
+    resource_type,
+    resource_id,
+    actor_id,
+    request_id
+) VALUES (
+    $1, $2, $3, $4, $5
+);
+
+-- name: ListAuditEntries :many
+-- Newest first; id breaks ties between entries of the same transaction
+SELECT * FROM audit_log
+WHERE resource_type = sqlc.arg('resource_type') AND resource_id = sqlc.arg('resource_id')
+ORDER BY id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
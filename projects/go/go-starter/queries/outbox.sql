@@ -0,0 +1,37 @@
+-- name: InsertOutboxEvent :exec
+INSERT INTO outbox (
+    event_type,
+    aggregate_id,
+    payload
+) VALUES (
+    $1, $2, $3
+);
+
+-- name: ClaimOutboxEvents :many
+-- Leases a batch of undelivered events so that concurrent pollers don't
+-- pick up the same rows; a lease that runs out makes the row claimable
+-- again. Rows that failed max_attempts times are left for an operator.
+UPDATE outbox
+SET locked_until = NOW() + sqlc.arg('lease_seconds')::int * INTERVAL '1 second'
+WHERE id IN (
+    SELECT id FROM outbox
+    WHERE published_at IS NULL
+      AND attempts < sqlc.arg('max_attempts')::int
+      AND (locked_until IS NULL OR locked_until < NOW())
+    ORDER BY id
+    LIMIT sqlc.arg('batch_size')::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkOutboxEventPublished :exec
+UPDATE outbox
+SET published_at = NOW(), locked_until = NULL
+WHERE id = $1;
+
+-- name: RecordOutboxEventFailure :exec
+UPDATE outbox
+SET attempts = attempts + 1,
+    last_error = sqlc.arg('last_error')::text,
+    locked_until = NULL
+WHERE id = sqlc.arg('id');
@@ -12,27 +12,63 @@ RETURNING *;
 SELECT * FROM users
 WHERE id = $1 LIMIT 1;
 
--- name: GetUserByEmail :one
+-- name: GetUserWithProfile :one
+-- The profile columns are all NULL when the user has no profile
+SELECT sqlc.embed(users),
+    profiles.id AS profile_id,
+    profiles.bio AS profile_bio,
+    profiles.avatar_url AS profile_avatar_url,
+    profiles.created_at AS profile_created_at,
+    profiles.updated_at AS profile_updated_at
+FROM users
+LEFT JOIN profiles ON profiles.user_id = users.id
+WHERE users.id = $1 LIMIT 1;
+
+-- name: GetUsersByIDs :many
+-- Missing ids are simply absent from the result; order is unspecified
 SELECT * FROM users
-WHERE email = $1 LIMIT 1;
+WHERE id = ANY(sqlc.arg('ids')::uuid[]);
 
--- name: ListUsers :many
+-- name: GetUserByEmail :one
 SELECT * FROM users
-ORDER BY created_at DESC
-LIMIT $1 OFFSET $2;
+WHERE email = $1 LIMIT 1;
 
 -- name: UpdateUser :one
 UPDATE users
 SET 
     email = COALESCE(sqlc.narg('email'), email),
     name = COALESCE(sqlc.narg('name'), name),
-    password_hash = COALESCE(sqlc.narg('password_hash'), password_hash)
-WHERE id = sqlc.arg('id')
+    password_hash = COALESCE(sqlc.narg('password_hash'), password_hash),
+    version = version + 1
+WHERE id = sqlc.arg('id') AND version = sqlc.arg('version')
 RETURNING *;
 
--- name: DeleteUser :exec
+-- name: UpdateUserPassword :execrows
+UPDATE users
+SET
+    password_hash = sqlc.arg('password_hash'),
+    password_changed_at = NOW(),
+    version = version + 1
+WHERE id = sqlc.arg('id');
+
+-- name: DeleteUser :execrows
 DELETE FROM users
 WHERE id = $1;
 
+-- ListUsers lives in internal/repository/user_list.go: sqlc can't
+-- parameterize ORDER BY, and the list endpoint takes a client-chosen sort.
+-- Keep its WHERE clause in sync with CountUsers.
+
 -- name: CountUsers :one
-SELECT COUNT(*) FROM users;
+SELECT COUNT(*) FROM users
+WHERE (sqlc.narg('email_pattern')::text IS NULL OR email ILIKE sqlc.narg('email_pattern'))
+  AND (sqlc.narg('name_pattern')::text IS NULL OR name ILIKE sqlc.narg('name_pattern'));
+
+-- name: SearchUsers :many
+-- The expression matches idx_users_search; keep them in sync
+SELECT id, email, name, password_hash, created_at, updated_at, version, role,
+    ts_rank(to_tsvector('simple', name || ' ' || email), plainto_tsquery('simple', sqlc.arg('query')))::real AS score
+FROM users
+WHERE to_tsvector('simple', name || ' ' || email) @@ plainto_tsquery('simple', sqlc.arg('query'))
+ORDER BY score DESC, id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"io/fs"
+	"log"
+	"mime"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"httpgo/internal/request"
+	"httpgo/internal/response"
+	"httpgo/internal/server"
+)
+
+// shutdownTimeout bounds how long in-flight downloads may take to finish
+const shutdownTimeout = 30 * time.Second
+
+// fileServer serves the files under root for GET and HEAD. Paths go
+// through DecodedPath, and files are opened through root, so neither ".."
+// nor a symlink can reach outside it. A directory serves its index.html.
+func fileServer(root *os.Root) server.Handler {
+	return func(w *response.Writer, req *request.Request) {
+		method := req.RequestLine.Method
+		if method != request.MethodGet && method != request.MethodHead {
+			writeStatus(w, req, response.StatusMethodNotAllowed)
+			return
+		}
+
+		name, err := req.RequestLine.DecodedPath()
+		switch {
+		case errors.Is(err, request.ErrPathTraversal):
+			writeStatus(w, req, response.StatusForbidden)
+			return
+		case err != nil:
+			writeStatus(w, req, response.StatusBadRequest)
+			return
+		}
+
+		f, info, err := open(root, strings.TrimPrefix(name, "/"))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			writeStatus(w, req, response.StatusNotFound)
+			return
+		case err != nil:
+			// Permission denied, or a symlink pointing outside root
+			log.Println(err)
+			writeStatus(w, req, response.StatusForbidden)
+			return
+		}
+		defer f.Close()
+
+		contentType := mime.TypeByExtension(path.Ext(info.Name()))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		h := response.GetDefaultHeaders(int(info.Size()), req)
+		h.Set("Content-Type", contentType)
+
+		if err := w.WriteStatusLine(response.StatusOK); err != nil {
+			log.Println(err)
+			return
+		}
+		if err := w.WriteHeaders(h); err != nil {
+			log.Println(err)
+			return
+		}
+		if method == request.MethodHead {
+			return
+		}
+		if _, err := io.Copy(bodyWriter{w}, f); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// open opens name under root, or the index.html of a directory
+func open(root *os.Root, name string) (*os.File, fs.FileInfo, error) {
+	if name == "" {
+		name = "."
+	}
+
+	f, err := root.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if !info.IsDir() {
+		return f, info, nil
+	}
+
+	f.Close()
+	f, err = root.Open(path.Join(name, "index.html"))
+	if err != nil {
+		return nil, nil, err
+	}
+	if info, err = f.Stat(); err != nil || info.IsDir() {
+		f.Close()
+		return nil, nil, fs.ErrNotExist
+	}
+	return f, info, nil
+}
+
+// bodyWriter lets io.Copy stream into a response body
+type bodyWriter struct {
+	w *response.Writer
+}
+
+func (bw bodyWriter) Write(b []byte) (int, error) {
+	return bw.w.WriteBody(b)
+}
+
+// writeStatus answers with status and its reason phrase as the body
+func writeStatus(w *response.Writer, req *request.Request, status response.StatusCode) {
+	body := []byte(response.StatusText(status) + "\n")
+	if err := w.WriteStatusLine(status); err != nil {
+		log.Println(err)
+		return
+	}
+	if err := w.WriteHeaders(response.GetDefaultHeaders(len(body), req)); err != nil {
+		log.Println(err)
+		return
+	}
+	if _, err := w.WriteBody(body); err != nil {
+		log.Println(err)
+	}
+}
+
+func main() {
+	addr := flag.String("addr", ":42069", "TCP address to listen on")
+	dir := flag.String("root", ".", "directory to serve")
+	flag.Parse()
+
+	root, err := os.OpenRoot(*dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer root.Close()
+
+	srv, err := server.Serve(*addr, fileServer(root))
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("serving %s on %s", *dir, srv.Addr())
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	<-sigChan
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Fatal(err)
+	}
+	log.Println("server gracefully stopped")
+}
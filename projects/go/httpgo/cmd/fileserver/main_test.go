@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"httpgo/internal/server"
+)
+
+func TestFileServer(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "hello.txt"), []byte("hello, world\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.html"), []byte("<h1>home</h1>"), 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "empty"), 0o755))
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	require.NoError(t, os.WriteFile(outside, []byte("secret"), 0o644))
+	require.NoError(t, os.Symlink(outside, filepath.Join(dir, "link.txt")))
+
+	root, err := os.OpenRoot(dir)
+	require.NoError(t, err)
+	defer root.Close()
+
+	srv, err := server.Serve("127.0.0.1:0", fileServer(root))
+	require.NoError(t, err)
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		wantStatus int
+		wantType   string
+		wantBody   string
+	}{
+		{name: "file", target: "/hello.txt", wantStatus: http.StatusOK, wantType: "text/plain; charset=utf-8", wantBody: "hello, world\n"},
+		{name: "head", method: "HEAD", target: "/hello.txt", wantStatus: http.StatusOK, wantType: "text/plain; charset=utf-8"},
+		{name: "directory index", target: "/", wantStatus: http.StatusOK, wantType: "text/html; charset=utf-8", wantBody: "<h1>home</h1>"},
+		{name: "directory without index", target: "/empty/", wantStatus: http.StatusNotFound},
+		{name: "missing file", target: "/nope.txt", wantStatus: http.StatusNotFound},
+		{name: "traversal", target: "/../../etc/passwd", wantStatus: http.StatusForbidden},
+		{name: "encoded traversal", target: "/%2e%2e/etc/passwd", wantStatus: http.StatusForbidden},
+		{name: "symlink out of root", target: "/link.txt", wantStatus: http.StatusForbidden},
+		{name: "encoded slash", target: "/a%2F..%2Fhello.txt", wantStatus: http.StatusBadRequest},
+		{name: "other method", method: "DELETE", target: "/hello.txt", wantStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = "GET"
+			}
+
+			// A raw request, since http.Client would clean the path
+			conn, err := net.Dial("tcp", srv.Addr().String())
+			require.NoError(t, err)
+			defer conn.Close()
+			_, err = io.WriteString(conn, method+" "+tt.target+" HTTP/1.1\r\nConnection: close\r\n\r\n")
+			require.NoError(t, err)
+
+			resp, err := http.ReadResponse(bufio.NewReader(conn), &http.Request{Method: method})
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tt.wantType, resp.Header.Get("Content-Type"))
+			assert.Equal(t, tt.wantBody, string(body))
+			if method == "HEAD" {
+				assert.Equal(t, "13", resp.Header.Get("Content-Length"))
+			}
+		})
+	}
+}
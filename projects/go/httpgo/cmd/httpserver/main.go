@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"httpgo/internal/request"
+	"httpgo/internal/response"
+	"httpgo/internal/server"
+)
+
+// shutdownTimeout bounds how long in-flight requests may take to finish
+const shutdownTimeout = 5 * time.Second
+
+// echo answers every request with its request target
+func echo(w *response.Writer, req *request.Request) {
+	body := []byte(req.RequestLine.RequestTarget + "\n")
+	if err := w.WriteStatusLine(response.StatusOK); err != nil {
+		log.Println(err)
+		return
+	}
+	if err := w.WriteHeaders(response.GetDefaultHeaders(len(body), req)); err != nil {
+		log.Println(err)
+		return
+	}
+	if _, err := w.WriteBody(body); err != nil {
+		log.Println(err)
+	}
+}
+
+func main() {
+	addr := flag.String("addr", ":42069", "TCP address to listen on")
+	flag.Parse()
+
+	srv, err := server.Serve(*addr, echo)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("server started on", srv.Addr())
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	<-sigChan
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Fatal(err)
+	}
+	log.Println("server gracefully stopped")
+}
@@ -1,70 +1,204 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net"
+	"os"
+	"os/signal"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 )
 
-func getLinesChannel(f io.ReadCloser) <-chan string {
+// DefaultMaxLineLength is the longest line LinesChannel accepts when given
+// a maxLineLength of 0
+const DefaultMaxLineLength = bufio.MaxScanTokenSize
+
+// LinesChannel streams the lines read from r, without their "\n" or "\r\n"
+// endings, and closes the lines channel once r is exhausted. A final line
+// that doesn't end in a newline is still sent.
+//
+// Once the lines channel is closed, the error channel yields the read error
+// that stopped it, or nil if r simply reached EOF. A line longer than
+// maxLineLength bytes stops reading with bufio.ErrTooLong.
+func LinesChannel(r io.Reader, maxLineLength int) (<-chan string, <-chan error) {
+	if maxLineLength <= 0 {
+		maxLineLength = DefaultMaxLineLength
+	}
+
+	lines := make(chan string)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(lines)
+
+		scanner := bufio.NewScanner(r)
+		// The limit includes the newline that ends the line
+		scanner.Buffer(make([]byte, 0, min(4096, maxLineLength+1)), maxLineLength+1)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		errs <- scanner.Err()
+	}()
+
+	return lines, errs
+}
+
+// getLinesChannel streams the lines read from f, without their "\n" or
+// "\r\n" endings. The channel is closed once f is exhausted; a final line
+// that doesn't end in a newline is still sent.
+//
+// Cancelling ctx closes f, which unblocks a pending Read, and the goroutine
+// closes the channel and returns without sending anything more. Consumers
+// that stop ranging early must cancel ctx, or the goroutine leaks.
+//
+// Deprecated: getLinesChannel only logs read errors, so callers can't tell
+// them from EOF, and it has no bound on line length. Use LinesChannel.
+func getLinesChannel(ctx context.Context, f io.ReadCloser) <-chan string {
 	strChan := make(chan string)
 
 	var a = make([]byte, 8)
 	var line string
 
 	go func() {
+		defer close(strChan)
+		stop := context.AfterFunc(ctx, func() { f.Close() })
+		defer stop()
+
+		send := func(s string) bool {
+			select {
+			case strChan <- strings.TrimSuffix(s, "\r"):
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
 		for {
-			isEOF, err := f.Read(a)
-			if isEOF == 0 || err != nil {
-				if line != "" {
-					strChan <- line
+			n, err := f.Read(a)
+			if n > 0 {
+				parts := strings.Split(string(a[:n]), "\n")
+				for _, part := range parts[:len(parts)-1] {
+					if !send(line + part) {
+						return
+					}
+					line = ""
 				}
-				return
+				line += parts[len(parts)-1]
 			}
 
-			stringedA := string(a)
-
-			if strings.Contains(stringedA, "\r\n") {
-				str := strings.Split(stringedA, "\r\n")
-				line = line + strings.Join(str[:1], "")
-				strChan <- line
-
-				line = ""
-
-				line = line + strings.Join(str[1:], "")
-				continue
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				if line != "" {
+					send(line)
+				}
+				if !errors.Is(err, io.EOF) {
+					log.Println(err)
+				}
+				return
 			}
-
-			line = line + string(a)
 		}
 	}()
 
 	return strChan
 }
 
-func main() {
-	listener, err := net.Listen("tcp", ":42069")
-	if err != nil {
-		fmt.Println(err)
-	}
+// drainTimeout is how long open connections get to finish after shutdown
+// starts before they are closed
+const drainTimeout = 5 * time.Second
+
+// serve prints the lines of every connection accepted on l to out. When ctx
+// is cancelled it closes l, gives the open connections drainTimeout to end
+// on their own, then closes them, and returns once every connection
+// goroutine has exited.
+func serve(ctx context.Context, l net.Listener, out io.Writer, drainTimeout time.Duration) {
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		conns = make(map[net.Conn]struct{})
+	)
 
-	defer listener.Close()
+	stop := context.AfterFunc(ctx, func() { l.Close() })
+	defer stop()
 
 	for {
-		conn, err := listener.Accept()
+		conn, err := l.Accept()
 		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				break
+			}
 			log.Println(err)
+			continue
 		}
 
+		mu.Lock()
+		conns[conn] = struct{}{}
+		mu.Unlock()
+
+		wg.Add(1)
 		go func() {
-			defer conn.Close()
-			for s := range getLinesChannel(conn) {
-				fmt.Println(s)
+			defer wg.Done()
+			defer func() {
+				mu.Lock()
+				delete(conns, conn)
+				mu.Unlock()
+				conn.Close()
+			}()
+
+			lines, errs := LinesChannel(conn, 0)
+			for s := range lines {
+				mu.Lock()
+				fmt.Fprintln(out, s)
+				mu.Unlock()
+			}
+			if err := <-errs; err != nil && !errors.Is(err, net.ErrClosed) {
+				log.Println(err)
 			}
 
-			fmt.Println("connection closed!")
+			mu.Lock()
+			fmt.Fprintln(out, "connection closed!")
+			mu.Unlock()
 		}()
 	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return
+	case <-time.After(drainTimeout):
+	}
+
+	mu.Lock()
+	for conn := range conns {
+		conn.Close()
+	}
+	mu.Unlock()
+	<-done
+}
+
+func main() {
+	listener, err := net.Listen("tcp", ":42069")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serve(ctx, listener, os.Stdout, drainTimeout)
+	log.Println("listener stopped")
 }
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func collect(lines <-chan string) []string {
+	var got []string
+	for line := range lines {
+		got = append(got, line)
+	}
+	return got
+}
+
+func TestGetLinesChannel(t *testing.T) {
+	f, err := os.Open("testdata/message.txt")
+	require.NoError(t, err)
+	defer f.Close()
+
+	// Lines are longer than the 8 byte read buffer, and the last one has no
+	// newline
+	assert.Equal(t, []string{
+		"Do you have what it takes?",
+		"Are you willing to work 80 hours a week?",
+		"end without a newline",
+	}, collect(getLinesChannel(context.Background(), f)))
+}
+
+func TestGetLinesChannel_Empty(t *testing.T) {
+	assert.Empty(t, collect(getLinesChannel(context.Background(), io.NopCloser(strings.NewReader("")))))
+	assert.Equal(t, []string{"", "a"}, collect(getLinesChannel(context.Background(), io.NopCloser(strings.NewReader("\na\n")))))
+}
+
+func TestLinesChannel(t *testing.T) {
+	f, err := os.Open("testdata/message.txt")
+	require.NoError(t, err)
+	defer f.Close()
+
+	lines, errs := LinesChannel(f, 0)
+	assert.Equal(t, []string{
+		"Do you have what it takes?",
+		"Are you willing to work 80 hours a week?",
+		"end without a newline",
+	}, collect(lines))
+	assert.NoError(t, <-errs)
+}
+
+func TestLinesChannel_EOFLine(t *testing.T) {
+	// "EOF" is data like any other line
+	lines, errs := LinesChannel(strings.NewReader("a\r\nEOF\nb"), 0)
+	assert.Equal(t, []string{"a", "EOF", "b"}, collect(lines))
+	assert.NoError(t, <-errs)
+}
+
+func TestLinesChannel_MaxLineLength(t *testing.T) {
+	lines, errs := LinesChannel(strings.NewReader("12345\n123456\n"), 5)
+	assert.Equal(t, []string{"12345"}, collect(lines))
+	assert.ErrorIs(t, <-errs, bufio.ErrTooLong)
+}
+
+func TestLinesChannel_ReadError(t *testing.T) {
+	errBoom := errors.New("boom")
+	r := io.MultiReader(strings.NewReader("a\nb"), iotest.ErrReader(errBoom))
+
+	// The partial line read before the error is still sent
+	lines, errs := LinesChannel(r, 0)
+	assert.Equal(t, []string{"a", "b"}, collect(lines))
+	assert.ErrorIs(t, <-errs, errBoom)
+}
+
+func TestGetLinesChannel_Cancel(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	// The writer is never closed, so without cancellation the reading
+	// goroutine would block forever
+	pr, pw := io.Pipe()
+	go func() {
+		for {
+			if _, err := io.WriteString(pw, "line\n"); err != nil {
+				return
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lines := getLinesChannel(ctx, pr)
+	assert.Equal(t, "line", <-lines)
+	assert.Equal(t, "line", <-lines)
+	cancel()
+
+	// Drain whatever was in flight; the channel must get closed
+	for range lines {
+	}
+}
+
+func TestGetLinesChannel_CancelBlockedRead(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	pr, _ := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	lines := getLinesChannel(ctx, pr)
+	cancel()
+
+	_, ok := <-lines
+	assert.False(t, ok)
+}
+
+// syncBuffer is a strings.Builder safe for the test to read while serve
+// writes to it
+type syncBuffer struct {
+	mu sync.Mutex
+	b  strings.Builder
+}
+
+func (sb *syncBuffer) Write(p []byte) (int, error) {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	return sb.b.Write(p)
+}
+
+func (sb *syncBuffer) String() string {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	return sb.b.String()
+}
+
+// startServe runs serve on a local port until the returned stop is called,
+// which waits for serve to return
+func startServe(t testing.TB, out io.Writer, drainTimeout time.Duration) (addr string, stop func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		serve(ctx, l, out, drainTimeout)
+	}()
+
+	return l.Addr().String(), func() {
+		cancel()
+		<-done
+	}
+}
+
+func TestServe(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	var out syncBuffer
+	addr, stop := startServe(t, &out, time.Second)
+
+	for range 3 {
+		conn, err := net.Dial("tcp", addr)
+		require.NoError(t, err)
+		_, err = io.WriteString(conn, "hello\r\nworld\n")
+		require.NoError(t, err)
+		require.NoError(t, conn.Close())
+	}
+
+	require.Eventually(t, func() bool {
+		return strings.Count(out.String(), "connection closed!") == 3
+	}, time.Second, 10*time.Millisecond)
+	stop()
+
+	assert.Equal(t, 3, strings.Count(out.String(), "hello\nworld\n"))
+}
+
+func TestServe_ShutdownClosesIdleConnections(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	var out syncBuffer
+	addr, stop := startServe(t, &out, 50*time.Millisecond)
+
+	// The client never closes this connection itself
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = io.WriteString(conn, "still here\n")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return strings.Contains(out.String(), "still here")
+	}, time.Second, 10*time.Millisecond)
+
+	stop()
+
+	assert.Contains(t, out.String(), "connection closed!")
+	_, err = net.Dial("tcp", addr)
+	assert.Error(t, err)
+}
+
+func BenchmarkServe_Connection(b *testing.B) {
+	addr, stop := startServe(b, io.Discard, time.Second)
+	defer stop()
+
+	before := runtime.NumGoroutine()
+	b.ReportAllocs()
+	for b.Loop() {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			b.Fatal(err)
+		}
+		io.WriteString(conn, "one\ntwo\nthree\n")
+		conn.Close()
+	}
+	b.StopTimer()
+
+	// Goroutines still alive once the connections have had time to end;
+	// this should settle at zero
+	time.Sleep(100 * time.Millisecond)
+	b.ReportMetric(float64(runtime.NumGoroutine()-before), "leaked-goroutines")
+}
@@ -2,37 +2,148 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"os"
+	"strings"
+	"time"
 )
 
-func main() {
-	udpAddr, err := net.ResolveUDPAddr("udp", "localhost:42069")
+const (
+	maxRedials     = 5
+	initialBackoff = 100 * time.Millisecond
+)
+
+func dial(addr string) (*net.UDPConn, error) {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
 	if err != nil {
-		log.Println(err)
-		return
+		return nil, err
 	}
 
-	udpConn, err := net.DialUDP("udp", nil, udpAddr)
+	return net.DialUDP("udp", nil, udpAddr)
+}
+
+// redial retries dial with exponential backoff, giving up after maxRedials attempts.
+func redial(addr string) (*net.UDPConn, error) {
+	backoff := initialBackoff
+
+	var err error
+	for attempt := 1; attempt <= maxRedials; attempt++ {
+		var conn *net.UDPConn
+		conn, err = dial(addr)
+		if err == nil {
+			return conn, nil
+		}
+
+		log.Printf("redial %d/%d failed: %v", attempt, maxRedials, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+
+	return nil, fmt.Errorf("giving up after %d attempts: %w", maxRedials, err)
+}
+
+// send writes msg, re-dialing once the socket reports an error. It returns the
+// connection to keep using, which may differ from conn after a re-dial.
+func send(conn *net.UDPConn, addr string, msg []byte) (*net.UDPConn, error) {
+	_, err := conn.Write(msg)
+	if err == nil {
+		return conn, nil
+	}
+	log.Println(err)
+
+	conn.Close()
+	conn, err = redial(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.Write(msg); err != nil {
+		return conn, err
+	}
+
+	return conn, nil
+}
+
+// message is one line of input as sent in -json mode
+type message struct {
+	Seq uint64 `json:"seq"`
+	Msg string `json:"msg"`
+}
+
+// frame turns line into a single datagram numbered seq, so a receiver can
+// spot gaps (lost datagrams) and out-of-order arrivals. Each datagram holds
+// exactly one newline-terminated message: "<seq> <line>\n", or with
+// asJSON, {"seq":<seq>,"msg":"<line>"} followed by a newline.
+func frame(seq uint64, line string, asJSON bool) ([]byte, error) {
+	line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+	if !asJSON {
+		return fmt.Appendf(nil, "%d %s\n", seq, line), nil
+	}
+
+	b, err := json.Marshal(message{Seq: seq, Msg: line})
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+func main() {
+	addr := flag.String("addr", "localhost:42069", "UDP address to send to")
+	asJSON := flag.Bool("json", false, `send each line as {"seq":N,"msg":"..."} instead of "N msg"`)
+	flag.Parse()
+
+	udpConn, err := dial(*addr)
 	if err != nil {
 		log.Println(err)
 		return
 	}
 
-	defer udpConn.Close()
+	defer func() {
+		if udpConn != nil {
+			udpConn.Close()
+		}
+	}()
 
 	ioReader := bufio.NewReader(os.Stdin)
+	var seq uint64
 
 	for {
 		fmt.Print(">")
-		str, err := ioReader.ReadString('\n')
-		if err != nil {
-			log.Println(err)
+		str, readErr := ioReader.ReadString('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			log.Println(readErr)
+			return
+		}
+
+		if str != "" {
+			// Every line uses up a sequence number, even if sending it
+			// fails, so the receiver sees the gap
+			seq++
+			datagram, err := frame(seq, str, *asJSON)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			udpConn, err = send(udpConn, *addr, datagram)
+			if err != nil {
+				log.Println(err)
+				if udpConn == nil {
+					return
+				}
+			}
 		}
 
-		udpConn.Write([]byte(str))
+		// Ctrl-D closes stdin; stop instead of spinning on repeated EOFs.
+		if errors.Is(readErr, io.EOF) {
+			fmt.Println()
+			return
+		}
 	}
 
 }
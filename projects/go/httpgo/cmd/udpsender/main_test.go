@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrame(t *testing.T) {
+	tests := []struct {
+		name   string
+		seq    uint64
+		line   string
+		asJSON bool
+		want   string
+	}{
+		{name: "plain", seq: 1, line: "hello\n", want: "1 hello\n"},
+		{name: "plain crlf", seq: 2, line: "hello\r\n", want: "2 hello\n"},
+		{name: "plain without newline", seq: 3, line: "last", want: "3 last\n"},
+		{name: "json", seq: 4, line: "hello\n", asJSON: true, want: `{"seq":4,"msg":"hello"}` + "\n"},
+		{name: "json escapes", seq: 5, line: "say \"hi\"\ttab\n", asJSON: true, want: `{"seq":5,"msg":"say \"hi\"\ttab"}` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := frame(tt.seq, tt.line, tt.asJSON)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestSend_Framed(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pc.Close()
+
+	conn, err := dial(pc.LocalAddr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	for i, line := range []string{"first\n", "second\n"} {
+		datagram, err := frame(uint64(i+1), line, true)
+		require.NoError(t, err)
+		conn, err = send(conn, pc.LocalAddr().String(), datagram)
+		require.NoError(t, err)
+	}
+
+	buf := make([]byte, 1024)
+	require.NoError(t, pc.SetReadDeadline(time.Now().Add(time.Second)))
+	for _, want := range []message{{Seq: 1, Msg: "first"}, {Seq: 2, Msg: "second"}} {
+		n, _, err := pc.ReadFrom(buf)
+		require.NoError(t, err)
+
+		var got message
+		require.NoError(t, json.Unmarshal(buf[:n], &got))
+		assert.Equal(t, want, got)
+	}
+}
@@ -0,0 +1,83 @@
+package request
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// parseChunked decodes a "Transfer-Encoding: chunked" body: chunks of
+// "<hex size>\r\n<data>\r\n" ending with a zero-size chunk, then trailer
+// fields up to a blank line
+func (p *parser) parseChunked(data []byte) (int, error) {
+	switch p.state {
+	case parsingChunkSize:
+		line, n := cutLine(data)
+		if n == 0 {
+			return 0, nil
+		}
+		size, err := parseChunkSize(line)
+		if err != nil {
+			return 0, err
+		}
+		if size > p.maxBodySize-len(p.req.Body) {
+			return 0, fmt.Errorf("%w: chunked body exceeds %d bytes", ErrBodyTooLarge, p.maxBodySize)
+		}
+		p.chunkRemaining = size
+		p.state = parsingChunkData
+		if size == 0 {
+			p.state = parsingTrailers
+		}
+		return n, nil
+
+	case parsingChunkData:
+		n := min(len(data), p.chunkRemaining)
+		p.req.Body = append(p.req.Body, data[:n]...)
+		p.chunkRemaining -= n
+		if p.chunkRemaining == 0 {
+			p.state = parsingChunkEnd
+		}
+		return n, nil
+
+	case parsingChunkEnd:
+		if len(data) < len(crlf) {
+			return 0, nil
+		}
+		if !bytes.HasPrefix(data, []byte(crlf)) {
+			return 0, errors.New("chunk data is not followed by CRLF")
+		}
+		p.state = parsingChunkSize
+		return len(crlf), nil
+
+	case parsingTrailers:
+		line, n := cutLine(data)
+		if n == 0 {
+			return 0, nil
+		}
+		if len(line) == 0 {
+			p.state = done
+			return n, nil
+		}
+		if err := p.req.Trailers.parseLine(line); err != nil {
+			return 0, err
+		}
+		return n, nil
+	}
+
+	return 0, nil
+}
+
+// parseChunkSize reads the hex size of a chunk, ignoring chunk extensions
+func parseChunkSize(line []byte) (int, error) {
+	if i := bytes.IndexByte(line, ';'); i != -1 {
+		line = line[:i]
+	}
+	line = bytes.TrimRight(line, " \t")
+
+	size, err := strconv.ParseInt(string(line), 16, 32)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid chunk size %q", line)
+	}
+	return int(size), nil
+}
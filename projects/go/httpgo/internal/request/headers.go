@@ -0,0 +1,73 @@
+package request
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Headers holds header field values by lowercased name. Repeated fields are
+// joined with ", ".
+type Headers map[string]string
+
+// commonNames are header names that are stored without allocating a new
+// string for every request
+var commonNames = []string{
+	"accept",
+	"accept-encoding",
+	"accept-language",
+	"authorization",
+	"cache-control",
+	"connection",
+	"content-length",
+	"content-type",
+	"cookie",
+	"host",
+	"user-agent",
+}
+
+// internName returns name as a string, reusing a common one when possible
+func internName(name []byte) string {
+	for _, common := range commonNames {
+		if string(name) == common {
+			return common
+		}
+	}
+	return string(name)
+}
+
+// Get returns the value of the named field, ignoring case
+func (h Headers) Get(name string) (string, bool) {
+	value, ok := h[strings.ToLower(name)]
+	return value, ok
+}
+
+// Set replaces the value of the named field
+func (h Headers) Set(name, value string) {
+	h[strings.ToLower(name)] = value
+}
+
+// parseLine adds one "Name: value" field line. No whitespace is allowed
+// between the name and the colon. The name is lowercased in place, so line
+// must not be used afterwards; only the stored name and value are copied.
+func (h Headers) parseLine(line []byte) error {
+	colon := bytes.IndexByte(line, ':')
+	if colon <= 0 || bytes.IndexAny(line[:colon], " \t") != -1 {
+		return fmt.Errorf("malformed header line %q", line)
+	}
+
+	name := line[:colon]
+	for i, c := range name {
+		if 'A' <= c && c <= 'Z' {
+			name[i] = c + ('a' - 'A')
+		}
+	}
+	value := bytes.Trim(line[colon+1:], " \t")
+
+	if existing, ok := h[string(name)]; ok {
+		h[string(name)] = existing + ", " + string(value)
+		return nil
+	}
+	h[internName(name)] = string(value)
+	return nil
+}
@@ -0,0 +1,115 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrPathTraversal is returned by DecodedPath for a path whose ".."
+	// segments climb above the root
+	ErrPathTraversal = errors.New("path escapes the root")
+	// ErrInvalidPath is returned by DecodedPath for a path that can't be
+	// decoded safely
+	ErrInvalidPath = errors.New("invalid path")
+)
+
+// splitTarget returns the path and query of a request target. An absolute
+// target ("http://host/path") is reduced to its path; an authority or "*"
+// target has none.
+func splitTarget(target string) (path, query string) {
+	path, query, _ = strings.Cut(target, "?")
+
+	if _, rest, ok := strings.Cut(path, "://"); ok {
+		if i := strings.IndexByte(rest, '/'); i != -1 {
+			return rest[i:], query
+		}
+		return "/", query
+	}
+	if !strings.HasPrefix(path, "/") {
+		return "", query
+	}
+	return path, query
+}
+
+// DecodedPath percent-decodes Path and resolves its "." and ".." segments,
+// so the result is safe to join onto a directory. A ".." that would climb
+// above the root fails with ErrPathTraversal. Malformed escapes, encoded
+// slashes ("%2F", which would change the segments) and NUL bytes fail with
+// ErrInvalidPath. A trailing slash is kept.
+func (rl RequestLine) DecodedPath() (string, error) {
+	if rl.Path == "" {
+		return "", fmt.Errorf("%w: %q has no path", ErrInvalidPath, rl.RequestTarget)
+	}
+
+	segments := strings.Split(rl.Path[1:], "/")
+	cleaned := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		decoded, err := unescape(segment)
+		if err != nil {
+			return "", err
+		}
+
+		switch decoded {
+		case "", ".":
+		case "..":
+			if len(cleaned) == 0 {
+				return "", fmt.Errorf("%w: %q", ErrPathTraversal, rl.Path)
+			}
+			cleaned = cleaned[:len(cleaned)-1]
+		default:
+			cleaned = append(cleaned, decoded)
+		}
+	}
+
+	path := "/" + strings.Join(cleaned, "/")
+	if len(cleaned) > 0 && strings.HasSuffix(rl.Path, "/") {
+		path += "/"
+	}
+	return path, nil
+}
+
+// unescape decodes the %XX sequences of one path segment
+func unescape(segment string) (string, error) {
+	if strings.IndexByte(segment, '%') == -1 {
+		return segment, nil
+	}
+
+	var b strings.Builder
+	b.Grow(len(segment))
+	for i := 0; i < len(segment); i++ {
+		if segment[i] != '%' {
+			b.WriteByte(segment[i])
+			continue
+		}
+		if i+2 >= len(segment) || !isHex(segment[i+1]) || !isHex(segment[i+2]) {
+			return "", fmt.Errorf("%w: malformed escape in %q", ErrInvalidPath, segment)
+		}
+
+		c := unhex(segment[i+1])<<4 | unhex(segment[i+2])
+		switch c {
+		case '/':
+			return "", fmt.Errorf("%w: encoded slash in %q", ErrInvalidPath, segment)
+		case 0:
+			return "", fmt.Errorf("%w: NUL byte in %q", ErrInvalidPath, segment)
+		}
+		b.WriteByte(c)
+		i += 2
+	}
+	return b.String(), nil
+}
+
+func isHex(c byte) bool {
+	return isDigit(c) || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case isDigit(c):
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	}
+	return c - 'A' + 10
+}
@@ -0,0 +1,67 @@
+package request
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitTarget(t *testing.T) {
+	tests := []struct {
+		target    string
+		wantPath  string
+		wantQuery string
+	}{
+		{target: "/", wantPath: "/"},
+		{target: "/coffee?size=large&milk", wantPath: "/coffee", wantQuery: "size=large&milk"},
+		{target: "http://localhost:42069/coffee?x=1", wantPath: "/coffee", wantQuery: "x=1"},
+		{target: "http://localhost:42069", wantPath: "/"},
+		{target: "*", wantPath: ""},
+	}
+
+	for _, tt := range tests {
+		path, query := splitTarget(tt.target)
+		assert.Equal(t, tt.wantPath, path, tt.target)
+		assert.Equal(t, tt.wantQuery, query, tt.target)
+	}
+}
+
+func TestRequestLine_DecodedPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr error
+	}{
+		{name: "root", path: "/", want: "/"},
+		{name: "plain", path: "/static/app.js", want: "/static/app.js"},
+		{name: "percent-encoded space", path: "/my%20file.txt", want: "/my file.txt"},
+		{name: "lowercase hex", path: "/caf%c3%a9", want: "/café"},
+		{name: "dot segments inside the root", path: "/a/./b/../c", want: "/a/c"},
+		{name: "duplicate slashes", path: "//a///b", want: "/a/b"},
+		{name: "trailing slash kept", path: "/docs/", want: "/docs/"},
+		{name: "back to the root", path: "/a/..", want: "/"},
+		{name: "traversal", path: "/../../etc/passwd", wantErr: ErrPathTraversal},
+		{name: "traversal after a segment", path: "/static/../../etc/passwd", wantErr: ErrPathTraversal},
+		{name: "encoded traversal", path: "/%2e%2e/etc/passwd", wantErr: ErrPathTraversal},
+		{name: "encoded slash", path: "/static%2F..%2F..%2Fetc/passwd", wantErr: ErrInvalidPath},
+		{name: "encoded slash alone", path: "/a%2fb", wantErr: ErrInvalidPath},
+		{name: "truncated escape", path: "/file%2", wantErr: ErrInvalidPath},
+		{name: "non-hex escape", path: "/file%zz", wantErr: ErrInvalidPath},
+		{name: "NUL byte", path: "/file%00.txt", wantErr: ErrInvalidPath},
+		{name: "no path", path: "", wantErr: ErrInvalidPath},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RequestLine{Path: tt.path}.DecodedPath()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -1,34 +1,436 @@
 package request
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
 	"io"
+	"strconv"
 	"strings"
 )
 
+// Method is an HTTP request method. Methods are case-sensitive.
+type Method string
+
+// The methods defined by RFC 9110 and RFC 5789
+const (
+	MethodGet     Method = "GET"
+	MethodHead    Method = "HEAD"
+	MethodPost    Method = "POST"
+	MethodPut     Method = "PUT"
+	MethodPatch   Method = "PATCH"
+	MethodDelete  Method = "DELETE"
+	MethodConnect Method = "CONNECT"
+	MethodOptions Method = "OPTIONS"
+	MethodTrace   Method = "TRACE"
+)
+
+// knownMethods is ordered by how common each method is
+var knownMethods = []Method{
+	MethodGet,
+	MethodPost,
+	MethodPut,
+	MethodDelete,
+	MethodPatch,
+	MethodHead,
+	MethodOptions,
+	MethodConnect,
+	MethodTrace,
+}
+
+// ErrUnsupportedVersion is returned for a well-formed HTTP version other than
+// 1.0 and 1.1; servers answer it with 505 HTTP Version Not Supported
+var ErrUnsupportedVersion = errors.New("unsupported HTTP version")
+
+// ErrBodyTooLarge is returned for a body longer than the parser's maximum,
+// whether announced by Content-Length or found while reading chunks;
+// servers answer it with 413 Content Too Large
+var ErrBodyTooLarge = errors.New("request body too large")
+
+// DefaultMaxBodySize is the body limit unless MaxBodySize says otherwise
+const DefaultMaxBodySize = 10 << 20
+
+// ErrUnknownMethod is returned for a well-formed method that isn't one of
+// the constants above, unless AllowUnknownMethods is set
+var ErrUnknownMethod = errors.New("unknown method")
+
 type Request struct {
 	RequestLine RequestLine
+	Headers     Headers
+	Body        []byte
+	// Trailers are the fields sent after a chunked body; empty otherwise
+	Trailers Headers
 }
 
 type RequestLine struct {
+	// HttpVersion is the version without its "HTTP/" prefix, e.g. "1.1"
 	HttpVersion   string
+	VersionMajor  int
+	VersionMinor  int
 	RequestTarget string
-	Method        string
+	// Path is the still percent-encoded path of RequestTarget, without the
+	// scheme and host of an absolute target; see DecodedPath
+	Path string
+	// RawQuery is the part of RequestTarget after "?", still encoded
+	RawQuery string
+	Method   Method
+}
+
+// Option configures RequestFromReader
+type Option func(*parser)
+
+// AllowUnknownMethods accepts extension methods such as PROPFIND. They must
+// still be uppercase tokens.
+func AllowUnknownMethods() Option {
+	return func(p *parser) {
+		p.allowUnknownMethods = true
+	}
+}
+
+// MaxBodySize limits the body to n bytes instead of DefaultMaxBodySize
+func MaxBodySize(n int) Option {
+	return func(p *parser) {
+		p.maxBodySize = n
+	}
+}
+
+// OnHeaders calls fn once the headers have been parsed, before any of the
+// body is read. An error from fn aborts parsing and is returned as is; a
+// server uses this to answer Expect: 100-continue.
+func OnHeaders(fn func(*Request) error) Option {
+	return func(p *parser) {
+		p.onHeaders = fn
+	}
+}
+
+type parserState int
+
+const (
+	parsingRequestLine parserState = iota
+	parsingHeaders
+	parsingBody
+	parsingChunkSize
+	parsingChunkData
+	parsingChunkEnd
+	parsingTrailers
+	done
+)
+
+const crlf = "\r\n"
+
+// bufferSize is the initial read buffer of RequestFromReader; it doubles
+// whenever a single line doesn't fit
+const bufferSize = 1024
+
+// parser builds a Request from input fed to it in arbitrary chunks
+type parser struct {
+	allowUnknownMethods bool
+	onHeaders           func(*Request) error
+	maxBodySize         int
+
+	state          parserState
+	req            Request
+	contentLength  int
+	chunkRemaining int
+}
+
+func newParser(opts ...Option) *parser {
+	p := &parser{req: Request{Headers: Headers{}, Trailers: Headers{}}, maxBodySize: DefaultMaxBodySize}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// parse consumes as much of data as it can and reports how many bytes it
+// used. Unconsumed bytes are an incomplete line that must be passed again,
+// followed by more input, on the next call.
+func (p *parser) parse(data []byte) (int, error) {
+	consumed := 0
+	for p.state != done {
+		n, err := p.parseStep(data[consumed:])
+		if err != nil {
+			return 0, err
+		}
+		if n == 0 {
+			// Need more data
+			break
+		}
+		consumed += n
+	}
+	return consumed, nil
+}
+
+// parseStep advances the state machine by at most one line or one chunk of
+// body
+func (p *parser) parseStep(data []byte) (int, error) {
+	switch p.state {
+	case parsingRequestLine:
+		line, n := cutLine(data)
+		if n == 0 {
+			return 0, nil
+		}
+		requestLine, err := p.parseRequestLine(line)
+		if err != nil {
+			return 0, err
+		}
+		p.req.RequestLine = requestLine
+		p.state = parsingHeaders
+		return n, nil
+
+	case parsingHeaders:
+		line, n := cutLine(data)
+		if n == 0 {
+			return 0, nil
+		}
+		if len(line) == 0 {
+			return n, p.endHeaders()
+		}
+		if err := p.req.Headers.parseLine(line); err != nil {
+			return 0, err
+		}
+		return n, nil
+
+	case parsingBody:
+		n := min(len(data), p.contentLength-len(p.req.Body))
+		p.req.Body = append(p.req.Body, data[:n]...)
+		if len(p.req.Body) == p.contentLength {
+			p.state = done
+		}
+		return n, nil
+
+	case parsingChunkSize, parsingChunkData, parsingChunkEnd, parsingTrailers:
+		return p.parseChunked(data)
+	}
+
+	return 0, nil
+}
+
+// endHeaders moves on to the body, or finishes when there is none. The
+// framing is checked before onHeaders, so a client is never told to send a
+// body that is then rejected.
+func (p *parser) endHeaders() error {
+	next, err := p.bodyState()
+	if err != nil {
+		return err
+	}
+
+	if p.onHeaders != nil {
+		if err := p.onHeaders(&p.req); err != nil {
+			return err
+		}
+	}
+
+	p.state = next
+	return nil
 }
 
-func RequestFromReader(reader io.Reader) (*Request, error) {
+// bodyState validates the body framing and returns the state that reads
+// the body
+func (p *parser) bodyState() (parserState, error) {
+	raw, ok := p.req.Headers.Get("Content-Length")
+	if encoding, chunked := p.req.Headers.Get("Transfer-Encoding"); chunked {
+		// Both framings at once is a request smuggling vector
+		if ok {
+			return 0, errors.New("Content-Length and Transfer-Encoding must not both be set")
+		}
+		if !strings.EqualFold(encoding, "chunked") {
+			return 0, fmt.Errorf("unsupported Transfer-Encoding %q", encoding)
+		}
+		return parsingChunkSize, nil
+	}
+	if !ok {
+		return done, nil
+	}
+
+	length, err := strconv.Atoi(raw)
+	if err != nil || length < 0 {
+		return 0, fmt.Errorf("invalid Content-Length %q", raw)
+	}
+	if length > p.maxBodySize {
+		return 0, fmt.Errorf("%w: Content-Length %d exceeds %d bytes", ErrBodyTooLarge, length, p.maxBodySize)
+	}
+	// The body grows as it arrives rather than being allocated up front,
+	// so a client can't reserve memory it never sends
+	p.contentLength = length
+	if length == 0 {
+		return done, nil
+	}
+	return parsingBody, nil
+}
+
+// cutLine returns the first CRLF-terminated line of data, sharing its
+// memory, and the number of bytes it takes up including the CRLF, or 0 when
+// no full line is there yet
+func cutLine(data []byte) ([]byte, int) {
+	end := bytes.Index(data, []byte(crlf))
+	if end == -1 {
+		return nil, 0
+	}
+	return data[:end], end + len(crlf)
+}
+
+// RequestFromReader reads a single request from reader, feeding the parser
+// whatever each Read returns. It returns io.EOF when reader ends before the
+// request begins. A *bufio.Reader is read without consuming anything past
+// the request, so it can be passed again for the next one on the same
+// connection.
+func RequestFromReader(reader io.Reader, opts ...Option) (*Request, error) {
+	p := newParser(opts...)
+	if br, ok := reader.(*bufio.Reader); ok {
+		return p.readBuffered(br)
+	}
 
-	var req Request
-	var str = make([]byte, 1024)
+	buf := make([]byte, bufferSize)
+	readTo := 0
+	started := false
+	for p.state != done {
+		if readTo == len(buf) {
+			grown := make([]byte, len(buf)*2)
+			copy(grown, buf)
+			buf = grown
+		}
+
+		n, readErr := reader.Read(buf[readTo:])
+		readTo += n
+		started = started || n > 0
+
+		consumed, err := p.parse(buf[:readTo])
+		if err != nil {
+			return nil, err
+		}
+		copy(buf, buf[consumed:readTo])
+		readTo -= consumed
+
+		if readErr != nil && p.state != done {
+			return nil, p.readError(readErr, started)
+		}
+	}
+
+	return &p.req, nil
+}
+
+// readBuffered parses straight out of br's buffer and discards only what
+// the parser consumed, so bytes of a pipelined request stay in br
+func (p *parser) readBuffered(br *bufio.Reader) (*Request, error) {
+	want := 1
+	started := false
+	for p.state != done {
+		data, readErr := br.Peek(max(want, br.Buffered()))
+		started = started || len(data) > 0
+
+		consumed, err := p.parse(data)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := br.Discard(consumed); err != nil {
+			return nil, err
+		}
+
+		if readErr != nil && p.state != done {
+			if errors.Is(readErr, bufio.ErrBufferFull) {
+				return nil, fmt.Errorf("line longer than %d bytes", br.Size())
+			}
+			return nil, p.readError(readErr, started)
+		}
+		want = len(data) - consumed + 1
+	}
+
+	return &p.req, nil
+}
 
-	_, err := reader.Read(str)
+// readError reports err from the underlying reader, telling a connection
+// closed between requests apart from one closed in the middle of one
+func (p *parser) readError(err error, started bool) error {
+	if !errors.Is(err, io.EOF) {
+		return err
+	}
+	if !started {
+		return io.EOF
+	}
+	return errors.New("incomplete request: unexpected EOF")
+}
+
+// parseRequestLine splits "<method> <target> <version>" in place; only the
+// target is copied into a new string
+func (p *parser) parseRequestLine(line []byte) (RequestLine, error) {
+	methodEnd := bytes.IndexByte(line, ' ')
+	targetEnd := -1
+	if methodEnd != -1 {
+		targetEnd = bytes.IndexByte(line[methodEnd+1:], ' ')
+	}
+	if targetEnd == -1 || bytes.IndexByte(line[methodEnd+1+targetEnd+1:], ' ') != -1 {
+		return RequestLine{}, fmt.Errorf("malformed request line %q: want method, target and version", line)
+	}
+	targetEnd += methodEnd + 1
+
+	method, err := p.parseMethod(line[:methodEnd])
+	if err != nil {
+		return RequestLine{}, err
+	}
+
+	version, major, minor, err := parseVersion(line[targetEnd+1:])
 	if err != nil {
-		return nil, err
+		return RequestLine{}, err
 	}
 
-	s := strings.Split(string(str), "\r\n")
+	target := string(line[methodEnd+1 : targetEnd])
+	path, query := splitTarget(target)
 
-	req.RequestLine.Method = s[0]
-  req.
+	return RequestLine{
+		Method:        method,
+		RequestTarget: target,
+		Path:          path,
+		RawQuery:      query,
+		HttpVersion:   version,
+		VersionMajor:  major,
+		VersionMinor:  minor,
+	}, nil
+}
 
-	return nil, nil
+// parseVersion splits "HTTP/<digit>.<digit>" into its major and minor
+// numbers. Only 1.0 and 1.1 are accepted; other well-formed versions wrap
+// ErrUnsupportedVersion.
+func parseVersion(b []byte) (version string, major, minor int, err error) {
+	v, ok := bytes.CutPrefix(b, []byte("HTTP/"))
+	if !ok || len(v) != 3 || !isDigit(v[0]) || v[1] != '.' || !isDigit(v[2]) {
+		return "", 0, 0, fmt.Errorf("malformed HTTP version %q", b)
+	}
+
+	major, minor = int(v[0]-'0'), int(v[2]-'0')
+	switch {
+	case major == 1 && minor == 0:
+		return "1.0", major, minor, nil
+	case major == 1 && minor == 1:
+		return "1.1", major, minor, nil
+	}
+	return "", 0, 0, fmt.Errorf("%w %q", ErrUnsupportedVersion, b)
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// parseMethod returns one of the Method constants without allocating;
+// only an allowed extension method is copied
+func (p *parser) parseMethod(b []byte) (Method, error) {
+	if len(b) == 0 {
+		return "", errors.New("missing method")
+	}
+	for _, c := range b {
+		if c < 'A' || c > 'Z' {
+			return "", fmt.Errorf("invalid method %q: methods are uppercase letters", b)
+		}
+	}
+
+	for _, method := range knownMethods {
+		if string(b) == string(method) {
+			return method, nil
+		}
+	}
+	if !p.allowUnknownMethods {
+		return "", fmt.Errorf("%w %q", ErrUnknownMethod, b)
+	}
+	return Method(b), nil
 }
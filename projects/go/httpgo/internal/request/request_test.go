@@ -1,6 +1,9 @@
 package request
 
 import (
+	"bufio"
+	"bytes"
+	"io"
 	"strings"
 	"testing"
 
@@ -13,7 +16,7 @@ func TestRequestLineParse(t *testing.T) {
 	r, err := RequestFromReader(strings.NewReader("GET / HTTP/1.1\r\nHost: localhost:42069\r\nUser-Agent: curl/7.81.0\r\nAccept: */*\r\n\r\n"))
 	require.NoError(t, err)
 	require.NotNil(t, r)
-	assert.Equal(t, "GET", r.RequestLine.Method)
+	assert.Equal(t, MethodGet, r.RequestLine.Method)
 	assert.Equal(t, "/", r.RequestLine.RequestTarget)
 	assert.Equal(t, "1.1", r.RequestLine.HttpVersion)
 
@@ -21,7 +24,7 @@ func TestRequestLineParse(t *testing.T) {
 	r, err = RequestFromReader(strings.NewReader("GET /coffee HTTP/1.1\r\nHost: localhost:42069\r\nUser-Agent: curl/7.81.0\r\nAccept: */*\r\n\r\n"))
 	require.NoError(t, err)
 	require.NotNil(t, r)
-	assert.Equal(t, "GET", r.RequestLine.Method)
+	assert.Equal(t, MethodGet, r.RequestLine.Method)
 	assert.Equal(t, "/coffee", r.RequestLine.RequestTarget)
 	assert.Equal(t, "1.1", r.RequestLine.HttpVersion)
 
@@ -29,3 +32,321 @@ func TestRequestLineParse(t *testing.T) {
 	_, err = RequestFromReader(strings.NewReader("/coffee HTTP/1.1\r\nHost: localhost:42069\r\nUser-Agent: curl/7.81.0\r\nAccept: */*\r\n\r\n"))
 	require.Error(t, err)
 }
+
+func TestRequestLineParse_Method(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		opts    []Option
+		want    Method
+		wantErr string
+	}{
+		{name: "known method", line: "POST /coffee HTTP/1.1", want: MethodPost},
+		{name: "lowercase method", line: "get / HTTP/1.1", wantErr: `invalid method "get": methods are uppercase letters`},
+		{name: "lowercase method with unknown allowed", line: "get / HTTP/1.1", opts: []Option{AllowUnknownMethods()}, wantErr: `invalid method "get"`},
+		{name: "empty method", line: " / HTTP/1.1", wantErr: "missing method"},
+		{name: "unknown method", line: "BREW /coffee HTTP/1.1", wantErr: `unknown method "BREW"`},
+		{name: "unknown method allowed", line: "PROPFIND / HTTP/1.1", opts: []Option{AllowUnknownMethods()}, want: Method("PROPFIND")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := RequestFromReader(strings.NewReader(tt.line+"\r\nHost: localhost:42069\r\n\r\n"), tt.opts...)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, r.RequestLine.Method)
+		})
+	}
+
+	_, err := RequestFromReader(strings.NewReader("BREW /coffee HTTP/1.1\r\n\r\n"))
+	assert.ErrorIs(t, err, ErrUnknownMethod)
+}
+
+func TestRequestLineParse_Version(t *testing.T) {
+	tests := []struct {
+		name      string
+		version   string
+		wantMajor int
+		wantMinor int
+		wantErr   error
+	}{
+		{name: "1.1", version: "HTTP/1.1", wantMajor: 1, wantMinor: 1},
+		{name: "1.0", version: "HTTP/1.0", wantMajor: 1, wantMinor: 0},
+		{name: "2.0 is unsupported", version: "HTTP/2.0", wantErr: ErrUnsupportedVersion},
+		{name: "1.2 is unsupported", version: "HTTP/1.2", wantErr: ErrUnsupportedVersion},
+		{name: "missing number", version: "HTTP/"},
+		{name: "missing minor", version: "HTTP/1"},
+		{name: "lowercase prefix", version: "http/1.1"},
+		{name: "multi-digit", version: "HTTP/10.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := RequestFromReader(strings.NewReader("GET / " + tt.version + "\r\n\r\n"))
+			if tt.wantMajor == 0 {
+				require.Error(t, err)
+				if tt.wantErr != nil {
+					assert.ErrorIs(t, err, tt.wantErr)
+				} else {
+					assert.ErrorContains(t, err, "malformed HTTP version")
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantMajor, r.RequestLine.VersionMajor)
+			assert.Equal(t, tt.wantMinor, r.RequestLine.VersionMinor)
+		})
+	}
+}
+
+// chunkReader returns at most numBytesPerRead bytes per Read, to simulate
+// input arriving over the network in pieces
+type chunkReader struct {
+	data            string
+	numBytesPerRead int
+	pos             int
+}
+
+func (cr *chunkReader) Read(p []byte) (int, error) {
+	if cr.pos >= len(cr.data) {
+		return 0, io.EOF
+	}
+	end := min(cr.pos+cr.numBytesPerRead, len(cr.data))
+	n := copy(p, cr.data[cr.pos:end])
+	cr.pos += n
+	return n, nil
+}
+
+func TestRequestFromReader_Chunked(t *testing.T) {
+	const raw = "POST /coffee HTTP/1.1\r\n" +
+		"Host: localhost:42069\r\n" +
+		"Accept: text/plain\r\n" +
+		"accept: application/json\r\n" +
+		"Content-Length: 13\r\n" +
+		"\r\n" +
+		"hello, world!"
+
+	for size := 1; size <= len(raw); size++ {
+		r, err := RequestFromReader(&chunkReader{data: raw, numBytesPerRead: size})
+		require.NoError(t, err, "chunk size %d", size)
+
+		assert.Equal(t, MethodPost, r.RequestLine.Method)
+		assert.Equal(t, "/coffee", r.RequestLine.RequestTarget)
+		host, _ := r.Headers.Get("Host")
+		assert.Equal(t, "localhost:42069", host)
+		accept, _ := r.Headers.Get("Accept")
+		assert.Equal(t, "text/plain, application/json", accept)
+		assert.Equal(t, "hello, world!", string(r.Body))
+	}
+}
+
+func TestRequestFromReader_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr string
+	}{
+		{name: "no blank line after headers", raw: "GET / HTTP/1.1\r\nHost: localhost\r\n", wantErr: "unexpected EOF"},
+		{name: "body shorter than Content-Length", raw: "POST / HTTP/1.1\r\nContent-Length: 10\r\n\r\nshort", wantErr: "unexpected EOF"},
+		{name: "space before colon", raw: "GET / HTTP/1.1\r\nHost : localhost\r\n\r\n", wantErr: "malformed header line"},
+		{name: "invalid Content-Length", raw: "POST / HTTP/1.1\r\nContent-Length: ten\r\n\r\n", wantErr: `invalid Content-Length "ten"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := RequestFromReader(&chunkReader{data: tt.raw, numBytesPerRead: 3})
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestRequestFromReader_BodyTooLarge(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr error
+	}{
+		{name: "Content-Length over the limit", raw: "POST / HTTP/1.1\r\nContent-Length: 11\r\n\r\n"},
+		{name: "Content-Length near max int", raw: "POST / HTTP/1.1\r\nContent-Length: 9223372036854775807\r\n\r\n"},
+		{name: "chunks adding up over the limit", raw: "POST / HTTP/1.1\r\nTransfer-Encoding: chunked\r\n\r\n6\r\nabcdef\r\n5\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := RequestFromReader(strings.NewReader(tt.raw), MaxBodySize(10))
+			assert.ErrorIs(t, err, ErrBodyTooLarge)
+		})
+	}
+
+	t.Run("Content-Length overflowing int", func(t *testing.T) {
+		_, err := RequestFromReader(strings.NewReader("POST / HTTP/1.1\r\nContent-Length: 99999999999999999999\r\n\r\n"))
+		assert.ErrorContains(t, err, "invalid Content-Length")
+	})
+
+	t.Run("at the limit", func(t *testing.T) {
+		r, err := RequestFromReader(strings.NewReader("POST / HTTP/1.1\r\nContent-Length: 10\r\n\r\n0123456789"), MaxBodySize(10))
+		require.NoError(t, err)
+		assert.Equal(t, "0123456789", string(r.Body))
+	})
+}
+
+func TestParser_Parse(t *testing.T) {
+	p := newParser()
+
+	// Half a request line is left for the next call
+	n, err := p.parse([]byte("GET / HT"))
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, parsingRequestLine, p.state)
+
+	n, err = p.parse([]byte("GET / HTTP/1.1\r\nHost: a"))
+	require.NoError(t, err)
+	assert.Equal(t, len("GET / HTTP/1.1\r\n"), n)
+	assert.Equal(t, parsingHeaders, p.state)
+
+	n, err = p.parse([]byte("Host: a\r\nContent-Length: 2\r\n\r\nh"))
+	require.NoError(t, err)
+	assert.Equal(t, len("Host: a\r\nContent-Length: 2\r\n\r\nh"), n)
+	assert.Equal(t, parsingBody, p.state)
+
+	n, err = p.parse([]byte("iextra"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "bytes past Content-Length are left alone")
+	assert.Equal(t, done, p.state)
+	assert.Equal(t, "hi", string(p.req.Body))
+}
+
+// benchRequest is a typical small request with a body
+const benchRequest = "POST /api/v1/coffee?size=large HTTP/1.1\r\n" +
+	"Host: localhost:42069\r\n" +
+	"User-Agent: curl/7.81.0\r\n" +
+	"Accept: */*\r\n" +
+	"Accept-Encoding: gzip, deflate\r\n" +
+	"Content-Type: application/json\r\n" +
+	"Content-Length: 23\r\n" +
+	"\r\n" +
+	`{"milk":true,"shots":2}`
+
+func BenchmarkRequestFromReader(b *testing.B) {
+	data := []byte(benchRequest)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := RequestFromReader(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkRequestFromReader_Chunked feeds the request 8 bytes per Read,
+// the way it trickles in from a slow connection
+func BenchmarkRequestFromReader_Chunked(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := RequestFromReader(&chunkReader{data: benchRequest, numBytesPerRead: 8}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseRequestLine(b *testing.B) {
+	p := newParser()
+	line := []byte("POST /api/v1/coffee?size=large HTTP/1.1")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.parseRequestLine(line); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkHeadersParseLine(b *testing.B) {
+	lines := [][]byte{
+		[]byte("Host: localhost:42069"),
+		[]byte("User-Agent: curl/7.81.0"),
+		[]byte("Accept: */*"),
+		[]byte("Accept-Encoding: gzip, deflate"),
+		[]byte("Content-Type: application/json"),
+		[]byte("Content-Length: 23"),
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		h := Headers{}
+		for _, line := range lines {
+			if err := h.parseLine(line); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestRequestFromReader_Buffered(t *testing.T) {
+	br := bufio.NewReaderSize(&chunkReader{
+		data:            "POST /one HTTP/1.1\r\nContent-Length: 3\r\n\r\nabcGET /two HTTP/1.1\r\n\r\n",
+		numBytesPerRead: 5,
+	}, 32)
+
+	r, err := RequestFromReader(br)
+	require.NoError(t, err)
+	assert.Equal(t, "/one", r.RequestLine.RequestTarget)
+	assert.Equal(t, "abc", string(r.Body))
+
+	// Nothing of the second request was consumed
+	r, err = RequestFromReader(br)
+	require.NoError(t, err)
+	assert.Equal(t, "/two", r.RequestLine.RequestTarget)
+
+	_, err = RequestFromReader(br)
+	assert.ErrorIs(t, err, io.EOF, "no further request")
+
+	_, err = RequestFromReader(bufio.NewReaderSize(strings.NewReader("GET /"+strings.Repeat("a", 32)+" HTTP/1.1\r\n\r\n"), 16))
+	assert.ErrorContains(t, err, "line longer than 16 bytes")
+}
+
+func TestRequestFromReader_ChunkedBody(t *testing.T) {
+	const raw = "POST /upload HTTP/1.1\r\n" +
+		"Transfer-Encoding: chunked\r\n" +
+		"Trailer: Content-MD5\r\n" +
+		"\r\n" +
+		"5\r\nhello\r\n" +
+		"8;name=value\r\n, world!\r\n" +
+		"0\r\n" +
+		"Content-MD5: 6cd3556deb0da54bca060b4c39479839\r\n" +
+		"\r\n"
+
+	for size := 1; size <= len(raw); size++ {
+		r, err := RequestFromReader(&chunkReader{data: raw, numBytesPerRead: size})
+		require.NoError(t, err, "chunk size %d", size)
+
+		assert.Equal(t, "hello, world!", string(r.Body))
+		md5, ok := r.Trailers.Get("Content-MD5")
+		assert.True(t, ok)
+		assert.Equal(t, "6cd3556deb0da54bca060b4c39479839", md5)
+		_, ok = r.Headers.Get("Content-MD5")
+		assert.False(t, ok, "trailers are kept apart from headers")
+	}
+}
+
+func TestRequestFromReader_ChunkedErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr string
+	}{
+		{name: "invalid size", raw: "POST / HTTP/1.1\r\nTransfer-Encoding: chunked\r\n\r\nzz\r\n", wantErr: `invalid chunk size "zz"`},
+		{name: "data longer than size", raw: "POST / HTTP/1.1\r\nTransfer-Encoding: chunked\r\n\r\n2\r\nabc\r\n0\r\n\r\n", wantErr: "chunk data is not followed by CRLF"},
+		{name: "no final chunk", raw: "POST / HTTP/1.1\r\nTransfer-Encoding: chunked\r\n\r\n2\r\nab\r\n", wantErr: "unexpected EOF"},
+		{name: "both framings", raw: "POST / HTTP/1.1\r\nTransfer-Encoding: chunked\r\nContent-Length: 2\r\n\r\n", wantErr: "must not both be set"},
+		{name: "other encoding", raw: "POST / HTTP/1.1\r\nTransfer-Encoding: gzip\r\n\r\n", wantErr: `unsupported Transfer-Encoding "gzip"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := RequestFromReader(strings.NewReader(tt.raw))
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,280 @@
+// Package response writes HTTP/1.1 responses for the server package
+package response
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"httpgo/internal/request"
+)
+
+type StatusCode int
+
+const (
+	StatusContinue                StatusCode = 100
+	StatusOK                      StatusCode = 200
+	StatusBadRequest              StatusCode = 400
+	StatusForbidden               StatusCode = 403
+	StatusNotFound                StatusCode = 404
+	StatusMethodNotAllowed        StatusCode = 405
+	StatusContentTooLarge         StatusCode = 413
+	StatusExpectationFailed       StatusCode = 417
+	StatusInternalServerError     StatusCode = 500
+	StatusHTTPVersionNotSupported StatusCode = 505
+)
+
+var reasonPhrases = map[StatusCode]string{
+	StatusContinue:                "Continue",
+	StatusOK:                      "OK",
+	StatusBadRequest:              "Bad Request",
+	StatusForbidden:               "Forbidden",
+	StatusNotFound:                "Not Found",
+	StatusMethodNotAllowed:        "Method Not Allowed",
+	StatusContentTooLarge:         "Content Too Large",
+	StatusExpectationFailed:       "Expectation Failed",
+	StatusInternalServerError:     "Internal Server Error",
+	StatusHTTPVersionNotSupported: "HTTP Version Not Supported",
+}
+
+// StatusText returns the reason phrase of status, e.g. "Not Found"
+func StatusText(status StatusCode) string {
+	return reasonPhrases[status]
+}
+
+// ErrWriteOrder is returned when a Writer method is called out of order
+var ErrWriteOrder = errors.New("response written out of order")
+
+type writerState int
+
+const (
+	statusNotWritten writerState = iota
+	statusWritten
+	headersWritten
+	bodyWritten
+	chunkedBodyDone
+)
+
+// Writer writes a response in the order HTTP requires: WriteStatusLine
+// once, then WriteHeaders once, then WriteBody any number of times. Calls
+// out of that order fail with ErrWriteOrder and write nothing. The headers
+// should come from GetDefaultHeaders so that Content-Length matches the
+// body.
+type Writer struct {
+	dst       io.Writer
+	req       *request.Request
+	state     writerState
+	keepAlive bool
+	chunked   bool
+}
+
+// NewWriter returns a Writer for the response to req on dst. req is nil
+// when the request couldn't be parsed.
+func NewWriter(dst io.Writer, req *request.Request) *Writer {
+	return &Writer{dst: dst, req: req}
+}
+
+// WriteStatusLine writes "HTTP/1.1 <code> <reason>"
+func (w *Writer) WriteStatusLine(status StatusCode) error {
+	if w.state != statusNotWritten {
+		return fmt.Errorf("%w: status line already written", ErrWriteOrder)
+	}
+
+	if _, err := fmt.Fprintf(w.dst, "HTTP/1.1 %d %s\r\n", status, reasonPhrases[status]); err != nil {
+		return err
+	}
+	w.state = statusWritten
+	return nil
+}
+
+// WriteHeaders writes h, sorted by name, and the blank line that ends them.
+// For a chunked body, set "Transfer-Encoding: chunked" instead of
+// Content-Length.
+func (w *Writer) WriteHeaders(h request.Headers) error {
+	switch w.state {
+	case statusNotWritten:
+		return fmt.Errorf("%w: headers written before the status line", ErrWriteOrder)
+	case headersWritten, bodyWritten, chunkedBodyDone:
+		return fmt.Errorf("%w: headers already written", ErrWriteOrder)
+	}
+
+	var buf bytes.Buffer
+	writeFields(&buf, h)
+	if _, err := buf.WriteTo(w.dst); err != nil {
+		return err
+	}
+
+	connection, ok := h.Get("Connection")
+	w.keepAlive = keepAlive(w.req) && !(ok && hasToken(connection, "close"))
+	w.state = headersWritten
+	return nil
+}
+
+// writeFields writes h sorted by name, then the blank line that ends a
+// header or trailer section
+func writeFields(buf *bytes.Buffer, h request.Headers) {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(buf, "%s: %s\r\n", name, h[name])
+	}
+	buf.WriteString("\r\n")
+}
+
+// WriteBody writes part of the body
+func (w *Writer) WriteBody(b []byte) (int, error) {
+	if err := w.checkBody(false); err != nil {
+		return 0, err
+	}
+
+	w.state = bodyWritten
+	return w.dst.Write(b)
+}
+
+// WriteChunkedBody writes b as one chunk of a body sent with
+// "Transfer-Encoding: chunked". Empty chunks are skipped, since a zero-size
+// chunk ends the body.
+func (w *Writer) WriteChunkedBody(b []byte) (int, error) {
+	if err := w.checkBody(true); err != nil {
+		return 0, err
+	}
+	w.state = bodyWritten
+	w.chunked = true
+	if len(b) == 0 {
+		return 0, nil
+	}
+
+	if _, err := fmt.Fprintf(w.dst, "%x\r\n", len(b)); err != nil {
+		return 0, err
+	}
+	n, err := w.dst.Write(b)
+	if err != nil {
+		return n, err
+	}
+	_, err = io.WriteString(w.dst, "\r\n")
+	return n, err
+}
+
+// WriteChunkedBodyDone ends a chunked body with the zero-size chunk and
+// then trailers, which may be nil. Each trailer should be advertised in
+// the Trailer header beforehand.
+func (w *Writer) WriteChunkedBodyDone(trailers request.Headers) error {
+	if err := w.checkBody(true); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("0\r\n")
+	writeFields(&buf, trailers)
+	if _, err := buf.WriteTo(w.dst); err != nil {
+		return err
+	}
+	w.state = chunkedBodyDone
+	return nil
+}
+
+// checkBody reports whether a body write may follow. Once a body has
+// started, plain and chunked writes can't be mixed: either would corrupt
+// the other's framing.
+func (w *Writer) checkBody(chunked bool) error {
+	switch w.state {
+	case headersWritten:
+		return nil
+	case bodyWritten:
+		if w.chunked != chunked {
+			return fmt.Errorf("%w: plain and chunked body writes mixed", ErrWriteOrder)
+		}
+		return nil
+	case chunkedBodyDone:
+		return fmt.Errorf("%w: chunked body already ended", ErrWriteOrder)
+	}
+	return fmt.Errorf("%w: body written before the headers", ErrWriteOrder)
+}
+
+// Started reports whether anything has been written
+func (w *Writer) Started() bool {
+	return w.state != statusNotWritten
+}
+
+// Finish completes a response the handler left unfinished: nothing at all
+// becomes an empty 200, a status line gets the default headers, and a
+// chunked body gets its final chunk
+func (w *Writer) Finish() error {
+	if w.chunked && w.state == bodyWritten {
+		return w.WriteChunkedBodyDone(nil)
+	}
+	if w.state == statusNotWritten {
+		if err := w.WriteStatusLine(StatusOK); err != nil {
+			return err
+		}
+	}
+	if w.state == statusWritten {
+		return w.WriteHeaders(GetDefaultHeaders(0, w.req))
+	}
+	return nil
+}
+
+// KeepAlive reports whether the connection may carry another request once
+// the response is complete: the request allows it and the headers didn't
+// say Connection: close
+func (w *Writer) KeepAlive() bool {
+	return w.state >= headersWritten && w.keepAlive
+}
+
+// GetDefaultHeaders returns the headers of a text/plain response to req
+// with a body of contentLen bytes. Connection says whether the connection
+// stays open: HTTP/1.1 keeps it unless the client sent Connection: close,
+// HTTP/1.0 closes it unless the client asked for keep-alive. A nil req (a
+// request that couldn't be parsed) always closes.
+func GetDefaultHeaders(contentLen int, req *request.Request) request.Headers {
+	h := request.Headers{}
+	h.Set("Content-Length", strconv.Itoa(contentLen))
+	h.Set("Content-Type", "text/plain")
+	if keepAlive(req) {
+		h.Set("Connection", "keep-alive")
+	} else {
+		h.Set("Connection", "close")
+	}
+	return h
+}
+
+func keepAlive(req *request.Request) bool {
+	if req == nil {
+		return false
+	}
+
+	connection, _ := req.Headers.Get("Connection")
+	if hasToken(connection, "close") {
+		return false
+	}
+	if req.RequestLine.VersionMajor == 1 && req.RequestLine.VersionMinor == 0 {
+		return hasToken(connection, "keep-alive")
+	}
+	return true
+}
+
+// hasToken reports whether the comma-separated header value contains token,
+// ignoring case
+func hasToken(value, token string) bool {
+	for _, part := range strings.Split(value, ",") {
+		if strings.EqualFold(strings.TrimSpace(part), token) {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteContinue sends the interim 100 Continue response that tells a client
+// waiting on Expect: 100-continue to send its body
+func WriteContinue(dst io.Writer) error {
+	_, err := fmt.Fprintf(dst, "HTTP/1.1 %d %s\r\n\r\n", StatusContinue, reasonPhrases[StatusContinue])
+	return err
+}
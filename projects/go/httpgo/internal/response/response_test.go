@@ -0,0 +1,225 @@
+package response
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"httpgo/internal/request"
+)
+
+func newRequest(minor int, connection string) *request.Request {
+	req := &request.Request{
+		RequestLine: request.RequestLine{VersionMajor: 1, VersionMinor: minor},
+		Headers:     request.Headers{},
+	}
+	if connection != "" {
+		req.Headers.Set("Connection", connection)
+	}
+	return req
+}
+
+func TestGetDefaultHeaders(t *testing.T) {
+	tests := []struct {
+		name           string
+		req            *request.Request
+		wantConnection string
+	}{
+		{name: "HTTP/1.1 keeps alive", req: newRequest(1, ""), wantConnection: "keep-alive"},
+		{name: "HTTP/1.1 with close", req: newRequest(1, "close"), wantConnection: "close"},
+		{name: "HTTP/1.1 with close among tokens", req: newRequest(1, "Upgrade, Close"), wantConnection: "close"},
+		{name: "HTTP/1.0 closes", req: newRequest(0, ""), wantConnection: "close"},
+		{name: "HTTP/1.0 with keep-alive", req: newRequest(0, "Keep-Alive"), wantConnection: "keep-alive"},
+		{name: "unparsed request", req: nil, wantConnection: "close"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := GetDefaultHeaders(42, tt.req)
+
+			assert.Equal(t, request.Headers{
+				"content-length": "42",
+				"content-type":   "text/plain",
+				"connection":     tt.wantConnection,
+			}, h)
+		})
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, newRequest(1, ""))
+
+	require.NoError(t, w.WriteStatusLine(StatusNotFound))
+	h := GetDefaultHeaders(2, newRequest(1, ""))
+	h.Set("Content-Type", "application/json")
+	require.NoError(t, w.WriteHeaders(h))
+	_, err := w.WriteBody([]byte("{"))
+	require.NoError(t, err)
+	_, err = w.WriteBody([]byte("}"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "HTTP/1.1 404 Not Found\r\n"+
+		"connection: keep-alive\r\n"+
+		"content-length: 2\r\n"+
+		"content-type: application/json\r\n"+
+		"\r\n"+
+		"{}", buf.String())
+	assert.True(t, w.KeepAlive())
+}
+
+func TestWriter_Order(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(w *Writer) error
+	}{
+		{
+			name: "body before status",
+			write: func(w *Writer) error {
+				_, err := w.WriteBody([]byte("hi"))
+				return err
+			},
+		},
+		{
+			name: "headers before status",
+			write: func(w *Writer) error {
+				return w.WriteHeaders(request.Headers{})
+			},
+		},
+		{
+			name: "body before headers",
+			write: func(w *Writer) error {
+				w.WriteStatusLine(StatusOK)
+				_, err := w.WriteBody([]byte("hi"))
+				return err
+			},
+		},
+		{
+			name: "status twice",
+			write: func(w *Writer) error {
+				w.WriteStatusLine(StatusOK)
+				return w.WriteStatusLine(StatusOK)
+			},
+		},
+		{
+			name: "headers twice",
+			write: func(w *Writer) error {
+				w.WriteStatusLine(StatusOK)
+				w.WriteHeaders(request.Headers{})
+				return w.WriteHeaders(request.Headers{})
+			},
+		},
+		{
+			name: "status after body",
+			write: func(w *Writer) error {
+				w.WriteStatusLine(StatusOK)
+				w.WriteHeaders(request.Headers{})
+				w.WriteBody([]byte("hi"))
+				return w.WriteStatusLine(StatusOK)
+			},
+		},
+		{
+			name: "chunk after the final chunk",
+			write: func(w *Writer) error {
+				w.WriteStatusLine(StatusOK)
+				w.WriteHeaders(request.Headers{})
+				w.WriteChunkedBodyDone(nil)
+				_, err := w.WriteChunkedBody([]byte("hi"))
+				return err
+			},
+		},
+		{
+			name: "headers after the final chunk",
+			write: func(w *Writer) error {
+				w.WriteStatusLine(StatusOK)
+				w.WriteHeaders(request.Headers{})
+				w.WriteChunkedBodyDone(nil)
+				return w.WriteHeaders(request.Headers{})
+			},
+		},
+		{
+			name: "body after the final chunk",
+			write: func(w *Writer) error {
+				w.WriteStatusLine(StatusOK)
+				w.WriteHeaders(request.Headers{})
+				w.WriteChunkedBodyDone(nil)
+				_, err := w.WriteBody([]byte("hi"))
+				return err
+			},
+		},
+		{
+			name: "final chunk twice",
+			write: func(w *Writer) error {
+				w.WriteStatusLine(StatusOK)
+				w.WriteHeaders(request.Headers{})
+				w.WriteChunkedBodyDone(nil)
+				return w.WriteChunkedBodyDone(nil)
+			},
+		},
+		{
+			name: "plain body after a chunk",
+			write: func(w *Writer) error {
+				w.WriteStatusLine(StatusOK)
+				w.WriteHeaders(request.Headers{})
+				w.WriteChunkedBody([]byte("hi"))
+				_, err := w.WriteBody([]byte("hi"))
+				return err
+			},
+		},
+		{
+			name: "chunk after a plain body",
+			write: func(w *Writer) error {
+				w.WriteStatusLine(StatusOK)
+				w.WriteHeaders(request.Headers{})
+				w.WriteBody([]byte("hi"))
+				_, err := w.WriteChunkedBody([]byte("hi"))
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := NewWriter(&buf, newRequest(1, ""))
+
+			assert.ErrorIs(t, tt.write(w), ErrWriteOrder)
+		})
+	}
+}
+
+func TestWriter_NothingWrittenAfterFinalChunk(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, newRequest(1, ""))
+	require.NoError(t, w.WriteStatusLine(StatusOK))
+	require.NoError(t, w.WriteHeaders(request.Headers{}))
+	_, err := w.WriteChunkedBody([]byte("hi"))
+	require.NoError(t, err)
+	require.NoError(t, w.WriteChunkedBodyDone(nil))
+	want := buf.String()
+
+	assert.Error(t, w.WriteHeaders(request.Headers{}))
+	_, err = w.WriteBody([]byte("late"))
+	assert.Error(t, err)
+	_, err = w.WriteChunkedBody([]byte("late"))
+	assert.Error(t, err)
+	assert.Error(t, w.WriteChunkedBodyDone(nil))
+	assert.NoError(t, w.Finish())
+
+	assert.Equal(t, want, buf.String())
+}
+
+func TestWriter_Finish(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, newRequest(1, "close"))
+
+	require.NoError(t, w.Finish())
+	assert.Equal(t, "HTTP/1.1 200 OK\r\n"+
+		"connection: close\r\n"+
+		"content-length: 0\r\n"+
+		"content-type: text/plain\r\n"+
+		"\r\n", buf.String())
+	assert.False(t, w.KeepAlive())
+}
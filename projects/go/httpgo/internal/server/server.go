@@ -0,0 +1,243 @@
+// Package server ties the request parser and the response writer together
+// into a minimal HTTP/1.1 server. Connections are kept alive and serve
+// requests one after another until the client or a response asks to close
+// (Connection: close, or an HTTP/1.0 request without keep-alive), a
+// request can't be parsed, or the server shuts down.
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"httpgo/internal/request"
+	"httpgo/internal/response"
+)
+
+// errExpectationFailed aborts parsing of a request whose Expect header the
+// server can't meet
+var errExpectationFailed = errors.New("expectation failed")
+
+// Handler writes the response to req
+type Handler func(w *response.Writer, req *request.Request)
+
+type Server struct {
+	listener net.Listener
+	handler  Handler
+
+	// mu guards closed and idle so that no connection is added to active,
+	// or goes idle, once Shutdown has started waiting
+	mu     sync.Mutex
+	closed bool
+	active sync.WaitGroup
+	idle   map[net.Conn]struct{}
+}
+
+// Serve listens on addr and serves every connection with handler in the
+// background until Close is called
+func Serve(addr string, handler Handler) (*Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{listener: listener, handler: handler, idle: make(map[net.Conn]struct{})}
+	go s.listen()
+
+	return s, nil
+}
+
+// Addr returns the address the server listens on
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Close stops accepting connections without waiting for the ones being
+// served
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+
+	return s.listener.Close()
+}
+
+// Shutdown stops accepting connections, closes idle keep-alive ones and
+// waits until those being served have finished, or ctx is done
+func (s *Server) Shutdown(ctx context.Context) error {
+	if err := s.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+
+	s.mu.Lock()
+	for conn := range s.idle {
+		// Unblocks the read waiting for the next request
+		conn.SetReadDeadline(time.Now())
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.active.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.closed
+}
+
+func (s *Server) listen() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Println(err)
+			continue
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.active.Add(1)
+		s.mu.Unlock()
+
+		go func() {
+			defer s.active.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+// handle serves the requests of one connection until the client or a
+// response closes it
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	br := bufio.NewReader(conn)
+	for s.waitForRequest(conn, br) {
+		if !s.serve(conn, br) {
+			return
+		}
+	}
+}
+
+// waitForRequest blocks until the next request starts to arrive. The
+// connection counts as idle meanwhile, so Shutdown can close it.
+func (s *Server) waitForRequest(conn net.Conn, br *bufio.Reader) bool {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return false
+	}
+	s.idle[conn] = struct{}{}
+	s.mu.Unlock()
+
+	_, err := br.Peek(1)
+
+	s.mu.Lock()
+	delete(s.idle, conn)
+	s.mu.Unlock()
+
+	return err == nil
+}
+
+// serve answers a single request and reports whether the connection stays
+// open. A panicking handler gets a 500 if it hadn't started its response,
+// instead of taking the listener down with it.
+func (s *Server) serve(conn net.Conn, br *bufio.Reader) (keepAlive bool) {
+	req, err := request.RequestFromReader(br, request.OnHeaders(func(req *request.Request) error {
+		return expectContinue(conn, req)
+	}))
+	if err != nil {
+		status := response.StatusBadRequest
+		switch {
+		case errors.Is(err, io.EOF):
+			return false
+		case errors.Is(err, request.ErrUnsupportedVersion):
+			status = response.StatusHTTPVersionNotSupported
+		case errors.Is(err, errExpectationFailed):
+			status = response.StatusExpectationFailed
+		case errors.Is(err, request.ErrBodyTooLarge):
+			status = response.StatusContentTooLarge
+		}
+		writeError(conn, status)
+		return false
+	}
+
+	bw := bufio.NewWriter(conn)
+	w := response.NewWriter(bw, req)
+	defer func() {
+		if v := recover(); v != nil {
+			log.Printf("panic serving %s: %v", conn.RemoteAddr(), v)
+			if !w.Started() {
+				writeError(conn, response.StatusInternalServerError)
+			}
+			keepAlive = false
+		}
+	}()
+
+	s.handler(w, req)
+	if err := w.Finish(); err != nil {
+		log.Println(err)
+		return false
+	}
+	if err := bw.Flush(); err != nil {
+		log.Println(err)
+		return false
+	}
+
+	return w.KeepAlive()
+}
+
+// expectContinue answers an Expect header before the body is read. Clients
+// sending 100-continue wait for the interim response before sending the
+// body; HTTP/1.0 clients don't know it, so they get none. Any other
+// expectation fails.
+func expectContinue(conn net.Conn, req *request.Request) error {
+	expect, ok := req.Headers.Get("Expect")
+	if !ok {
+		return nil
+	}
+	if !strings.EqualFold(expect, "100-continue") {
+		return errExpectationFailed
+	}
+	if req.RequestLine.VersionMinor == 0 {
+		return nil
+	}
+	return response.WriteContinue(conn)
+}
+
+// writeError answers a request that couldn't be served and closes the
+// connection
+func writeError(conn net.Conn, status response.StatusCode) {
+	w := response.NewWriter(conn, nil)
+	if err := w.WriteStatusLine(status); err != nil {
+		log.Println(err)
+		return
+	}
+	if err := w.WriteHeaders(response.GetDefaultHeaders(0, nil)); err != nil {
+		log.Println(err)
+	}
+}
@@ -0,0 +1,334 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"httpgo/internal/request"
+	"httpgo/internal/response"
+)
+
+// respond writes a 200 text response with extra headers
+func respond(t *testing.T, w *response.Writer, req *request.Request, body string, extra ...string) {
+	h := response.GetDefaultHeaders(len(body), req)
+	for i := 0; i+1 < len(extra); i += 2 {
+		h.Set(extra[i], extra[i+1])
+	}
+	assert.NoError(t, w.WriteStatusLine(response.StatusOK))
+	assert.NoError(t, w.WriteHeaders(h))
+	_, err := w.WriteBody([]byte(body))
+	assert.NoError(t, err)
+}
+
+// roundTrip sends raw to addr and parses the reply
+func roundTrip(t *testing.T, addr, raw string) (*http.Response, string) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = io.WriteString(conn, raw)
+	require.NoError(t, err)
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return resp, string(body)
+}
+
+func TestServe(t *testing.T) {
+	srv, err := Serve("127.0.0.1:0", func(w *response.Writer, req *request.Request) {
+		if req.RequestLine.RequestTarget == "/panic" {
+			panic("handler bug")
+		}
+		respond(t, w, req, req.RequestLine.RequestTarget, "X-Method", string(req.RequestLine.Method))
+	})
+	require.NoError(t, err)
+	defer srv.Close()
+	addr := srv.Addr().String()
+
+	tests := []struct {
+		name       string
+		raw        string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "echo", raw: "GET /coffee HTTP/1.1\r\nHost: localhost\r\n\r\n", wantStatus: http.StatusOK, wantBody: "/coffee"},
+		{name: "malformed request line", raw: "/coffee HTTP/1.1\r\n\r\n", wantStatus: http.StatusBadRequest},
+		{name: "unsupported version", raw: "GET / HTTP/2.0\r\n\r\n", wantStatus: http.StatusHTTPVersionNotSupported},
+		{name: "handler panic", raw: "GET /panic HTTP/1.1\r\n\r\n", wantStatus: http.StatusInternalServerError},
+		{name: "still serving after a panic", raw: "POST /tea HTTP/1.1\r\nContent-Length: 0\r\n\r\n", wantStatus: http.StatusOK, wantBody: "/tea"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := roundTrip(t, addr, tt.raw)
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Equal(t, tt.wantBody, body)
+			assert.Equal(t, int64(len(tt.wantBody)), resp.ContentLength)
+		})
+	}
+
+	resp, _ := roundTrip(t, addr, "PUT /x HTTP/1.1\r\n\r\n")
+	assert.Equal(t, "PUT", resp.Header.Get("X-Method"))
+}
+
+func TestServer_Shutdown(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	srv, err := Serve("127.0.0.1:0", func(w *response.Writer, req *request.Request) {
+		close(started)
+		<-release
+		respond(t, w, req, "finished")
+	})
+	require.NoError(t, err)
+	addr := srv.Addr().String()
+
+	type result struct {
+		status int
+		body   string
+	}
+	inFlight := make(chan result, 1)
+	go func() {
+		resp, body := roundTrip(t, addr, "GET / HTTP/1.1\r\n\r\n")
+		inFlight <- result{resp.StatusCode, body}
+	}()
+	<-started
+
+	shutdownErr := make(chan error, 1)
+	go func() { shutdownErr <- srv.Shutdown(context.Background()) }()
+
+	// New connections are refused while the in-flight one is still served
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+		}
+		return err != nil
+	}, time.Second, 10*time.Millisecond)
+	select {
+	case err := <-shutdownErr:
+		t.Fatalf("Shutdown returned %v before the request finished", err)
+	default:
+	}
+
+	close(release)
+	assert.Equal(t, result{http.StatusOK, "finished"}, <-inFlight)
+	assert.NoError(t, <-shutdownErr)
+}
+
+func TestServer_Shutdown_Timeout(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	srv, err := Serve("127.0.0.1:0", func(w *response.Writer, req *request.Request) {
+		close(started)
+		<-release
+	})
+	require.NoError(t, err)
+
+	conn, err := net.Dial("tcp", srv.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = io.WriteString(conn, "GET / HTTP/1.1\r\n\r\n")
+	require.NoError(t, err)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, srv.Shutdown(ctx), context.DeadlineExceeded)
+}
+
+func TestServe_ExpectContinue(t *testing.T) {
+	srv, err := Serve("127.0.0.1:0", func(w *response.Writer, req *request.Request) {
+		respond(t, w, req, string(req.Body))
+	})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetDeadline(time.Now().Add(time.Second)))
+
+	// Like curl, send the headers alone and wait for permission
+	_, err = io.WriteString(conn, "POST /upload HTTP/1.1\r\nContent-Length: 5\r\nExpect: 100-continue\r\n\r\n")
+	require.NoError(t, err)
+
+	reader := bufio.NewReader(conn)
+	interim := make([]byte, len("HTTP/1.1 100 Continue\r\n\r\n"))
+	_, err = io.ReadFull(reader, interim)
+	require.NoError(t, err)
+	assert.Equal(t, "HTTP/1.1 100 Continue\r\n\r\n", string(interim))
+
+	_, err = io.WriteString(conn, "hello")
+	require.NoError(t, err)
+
+	resp, err := http.ReadResponse(reader, nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "hello", string(body))
+}
+
+func TestServe_ExpectationFailed(t *testing.T) {
+	srv, err := Serve("127.0.0.1:0", func(w *response.Writer, req *request.Request) {
+		t.Error("handler called for an unmet expectation")
+	})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	resp, _ := roundTrip(t, srv.Addr().String(), "POST / HTTP/1.1\r\nContent-Length: 5\r\nExpect: 200-ok\r\n\r\n")
+	assert.Equal(t, http.StatusExpectationFailed, resp.StatusCode)
+}
+
+func TestServe_BodyTooLarge(t *testing.T) {
+	srv, err := Serve("127.0.0.1:0", func(w *response.Writer, req *request.Request) {
+		t.Error("handler called for an oversized body")
+	})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	resp, _ := roundTrip(t, srv.Addr().String(), "POST / HTTP/1.1\r\nContent-Length: 9223372036854775807\r\n\r\n")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+	// The server is still up
+	resp, _ = roundTrip(t, srv.Addr().String(), "POST / HTTP/1.1\r\nContent-Length: 99999999999999999999\r\n\r\n")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestServe_KeepAlive(t *testing.T) {
+	srv, err := Serve("127.0.0.1:0", func(w *response.Writer, req *request.Request) {
+		respond(t, w, req, req.RequestLine.RequestTarget)
+	})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	tests := []struct {
+		name      string
+		requests  string
+		wantBody  []string
+		wantClose bool
+	}{
+		{
+			name:     "HTTP/1.1 serves pipelined requests on one connection",
+			requests: "GET /one HTTP/1.1\r\n\r\nGET /two HTTP/1.1\r\n\r\n",
+			wantBody: []string{"/one", "/two"},
+		},
+		{
+			name:      "Connection: close",
+			requests:  "GET /one HTTP/1.1\r\nConnection: close\r\n\r\n",
+			wantBody:  []string{"/one"},
+			wantClose: true,
+		},
+		{
+			name:      "HTTP/1.0 closes by default",
+			requests:  "GET /one HTTP/1.0\r\n\r\n",
+			wantBody:  []string{"/one"},
+			wantClose: true,
+		},
+		{
+			name:     "HTTP/1.0 with keep-alive",
+			requests: "GET /one HTTP/1.0\r\nConnection: keep-alive\r\n\r\nGET /two HTTP/1.0\r\nConnection: keep-alive\r\n\r\n",
+			wantBody: []string{"/one", "/two"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := net.Dial("tcp", srv.Addr().String())
+			require.NoError(t, err)
+			defer conn.Close()
+			require.NoError(t, conn.SetDeadline(time.Now().Add(time.Second)))
+
+			_, err = io.WriteString(conn, tt.requests)
+			require.NoError(t, err)
+
+			reader := bufio.NewReader(conn)
+			for _, want := range tt.wantBody {
+				resp, err := http.ReadResponse(reader, nil)
+				require.NoError(t, err)
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				resp.Body.Close()
+
+				assert.Equal(t, want, string(body))
+				assert.Equal(t, tt.wantClose, resp.Close)
+			}
+
+			if tt.wantClose {
+				_, err = reader.ReadByte()
+				assert.ErrorIs(t, err, io.EOF, "the server closes the connection")
+			}
+		})
+	}
+}
+
+func TestServer_Shutdown_ClosesIdleConnections(t *testing.T) {
+	srv, err := Serve("127.0.0.1:0", func(w *response.Writer, req *request.Request) {})
+	require.NoError(t, err)
+
+	conn, err := net.Dial("tcp", srv.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = io.WriteString(conn, "GET / HTTP/1.1\r\n\r\n")
+	require.NoError(t, err)
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	require.NoError(t, err)
+	require.False(t, resp.Close)
+
+	// The connection is idle but open; Shutdown must not wait for it
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, srv.Shutdown(ctx))
+}
+
+func TestServe_ChunkedTrailers(t *testing.T) {
+	// Echoes a chunked request body and its trailers in a chunked response
+	srv, err := Serve("127.0.0.1:0", func(w *response.Writer, req *request.Request) {
+		h := response.GetDefaultHeaders(0, req)
+		delete(h, "content-length")
+		h.Set("Transfer-Encoding", "chunked")
+		h.Set("Trailer", "Content-MD5")
+
+		require.NoError(t, w.WriteStatusLine(response.StatusOK))
+		require.NoError(t, w.WriteHeaders(h))
+		for i := 0; i < len(req.Body); i += 4 {
+			_, err := w.WriteChunkedBody(req.Body[i:min(i+4, len(req.Body))])
+			require.NoError(t, err)
+		}
+		md5, _ := req.Trailers.Get("Content-MD5")
+		require.NoError(t, w.WriteChunkedBodyDone(request.Headers{"content-md5": md5}))
+	})
+	require.NoError(t, err)
+	defer srv.Close()
+
+	resp, body := roundTrip(t, srv.Addr().String(), "POST /echo HTTP/1.1\r\n"+
+		"Transfer-Encoding: chunked\r\n"+
+		"Trailer: Content-MD5\r\n"+
+		"\r\n"+
+		"d\r\nhello, world!\r\n"+
+		"0\r\n"+
+		"Content-MD5: 6cd3556deb0da54bca060b4c39479839\r\n"+
+		"\r\n")
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"chunked"}, resp.TransferEncoding)
+	assert.Equal(t, "hello, world!", body)
+	assert.Equal(t, "6cd3556deb0da54bca060b4c39479839", resp.Trailer.Get("Content-MD5"))
+}